@@ -0,0 +1,84 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// spanBefore returns the time the span, like "12m" or "2y", before t. The
+// units are d(ays), w(eeks), m(onths) and y(ears).
+func spanBefore(t time.Time, span string) (time.Time, error) {
+	if len(span) < 2 {
+		return time.Time{}, fmt.Errorf("invalid time span %q", span)
+	}
+	n, err := strconv.Atoi(span[:len(span)-1])
+	if err != nil || n < 0 {
+		return time.Time{}, fmt.Errorf("invalid time span %q", span)
+	}
+	switch span[len(span)-1] {
+	case 'd':
+		return t.AddDate(0, 0, -n), nil
+	case 'w':
+		return t.AddDate(0, 0, -7*n), nil
+	case 'm':
+		return t.AddDate(0, -n, 0), nil
+	case 'y':
+		return t.AddDate(-n, 0, 0), nil
+	default:
+		return time.Time{}, fmt.Errorf("invalid time span %q", span)
+	}
+}
+
+// getActivity sets the activity of each author to the number of commits,
+// each weighted by half for every half-life of age.
+func getActivity(authors []author, idx *authorIndex, commits []commit, halfLife string, now time.Time) error {
+	before, err := spanBefore(now, halfLife)
+	if err != nil {
+		return err
+	}
+	hl := now.Sub(before).Hours()
+	if hl <= 0 {
+		return fmt.Errorf("invalid half-life %q", halfLife)
+	}
+	for _, c := range commits {
+		if i, ok := idx.email(c.email); ok {
+			age := now.Sub(c.date).Hours()
+			if age < 0 {
+				age = 0
+			}
+			authors[i].activity += math.Pow(0.5, age/hl)
+		}
+	}
+	return nil
+}
+
+// activeAuthors returns the authors with commits within the window before
+// now, if a window is given, ordered by activity if byActivity is set.
+func activeAuthors(authors []author, window string, byActivity bool, now time.Time) ([]author, error) {
+	res := authors
+	if window != "" {
+		since, err := spanBefore(now, window)
+		if err != nil {
+			return nil, err
+		}
+		res = nil
+		for _, a := range authors {
+			if !a.last.Before(since) {
+				res = append(res, a)
+			}
+		}
+	}
+	if byActivity {
+		res = append([]author(nil), res...)
+		sortAuthors(res, func(a author) float64 { return a.activity })
+	}
+	return res, nil
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSpanBefore(t *testing.T) {
+	now := time.Date(2020, 3, 31, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"10d": time.Date(2020, 3, 21, 12, 0, 0, 0, time.UTC),
+		"2w":  time.Date(2020, 3, 17, 12, 0, 0, 0, time.UTC),
+		"1m":  time.Date(2020, 3, 2, 12, 0, 0, 0, time.UTC), // February 31st, normalized
+		"2y":  time.Date(2018, 3, 31, 12, 0, 0, 0, time.UTC),
+		"0d":  now,
+	}
+	for span, expected := range cases {
+		if got, err := spanBefore(now, span); err != nil || !got.Equal(expected) {
+			t.Errorf("spanBefore(%q) = %v, %v, expected %v", span, got, err, expected)
+		}
+	}
+	for _, span := range []string{"", "d", "12", "-1m", "1h", "xm"} {
+		if _, err := spanBefore(now, span); err == nil {
+			t.Errorf("no error for %q", span)
+		}
+	}
+}
+
+func TestGetActivity(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	authors := []author{
+		{name: "Alice A", emails: []string{"alice@example.com"}},
+		{name: "Bob B", emails: []string{"bob@example.com"}},
+	}
+	commits := []commit{
+		{email: "alice@example.com", date: now.AddDate(0, 0, -10)},
+		{email: "alice@example.com", date: now.AddDate(0, 0, -20)},
+		{email: "bob@example.com", date: now.Add(time.Hour)},
+	}
+	if err := getActivity(authors, newAuthorIndex(authors), commits, "10d", now); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(authors[0].activity-0.75) > 1e-9 || authors[1].activity != 1 {
+		t.Errorf("activity %v and %v, expected 0.75 and 1", authors[0].activity, authors[1].activity)
+	}
+	if err := getActivity(authors, newAuthorIndex(authors), commits, "0d", now); err == nil {
+		t.Error("no error for a zero half-life")
+	}
+}
+
+func TestActiveAuthors(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	authors := []author{
+		{name: "Alice A", last: now.AddDate(0, -1, 0), activity: 1},
+		{name: "Bob B", last: now.AddDate(-1, 0, 0), activity: 3},
+		{name: "Carol C", last: now.AddDate(0, 0, -1), activity: 2},
+	}
+	names := func(authors []author) []string {
+		var res []string
+		for _, a := range authors {
+			res = append(res, a.name)
+		}
+		return res
+	}
+
+	res, err := activeAuthors(authors, "6m", false, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(res); len(got) != 2 || got[0] != "Alice A" || got[1] != "Carol C" {
+		t.Errorf("active %q", got)
+	}
+	res, err = activeAuthors(authors, "", true, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := names(res); len(got) != 3 || got[0] != "Bob B" || got[1] != "Carol C" || got[2] != "Alice A" {
+		t.Errorf("by activity %q", got)
+	}
+	if authors[0].name != "Alice A" {
+		t.Error("the authors were sorted in place")
+	}
+}
+
+func TestActiveWindow(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Bob B <bob@example.com>", date: "2020-01-01T12:00:00Z", message: "Long ago"},
+		testCommit{author: "Alice A <alice@example.com>", message: "Just now"},
+	)
+	defer cleanup()
+
+	if out := mustRunMain(t, dir, "-active-window", "1y", "-names"); out != "Alice A\n" {
+		t.Errorf("unexpected output\n%s", out)
+	}
+	if _, stderr, code := runMain(t, dir, "-active-window", "1x", "-names"); code == 0 {
+		t.Errorf("exit code 0 for an invalid window\n%s", stderr)
+	}
+}
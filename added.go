@@ -0,0 +1,49 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// getAddedFiles attributes the number of files added in each commit to
+// the commit author.
+func getAddedFiles(authors []author, idx *authorIndex, commits []commit, added map[string][]string) {
+	for _, c := range commits {
+		if i, ok := idx.email(c.email); ok {
+			authors[i].addedFiles += len(added[c.hash])
+		}
+	}
+}
+
+// writeAddedFiles writes the number of files added per author, most files
+// first, skipping authors that didn't add any. With licenses, it also
+// writes how many of the files had a license header, and which.
+func writeAddedFiles(w io.Writer, authors []author, licenses bool) error {
+	sorted := make([]author, len(authors))
+	copy(sorted, authors)
+	sortAuthors(sorted, func(a author) float64 { return float64(a.addedFiles) })
+
+	bw := bufio.NewWriter(w)
+	for _, a := range sorted {
+		if a.addedFiles == 0 {
+			continue
+		}
+		if licenses {
+			fmt.Fprintf(bw, "%5d %5d %s", a.addedFiles, a.licensedFiles(), a.displayName())
+			if len(a.licenses) > 0 {
+				fmt.Fprintf(bw, " (%s)", licenseSummary(a.licenses))
+			}
+			fmt.Fprintf(bw, "\n")
+			continue
+		}
+		fmt.Fprintf(bw, "%5d %s\n", a.addedFiles, a.displayName())
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,23 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import "testing"
+
+func TestAddedFiles(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Bob B <bob@example.com>", date: "2020-01-01T12:00:00Z", message: "Add bob", files: map[string]string{"bob.txt": "bob\n", "docs/odd\tname.txt": "odd\n", "docs/båt.txt": "boat\n"}},
+		testCommit{author: "Alice A <alice@example.com>", date: "2020-02-01T12:00:00Z", message: "Add alice", files: map[string]string{"alice.txt": "alice\n"}},
+		testCommit{author: "Alice A <alice@example.com>", date: "2020-03-01T12:00:00Z", message: "Improve alice", files: map[string]string{"alice.txt": "alice\nmore\n", "bob.txt": "bob\nmore\n"}},
+		testCommit{author: "Carol C <carol@example.com>", date: "2020-04-01T12:00:00Z", message: "Nothing new"},
+	)
+	defer cleanup()
+
+	if out := mustRunMain(t, dir, "-added-files"); out != "    3 Bob B\n    1 Alice A\n" {
+		t.Errorf("unexpected output\n%s", out)
+	}
+}
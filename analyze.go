@@ -0,0 +1,377 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// An analysis is the result of walking the git history: the merged and
+// filtered authors with their statistics, and whatever else is needed to
+// render the selected outputs.
+type analysis struct {
+	authors      []author
+	stale        []author // listed authors without commits
+	listedEmails []string // emails listed in the AUTHORS file
+	scopes       []scope
+	credits      map[string][]credit
+	milestones   []milestone
+	trend        *trend
+	imports      []importCommit
+	modules      []module
+	maintenance  []maintainedBranch
+	dropped      []droppedAuthor
+	sample       int      // the one in n commits analyzed, if sampled
+	commits      []commit // the commits analyzed
+}
+
+// A runSummary counts what happened to the commits and identities in the
+// history, for the user to verify.
+type runSummary struct {
+	scanned      int // commits read from git
+	excluded     int // commits excluded, reverted or duplicated
+	reattributed int // commits given to their pull request author
+	merged       int // emails merged into an author by name
+	added        int // new authors found in the history
+	bots         int // authors matching -exclude-pattern
+	belowMin     int // authors with fewer than -min commits
+}
+
+func (s runSummary) write(w io.Writer) {
+	if s.reattributed > 0 {
+		fmt.Fprintf(w, "Reattributed %d squash merged commits to their pull request authors\n", s.reattributed)
+	}
+	fmt.Fprintf(w, "Scanned %d commits, excluded %d; merged %d emails by name, found %d new authors; filtered %d bots and %d below -min\n",
+		s.scanned, s.excluded, s.merged, s.added, s.bots, s.belowMin)
+}
+
+// analyze reads the AUTHORS file and git history according to the
+// options.
+func analyze(opts *options) *analysis {
+	mustRepository()
+	if opts.check && opts.authorsFile == "" && opts.authorsRef == "" {
+		fatal("-check requires -read-authors or -read-authors-ref")
+	}
+
+	// Load exclude hashes, if any
+	var exclude excludes
+	if opts.excludeHashes != "" {
+		exclude = readExcludes(opts.excludeHashes, opts.strict)
+	}
+
+	// Load existing AUTHORS, if any
+	var authors []author
+	var listedEmails []string
+	if opts.authorsFile != "" || opts.authorsRef != "" {
+		var lists [][]author
+		if opts.authorsRef != "" {
+			lists = append(lists, getAuthorsRef(opts.authorsRef))
+		}
+		for _, file := range opts.authorsFiles {
+			lists = append(lists, getAuthors(file))
+			if opts.versionCheck {
+				checkMetadataVersion(file, readAll(file))
+			}
+		}
+		authors = mergeAuthorLists(lists, !opts.noNameMatching)
+		for i := range authors {
+			authors[i].listed = true
+			for _, e := range authors[i].emails {
+				authors[i].setProvenance(e, provenanceListed)
+			}
+			listedEmails = append(listedEmails, authors[i].emails...)
+		}
+	}
+
+	// Index the thus known email addresses and names
+	if opts.strict {
+		listed := make(stringSet)
+		for _, a := range authors {
+			for _, e := range a.emails {
+				if listed.has(e) {
+					fatalf("strict: email %s is listed for more than one author", e)
+				}
+				listed.add(e)
+			}
+		}
+	}
+	idx := newAuthorIndex(authors)
+
+	// Read the git log, minus any commits we should ignore
+	var revs []string
+	if opts.allBranches {
+		revs = append(revs, "--branches")
+	}
+	for _, ref := range opts.refs {
+		revs = append(revs, "--glob="+ref)
+	}
+	multiBranch := len(revs) > 0
+	// The walks over the history are limited alike by -max-commits, while
+	// revs remains the revisions as such
+	walk := revs
+	if opts.maxCommits > 0 {
+		walk = append(revs[:len(revs):len(revs)], fmt.Sprintf("--max-count=%d", opts.maxCommits))
+	}
+	var summary runSummary
+	commits := readCommits(walk, opts.parallel)
+	summary.scanned = len(commits)
+	var rewrites []rewriteRule
+	if opts.rewriteFile != "" {
+		rewrites = readRewrites(opts.rewriteFile)
+		rewriteCommits(commits, rewrites)
+	}
+	if opts.githubBackfill != "" {
+		n, err := backfillCommits(commits, opts.githubBackfill, defaultBackfillCache())
+		if err != nil {
+			fatal("github-backfill:", err)
+		}
+		summary.reattributed = n
+	}
+	commits = exclude.filter(commits)
+	if multiBranch {
+		// The same change may be present on several branches
+		commits = dedupPatches(commits, patchIDs(walk))
+	}
+	if opts.excludeReverts {
+		commits = filterCommits(commits, revertPairs(commits))
+	}
+	if opts.notesRef != "" {
+		commits = applyCreditNotes(commits, creditNotes(opts.notesRef))
+	}
+	if opts.foldFixups {
+		commits = foldFixups(commits)
+	}
+	summary.excluded = summary.scanned - len(commits)
+	if opts.sample > 1 {
+		commits = sampleCommits(commits, opts.sample, opts.sampleRandom, opts.sampleSeed)
+		warnf(warnEstimate, "commit counts are %s", estimateNote(opts.sample))
+	}
+
+	// Grab the set of all known authors based on the git log, and add any
+	// missing ones to the authors list.
+	all := allAuthors(commits)
+	if opts.ssoFile != "" {
+		authors = applySSOMap(authors, idx, readSSOMap(opts.ssoFile), all)
+	}
+	var ring *keyRing
+	if opts.signingKeys {
+		ring = newKeyRing(commits, signingKeys(walk))
+	}
+	for _, id := range all {
+		email, name := id.email, id.name
+		if _, ok := idx.email(email); ok {
+			continue
+		}
+
+		if ring != nil {
+			if i, ok := ring.match(email, idx); ok {
+				// The same key signed commits under both emails
+				authors[i].emails = append(authors[i].emails, email)
+				authors[i].setProvenance(email, provenanceKey)
+				idx.addEmail(email, i)
+				continue
+			}
+		}
+
+		if i, ok := idx.name(name); ok && !opts.noNameMatching {
+			// We found a match on name
+			if opts.strict && !plausiblySamePerson(authors[i], email) {
+				fatalf("strict: %s <%s> matches an existing author by name only, but the email domains differ", name, email)
+			}
+			into := authors[i].name
+			if len(authors[i].emails) > 0 {
+				into += " <" + authors[i].emails[0] + ">"
+			}
+			warnf(warnNameMatch, "%s <%s> merged into %s by name only", name, email, into)
+			authors[i].emails = append(authors[i].emails, email)
+			authors[i].setProvenance(email, provenanceName)
+			idx.addEmail(email, i)
+			summary.merged++
+			continue
+		}
+
+		authors = append(authors, author{
+			name:   name,
+			emails: []string{email},
+		})
+		authors[len(authors)-1].setProvenance(email, provenanceNew)
+		idx.add(authors, len(authors)-1)
+		summary.added++
+	}
+
+	if opts.ldap != "" {
+		if err := applyLDAP(authors, opts.ldap); err != nil {
+			fatal("ldap:", err)
+		}
+	}
+	if opts.profilesDir != "" {
+		applyProfiles(authors, idx, opts.profilesDir)
+	}
+	if opts.deriveNicknames {
+		deriveNicknames(authors)
+	}
+
+	// Count commits per author, for ranking
+	getContributions(authors, idx, commits)
+	if opts.sample > 1 {
+		scaleContributions(authors, opts.sample)
+	}
+	if opts.decay != "" {
+		if err := getActivity(authors, idx, commits, opts.decay, opts.now()); err != nil {
+			fatal("decay:", err)
+		}
+	}
+	if opts.teamFile != "" || opts.teamOrg != "" {
+		var members []string
+		if opts.teamFile != "" {
+			members = readLines(opts.teamFile)
+		}
+		if opts.teamOrg != "" {
+			users, err := githubOrgMembers(opts.teamOrg)
+			if err != nil {
+				fatal("team-org:", err)
+			}
+			members = append(members, users...)
+		}
+		getTeam(authors, newPolicy(members))
+	}
+	if opts.printMergeStats {
+		getMergeStats(authors, idx, commits, firstParents(revs))
+	}
+	if opts.printAddedFiles || opts.licenseHeaders {
+		added := addedFiles(walk)
+		getAddedFiles(authors, idx, commits, added)
+		if opts.licenseHeaders {
+			getLicenseHeaders(authors, idx, commits, added)
+		}
+	}
+	if opts.printVelocity {
+		getReleaseVelocity(authors, idx, commits, releasedIn(releaseTags(opts.releaseTags)))
+	}
+	var imports []importCommit
+	if opts.printLines || opts.printImports || opts.printOutliers {
+		var rules excludes
+		if opts.importsFile != "" {
+			rules = readExcludes(opts.importsFile, opts.strict)
+		}
+		lines := lineCounts(walk)
+		imports = getImports(commits, lines, rules, opts.importThreshold)
+		getLines(authors, idx, commits, lines, imports)
+		if opts.printOutliers {
+			getLargest(authors, idx, commits, lines, imports)
+		}
+	}
+	if opts.printBlame {
+		cacheFile := opts.blameCache
+		if cacheFile == "" && !opts.noBlameCache {
+			cacheFile = defaultBlameCache()
+		}
+		getBlameLines(authors, idx, blameLines(opts.parallel, cacheFile), rewrites)
+	}
+	if opts.printCategories {
+		rules := defaultCategoryRules
+		if opts.categoryRules != "" {
+			rules = readCategoryRules(opts.categoryRules)
+		}
+		getCategories(authors, idx, commits, rules)
+	}
+	if opts.tagCredit {
+		authors = getTagged(authors, idx, annotatedTags(opts.releaseTags), !opts.noNameMatching, opts.printCategories)
+	}
+
+	// Entries in the AUTHORS file without commits are stale, which we
+	// need to know before they are filtered out below, for -check now or
+	// after an import.
+	stale := staleAuthors(authors)
+
+	// Generate the path scoped lists, based on all authors
+	keep := func(a author) bool {
+		drop, _ := dropReason(a, opts)
+		return drop == 0
+	}
+	var scopes []scope
+	if opts.scopesFile != "" {
+		scopes = getScopes(readScopes(opts.scopesFile), walk, authors, idx, commits, keep)
+	}
+	var modules []module
+	if opts.modulesFile != "" {
+		modules = getModules(readModules(opts.modulesFile), walk, authors, idx, commits, keep)
+	}
+	var maintenance []maintainedBranch
+	if opts.maintenance != "" {
+		maintenance = getMaintenance(maintenanceBranches(opts.maintenance), opts.maintenanceBase, authors, exclude, rewrites, opts.parallel, keep)
+	}
+
+	// Filter on minimum contributions
+	var kept []author
+	var dropped []droppedAuthor
+	for _, a := range authors {
+		drop, reason := dropReason(a, opts)
+		switch drop {
+		case 0:
+			kept = append(kept, a)
+			continue
+		case dropExcluded:
+			summary.bots++
+		case dropBelowMin:
+			summary.belowMin++
+		}
+		dropped = append(dropped, droppedAuthor{author: a, reason: reason})
+	}
+	authors = kept
+	sort.SliceStable(dropped, func(i, j int) bool {
+		return lessByName(dropped[i].author, dropped[j].author)
+	})
+	disambiguate(authors)
+
+	var credits map[string][]credit
+	if opts.printCredits || opts.printThanks {
+		credits = getCredits(commits, authors, newAuthorIndex(authors), opts.creditKeys())
+	}
+
+	var milestones []milestone
+	if opts.printMilestones {
+		var err error
+		milestones, err = getMilestones(authors, newAuthorIndex(authors), commits, opts.milestoneWindow, opts.now())
+		if err != nil {
+			fatal("milestones:", err)
+		}
+	}
+
+	var tr *trend
+	if opts.trend != "" {
+		sortByName(authors)
+		var err error
+		tr, err = getTrend(authors, newAuthorIndex(authors), commits, opts.trend, opts.now())
+		if err != nil {
+			fatal("trend:", err)
+		}
+	}
+
+	if !opts.quiet {
+		summary.write(os.Stderr)
+	}
+
+	return &analysis{
+		authors:      authors,
+		stale:        stale,
+		listedEmails: listedEmails,
+		scopes:       scopes,
+		credits:      credits,
+		milestones:   milestones,
+		trend:        tr,
+		imports:      imports,
+		modules:      modules,
+		maintenance:  maintenance,
+		dropped:      dropped,
+		sample:       opts.sample,
+		commits:      commits,
+	}
+}
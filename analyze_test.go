@@ -0,0 +1,177 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaxCommitsMergeStats(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+
+	out := mustRunMain(t, dir, "-merge-stats", "-max-commits", "1", "-stats")
+	if !strings.Contains(out, "Carol C") {
+		t.Errorf("expected Carol in the stats, got\n%s", out)
+	}
+	if strings.Contains(out, "Bob B") {
+		t.Errorf("expected only the most recent commit, got\n%s", out)
+	}
+}
+
+func TestMaxCommitsCheck(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	authors := writeTestFile(t, dir, "AUTHORS", "Alice A <alice@example.com>\nBob B <bob@example.com>\nCarol C <carol@example.com>\n")
+
+	// Bob has no commits among the most recent, but is no less listed
+	stdout, stderr, code := runMain(t, dir, "-read-authors", authors, "-check", "-max-commits", "1")
+	if code != 0 {
+		t.Errorf("exit code %d, expected 0\n%s%s", code, stdout, stderr)
+	}
+	if strings.Contains(stdout, "Stale") {
+		t.Errorf("unexpected stale authors:\n%s", stdout)
+	}
+
+	// A new author among them is still found
+	commitFiles(t, dir, testCommit{author: "Dave D <dave@example.com>", message: "Add dave", files: map[string]string{"dave.txt": "dave\n"}})
+	stdout, _, code = runMain(t, dir, "-read-authors", authors, "-check", "-max-commits", "1")
+	if code != 1 || !strings.Contains(stdout, "Missing author: Dave D") {
+		t.Errorf("exit code %d, expected Dave missing:\n%s", code, stdout)
+	}
+
+	// Without the limit, authors without commits are stale as before
+	authors = writeTestFile(t, dir, "AUTHORS", "Alice A <alice@example.com>\nBob B <bob@example.com>\nCarol C <carol@example.com>\nDave D <dave@example.com>\nEve E <eve@example.com>\n")
+	stdout, _, code = runMain(t, dir, "-read-authors", authors, "-check")
+	if code != 1 || !strings.Contains(stdout, "Stale author: Eve E") {
+		t.Errorf("exit code %d, expected Eve stale:\n%s", code, stdout)
+	}
+}
+
+func TestStrict(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Alice A <alice@corp-a.example.com>", date: "2020-01-01T12:00:00Z", message: "At A"},
+		testCommit{author: "Alice A <alice@corp-b.example.com>", date: "2020-02-01T12:00:00Z", message: "At B"},
+		testCommit{author: "Bob B <bob@corp-a.example.com>", date: "2020-03-01T12:00:00Z", message: "At A"},
+		testCommit{author: "Bob B <bob.b@gmail.com>", date: "2020-04-01T12:00:00Z", message: "At home"},
+	)
+	defer cleanup()
+
+	// Guessing, Alice is one person
+	if out := mustRunMain(t, dir, "-authors"); !containsLine(out, "Alice A <alice@corp-a.example.com> <alice@corp-b.example.com>") {
+		t.Errorf("unexpected output\n%s", out)
+	}
+	_, stderr, code := runMain(t, dir, "-strict", "-authors")
+	if code == 0 || !strings.Contains(stderr, "strict: Alice A <alice@corp-b.example.com> matches an existing author by name only, but the email domains differ") {
+		t.Errorf("exit code %d for an ambiguous match\n%s", code, stderr)
+	}
+
+	// Bob's freemail address is plausibly Bob's
+	authors := writeTestFile(t, dir, "AUTHORS", "Alice A <alice@corp-a.example.com> <alice@corp-b.example.com>\n")
+	if out := mustRunMain(t, dir, "-read-authors", authors, "-strict", "-authors"); !containsLine(out, "Bob B <bob@corp-a.example.com> <bob.b@gmail.com>") {
+		t.Errorf("unexpected output\n%s", out)
+	}
+
+	writeTestFile(t, dir, "AUTHORS", "Alice A <alice@corp-a.example.com>\nAlice Other <alice@corp-a.example.com>\n")
+	if _, stderr, code := runMain(t, dir, "-read-authors", authors, "-strict", "-authors"); code == 0 || !strings.Contains(stderr, "strict: email alice@corp-a.example.com is listed for more than one author") {
+		t.Errorf("exit code %d for an email listed twice\n%s", code, stderr)
+	}
+}
+
+func TestAllBranches(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	carol := revParse(t, dir, "HEAD")
+	runGit(t, dir, "checkout", "-q", "-b", "release-1", "HEAD~1")
+	runGit(t, dir, "cherry-pick", carol)
+	commitFiles(t, dir, testCommit{author: "Eve E <eve@example.com>", date: "2020-05-01T12:00:00Z", message: "Fix for release only"})
+	runGit(t, dir, "checkout", "-q", "-")
+
+	if out := mustRunMain(t, dir, "-stats"); strings.Contains(out, "Eve E") {
+		t.Errorf("release branch counted\n%s", out)
+	}
+	// The cherry picked commit counts once
+	if out := mustRunMain(t, dir, "-all-branches", "-stats"); !containsLine(out, "    1  0 Carol C") || !containsLine(out, "    1  0 Eve E") {
+		t.Errorf("unexpected output with -all-branches\n%s", out)
+	}
+}
+
+func TestRefs(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	runGit(t, dir, "checkout", "-q", "-b", "release-1", "HEAD~1")
+	commitFiles(t, dir, testCommit{author: "Eve E <eve@example.com>", date: "2020-05-01T12:00:00Z", message: "Fix for release only"})
+	runGit(t, dir, "tag", "v1")
+	runGit(t, dir, "checkout", "-q", "-")
+	runGit(t, dir, "branch", "-D", "release-1")
+
+	out := mustRunMain(t, dir, "-refs", "tags/*", "-stats")
+	if !containsLine(out, "    1  0 Eve E") || strings.Contains(out, "Carol C") {
+		t.Errorf("unexpected output with -refs\n%s", out)
+	}
+	out = mustRunMain(t, dir, "-refs", "tags/*", "-refs", "heads/*", "-stats")
+	if !containsLine(out, "    1  0 Eve E") || !containsLine(out, "    1  0 Carol C") {
+		t.Errorf("unexpected output with two -refs\n%s", out)
+	}
+}
+
+func TestRunSummary(t *testing.T) {
+	commits := append(threeAuthors[:len(threeAuthors):len(threeAuthors)],
+		testCommit{author: "Alice A <alice@laptop.local>", date: "2020-05-01T12:00:00Z", message: "From the laptop"},
+		testCommit{author: "dependabot[bot] <bot@example.com>", date: "2020-06-01T12:00:00Z", message: "Bump"},
+	)
+	dir, cleanup := newHistoryRepo(t, commits...)
+	defer cleanup()
+	authors := writeTestFile(t, dir, "AUTHORS", "Alice A <alice@example.com>\n")
+	excludes := writeTestFile(t, dir, "excludes", revParse(t, dir, "HEAD~5")+"\n")
+
+	_, stderr, code := runMain(t, dir, "-read-authors", authors, "-exclude-commits", excludes, "-min", "2", "-stats")
+	if code != 0 {
+		t.Fatalf("exit code %d\n%s", code, stderr)
+	}
+	summary := "Scanned 6 commits, excluded 1; merged 1 emails by name, found 2 new authors; filtered 1 bots and 1 below -min"
+	if !containsLine(stderr, summary) {
+		t.Errorf("no summary %q in\n%s", summary, stderr)
+	}
+
+	if _, stderr, _ := runMain(t, dir, "-read-authors", authors, "-quiet", "-stats"); strings.Contains(stderr, "Scanned") {
+		t.Errorf("summary with -quiet\n%s", stderr)
+	}
+}
+
+func TestNameMatching(t *testing.T) {
+	commits := append(threeAuthors[:len(threeAuthors):len(threeAuthors)],
+		testCommit{author: "Alice A <alice@laptop.local>", date: "2020-05-01T12:00:00Z", message: "From the laptop"})
+	dir, cleanup := newHistoryRepo(t, commits...)
+	defer cleanup()
+	authors := writeTestFile(t, dir, "AUTHORS", "Alice A <alice@example.com>\nCarol C\n")
+
+	stdout, stderr, code := runMain(t, dir, "-read-authors", authors, "-authors")
+	if code != 0 {
+		t.Fatalf("exit code %d\n%s", code, stderr)
+	}
+	for _, msg := range []string{
+		"Alice A <alice@laptop.local> merged into Alice A <alice@example.com> by name only",
+		"Carol C <carol@example.com> merged into Carol C by name only",
+	} {
+		if !strings.Contains(stderr, msg) {
+			t.Errorf("no warning %q in\n%s", msg, stderr)
+		}
+	}
+	if !containsLine(stdout, "Alice A <alice@example.com> <alice@laptop.local>") {
+		t.Errorf("unexpected output\n%s", stdout)
+	}
+
+	stdout, stderr, _ = runMain(t, dir, "-read-authors", authors, "-no-name-matching", "-authors")
+	if strings.Contains(stderr, "by name only") {
+		t.Errorf("name matched with -no-name-matching\n%s", stderr)
+	}
+	if !containsLine(stdout, "Alice A <alice@example.com>") || !containsLine(stdout, "Alice A <alice@laptop.local>") {
+		t.Errorf("unexpected output with -no-name-matching\n%s", stdout)
+	}
+}
@@ -7,34 +7,141 @@
 package main
 
 import (
-	"bytes"
-	"flag"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"math"
 	"os"
-	"os/exec"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var (
 	nicknameRe = regexp.MustCompile(`\(([^\s]*)\)`)
 	emailRe    = regexp.MustCompile(`<([^\s]*)>`)
+	urlRe      = regexp.MustCompile(`^<?(https?://[^\s>]+)>?$`)
 )
 
 type author struct {
-	name     string
-	nickname string
-	emails   []string
-	commits  int
-	geekrank int
+	name         string
+	nickname     string
+	emails       []string
+	commits      int
+	geekrank     int
+	daysActive   int            // distinct days with commits
+	dayStreak    int            // longest run of consecutive days with commits
+	weekStreak   int            // longest run of consecutive weeks with commits
+	first        time.Time      // date of the first commit
+	last         time.Time      // date of the most recent commit
+	listed       bool           // read from the AUTHORS file
+	categories   map[string]int // commits per category, when categorized
+	direct       int            // commits on the mainline, when analyzed
+	merged       int            // commits merged into the mainline, when analyzed
+	addedFiles   int            // files added, when analyzed
+	licenses     map[string]int // added files by license header, when analyzed
+	blameLines   int            // lines surviving at HEAD, when analyzed
+	lines        int            // lines changed, except in imports, when analyzed
+	tagged       int            // annotated release tags created, when analyzed
+	largest      []largeCommit  // largest commits, when analyzed
+	activity     float64        // decay weighted commits, when analyzed
+	qualifier    string         // tells apart authors with the same name, when needed
+	styledName   string         // the name in the -name-style, when not full
+	keptID       string         // the id, kept when emails are suppressed
+	team         bool           // member of the core team, when analyzed
+	releaseWeeks []int          // released commits by weeks before the release, when analyzed
+	pin          int            // fixed 1-based position in the list, or zero
+	nicknames    []string       // additional nicknames, beyond the first
+	note         string         // free text annotation, such as "original author"
+	pronouns     string         // as given in the author's profile
+	department   string         // from the LDAP directory, when looked up
+	chosenAvatar string         // avatar image URL given in the author's profile
+	tags         []string
+	urls         []string
+	provenance   map[string]string // email -> how it came to belong to the author
+	avatar       string            // avatar image URL or path, when resolved
+	avatar2x     string            // the same at twice the size
 }
 
-// The displayName is the name followed by nickname, if any
+// Provenance values, describing how an email address came to belong to an
+// author.
+const (
+	provenanceListed = "listed" // listed in the AUTHORS file
+	provenanceName   = "name"   // matched on the name in the git log
+	provenanceNew    = "new"    // a new author from the git log
+	provenanceKey    = "key"    // signed commits with a key of the author's
+	provenanceSSO    = "sso"    // mapped to the author's SSO identity
+)
+
+func (a *author) setProvenance(email, how string) {
+	if a.provenance == nil {
+		a.provenance = make(map[string]string)
+	}
+	a.provenance[email] = how
+}
+
+// The id is a stable identifier for the author, derived from the canonical
+// (first) email address so that it survives changes to the name or the set
+// of secondary emails.
+func (a author) id() string {
+	if a.keptID != "" {
+		return a.keptID
+	}
+	if len(a.emails) == 0 {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(strings.ToLower(a.emails[0])))
+	return hex.EncodeToString(hash[:8])
+}
+
+// The years are the range of years the author has been active, like
+// "2015-2019", or just "2019" when there is only the one.
+func (a author) years() string {
+	if a.first.IsZero() {
+		return ""
+	}
+	if a.first.Year() == a.last.Year() {
+		return strconv.Itoa(a.first.Year())
+	}
+	return fmt.Sprintf("%d-%d", a.first.Year(), a.last.Year())
+}
+
+// The url is the author's primary home page or forge profile, if any.
+func (a author) url() string {
+	if len(a.urls) == 0 {
+		return ""
+	}
+	return a.urls[0]
+}
+
+// The displayName is the name followed by nickname, if any, and the
+// qualifier telling it apart from other authors with the same name.
 func (a author) displayName() string {
+	s := a.fullName()
+	if a.qualifier != "" {
+		s = s + " " + a.qualifier
+	}
+	return s
+}
+
+// The shownName is the name as shown in the lists of names: the
+// displayName, or the styled name and qualifier with a -name-style.
+func (a author) shownName() string {
+	if a.styledName == "" {
+		return a.displayName()
+	}
+	if a.qualifier != "" {
+		return a.styledName + " " + a.qualifier
+	}
+	return a.styledName
+}
+
+// The fullName is the name followed by nickname, if any, as written in the
+// AUTHORS file.
+func (a author) fullName() string {
 	s := a.name
 	if a.hasNickName() {
 		s = s + " (" + a.nickname + ")"
@@ -42,6 +149,41 @@ func (a author) displayName() string {
 	return s
 }
 
+// disambiguate sets a qualifier on authors that would otherwise be shown
+// with the same name: the nickname, when it isn't already shown, or else
+// the email domain, or else the email address.
+func disambiguate(authors []author) {
+	byName := make(map[string][]int)
+	for i := range authors {
+		authors[i].qualifier = ""
+		key := strings.ToLower(authors[i].shownName())
+		byName[key] = append(byName[key], i)
+	}
+
+	for _, idxs := range byName {
+		if len(idxs) < 2 {
+			continue
+		}
+		seen := make(map[string]int)
+		for _, i := range idxs {
+			a := &authors[i]
+			switch {
+			case a.nickname != "" && (a.styledName != "" || !a.hasNickName()):
+				a.qualifier = "(" + a.nickname + ")"
+			case len(a.emails) > 0:
+				a.qualifier = "[" + emailDomain(a.emails[0]) + "]"
+			}
+			seen[a.qualifier]++
+		}
+		for _, i := range idxs {
+			a := &authors[i]
+			if seen[a.qualifier] > 1 && len(a.emails) > 0 {
+				a.qualifier = "[" + a.emails[0] + "]"
+			}
+		}
+	}
+}
+
 // hasNickName returns true if there is a nick name and it's relevantly
 // different from the actual name.
 func (a author) hasNickName() bool {
@@ -54,122 +196,217 @@ func (a author) hasNickName() bool {
 	return true
 }
 
-func main() {
-	authorsFile := flag.String("read-authors", "", "Name of canonical AUTHORS file")
-	printAuthors := flag.Bool("authors", false, "Print the AUTHORS list")
-	printNames := flag.Bool("names", false, "Print the name list")
-	printStats := flag.Bool("stats", false, "Print the statistics")
-	minContributions := flag.Int("min", 1, "Minimum number of contribution to show up in lists")
-	geekrank := flag.Bool("geekrank", false, "Sort contributors by geekrank")
-	excludeHashes := flag.String("exclude-commits", "", "File containing commit hashes to ignore")
-	excludePattern := flag.String("exclude-pattern", "[bot]", "Skip names containing this string")
-	flag.Parse()
+// freemailDomains are email domains shared by many unrelated people, and
+// hence don't tell us anything about whether two addresses belong to the
+// same person.
+var freemailDomains = stringSetFromStrings([]string{
+	"gmail.com", "googlemail.com", "outlook.com", "hotmail.com", "live.com",
+	"yahoo.com", "icloud.com", "me.com", "mac.com", "protonmail.com",
+	"proton.me", "gmx.de", "gmx.net", "web.de", "fastmail.com",
+	"users.noreply.github.com",
+})
+
+// plausiblySamePerson returns true unless the email is clearly from a
+// different organization than all of the author's current emails, i.e.
+// the domains differ and none of them is a freemail domain.
+func plausiblySamePerson(a author, email string) bool {
+	domain := emailDomain(email)
+	if domain == "" || freemailDomains.has(domain) {
+		return true
+	}
+	for _, e := range a.emails {
+		d := emailDomain(e)
+		if d == "" || d == domain || freemailDomains.has(d) {
+			return true
+		}
+	}
+	return false
+}
 
-	// Load exclude hashes, if any
-	var exclude stringSet
-	if *excludeHashes != "" {
-		hashes := readAll(*excludeHashes)
-		lines := strings.Split(string(hashes), "\n")
-		exclude = stringSetFromStrings(lines)
+// emailDomain returns the lower cased domain part of the email address, or
+// the empty string if there isn't one.
+func emailDomain(email string) string {
+	idx := strings.LastIndexByte(email, '@')
+	if idx < 0 {
+		return ""
 	}
+	return strings.ToLower(email[idx+1:])
+}
 
-	// Load existing AUTHORS, if any
-	var authors []author
-	if *authorsFile != "" {
-		authors = getAuthors(*authorsFile)
+// githubUsername returns the GitHub user name from a GitHub noreply
+// address like "1234+jdoe@users.noreply.github.com", or the empty string if
+// the address isn't one.
+func githubUsername(email string) string {
+	if emailDomain(email) != "users.noreply.github.com" {
+		return ""
+	}
+	local := email[:strings.LastIndexByte(email, '@')]
+	if idx := strings.IndexByte(local, '+'); idx >= 0 {
+		local = local[idx+1:]
 	}
+	return local
+}
 
-	// Grab the set of thus known email addresses
-	listed := make(stringSet)
-	names := make(map[string]int)
-	for i, a := range authors {
-		names[a.name] = i
-		for _, e := range a.emails {
-			listed.add(e)
+func getAuthors(file string) []author {
+	bs := readAll(file)
+	if isYAMLFile(file) {
+		authors, err := parseYAMLAuthors(bs)
+		if err != nil {
+			fatalf("%s: %v", file, err)
 		}
+		return authors
 	}
+	warnMalformed(file, bs)
+	return parseAuthors(bs)
+}
 
-	// Grab the set of all known authors based on the git log, and add any
-	// missing ones to the authors list.
-	all := allAuthors(exclude)
-	for email, name := range all {
-		if listed.has(email) {
+// warnMalformed warns about the lines in the plain text AUTHORS file that
+// aren't comments but have no email address, and so can't be matched to
+// anything.
+func warnMalformed(file string, bs []byte) {
+	for i, line := range strings.Split(string(bs), "\n") {
+		if strings.TrimSpace(line) == "" || line[0] == '#' {
 			continue
 		}
-
-		if _, ok := names[name]; ok && name != "" {
-			// We found a match on name
-			authors[names[name]].emails = append(authors[names[name]].emails, email)
-			listed.add(email)
-			continue
+		if entry, _ := splitNote(line); !emailRe.MatchString(entry) {
+			warn(warning{Kind: warnMalformedEntry, File: file, Line: i + 1, Message: "entry without an email address"})
 		}
-
-		authors = append(authors, author{
-			name:   name,
-			emails: []string{email},
-		})
-		names[name] = len(authors) - 1
-		listed.add(email)
 	}
+}
 
-	// Count commits per author, for ranking
-	getContributions(authors)
-
-	// Filter on minimum contributions
-	for i := 0; i < len(authors); i++ {
-		if strings.Contains(authors[i].name, *excludePattern) || authors[i].commits < *minContributions {
-			authors = append(authors[:i], authors[i+1:]...)
-			i--
+// pinDirective is the comment line that pins the following entry of the
+// AUTHORS file to its position, so that it's kept there when the rest are
+// sorted.
+const pinDirective = "# git-contributors: pin"
+
+// moreEmailsDirective is the comment line listing more emails of the
+// following entry of the AUTHORS file, beyond those -max-emails leaves on
+// the entry's line. They are read like the others.
+const moreEmailsDirective = "# git-contributors: emails"
+
+// moreEmails returns the emails of a more emails directive, and whether the
+// line is one.
+func moreEmails(line string) ([]string, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || strings.Join(fields[:3], " ") != moreEmailsDirective {
+		return nil, false
+	}
+	var emails []string
+	for _, field := range fields[3:] {
+		if m := emailRe.FindStringSubmatch(field); len(m) > 1 {
+			emails = append(emails, m[1])
 		}
 	}
+	return emails, true
+}
 
-	// Sort by name and, optionally, rank
-	sort.Sort(byName(authors))
-	if *geekrank {
-		sort.Sort(byGeekrank(authors))
-	}
+// mergeAuthorLists merges the entries of several AUTHORS files for the
+// same person, as recognized by a shared email or, if byName is set, the
+// same name. The first entry's name and nickname win. Entries in the same
+// file are not merged with each other, as different people may share a
+// name, and -strict reports emails listed twice.
+func mergeAuthorLists(lists [][]author, byName bool) []author {
+	var authors []author
+	idx := newAuthorIndex(nil)
+	for fi, list := range lists {
+		var added []int
+		for _, a := range list {
+			i, ok := -1, false
+			for _, e := range a.emails {
+				if i, ok = idx.email(e); ok {
+					break
+				}
+			}
+			if !ok && byName {
+				i, ok = idx.name(a.name)
+			}
+			if !ok {
+				if fi > 0 {
+					// Pins are positions in the first file only
+					a.pin = 0
+				}
+				authors = append(authors, a)
+				added = append(added, len(authors)-1)
+				continue
+			}
 
-	if *printNames {
-		var lines []string
-		for _, author := range authors {
-			lines = append(lines, author.displayName())
+			m := &authors[i]
+			m.emails = appendMissing(m.emails, a.emails...)
+			m.urls = appendMissing(m.urls, a.urls...)
+			m.tags = appendMissing(m.tags, a.tags...)
+			if a.nickname != "" && a.nickname != m.nickname {
+				if m.nickname == "" {
+					m.nickname = a.nickname
+				} else {
+					m.nicknames = appendMissing(m.nicknames, a.nickname)
+				}
+			}
+			m.nicknames = appendMissing(m.nicknames, a.nicknames...)
+			if m.note == "" {
+				m.note = a.note
+			}
+			added = append(added, i)
 		}
-		contributorNames := strings.Join(lines, ", ")
-		fmt.Println(contributorNames)
-	}
-
-	if *printStats {
-		for _, author := range authors {
-			fmt.Printf("%5d %2d %s\n", author.commits, author.geekrank, author.displayName())
+		for _, i := range added {
+			idx.add(authors, i)
 		}
 	}
+	return authors
+}
 
-	if *printAuthors {
-		for _, author := range authors {
-			fmt.Printf("%s", author.displayName())
-			for _, email := range author.emails {
-				fmt.Printf(" <%s>", email)
+// appendMissing appends the strings not already in the slice.
+func appendMissing(l []string, ss ...string) []string {
+	for _, s := range ss {
+		found := false
+		for _, e := range l {
+			if e == s {
+				found = true
+				break
 			}
-			fmt.Printf("\n")
+		}
+		if !found {
+			l = append(l, s)
 		}
 	}
+	return l
 }
 
-func getAuthors(file string) []author {
-	bs := readAll(file)
+// splitNote splits an AUTHORS line into the entry and the note in a
+// trailing comment, if any, as in "Jane Doe <jane@example.com> # maintainer".
+func splitNote(line string) (string, string) {
+	if i := strings.Index(line, " #"); i >= 0 {
+		return line[:i], strings.TrimSpace(line[i+2:])
+	}
+	return line, ""
+}
+
+// parseAuthors parses the plain text AUTHORS format, one author per line.
+func parseAuthors(bs []byte) []author {
 	lines := strings.Split(string(bs), "\n")
 	var authors []author
 
+	pinNext := false
+	var more []string
 	for _, line := range lines {
-		if len(line) == 0 || line[0] == '#' {
+		if strings.TrimSpace(line) == pinDirective {
+			pinNext = true
+			continue
+		}
+		if emails, ok := moreEmails(line); ok {
+			more = append(more, emails...)
+			continue
+		}
+		if strings.TrimSpace(line) == "" || line[0] == '#' {
 			continue
 		}
 
-		fields := strings.Fields(line)
-		var author author
-		for _, field := range fields {
+		line, note := splitNote(line)
+		author := author{note: note}
+		for _, field := range strings.Fields(line) {
 			if m := nicknameRe.FindStringSubmatch(field); len(m) > 1 {
 				author.nickname = m[1]
+			} else if m := urlRe.FindStringSubmatch(field); len(m) > 1 {
+				author.urls = append(author.urls, m[1])
 			} else if m := emailRe.FindStringSubmatch(field); len(m) > 1 {
 				author.emails = append(author.emails, m[1])
 			} else {
@@ -181,107 +418,196 @@ func getAuthors(file string) []author {
 			}
 		}
 
+		if pinNext {
+			author.pin = len(authors) + 1
+			pinNext = false
+		}
+		author.emails = append(author.emails, more...)
+		more = nil
 		authors = append(authors, author)
 	}
 	return authors
 }
 
+// readLines returns the non-empty lines of the file, with white space
+// trimmed and lines starting with '#' ignored.
+func readLines(path string) []string {
+	var lines []string
+	for _, line := range strings.Split(string(readAll(path)), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
 func readAll(path string) []byte {
 	fd, err := os.Open(path)
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 	defer fd.Close()
 
 	bs, err := ioutil.ReadAll(fd)
 	if err != nil {
-		log.Fatal(err)
+		fatal(err)
 	}
 
 	return bs
 }
 
 // Add number of commits per author to the author list.
-func getContributions(authors []author) {
-	buf := new(bytes.Buffer)
-	cmd := exec.Command("git", "log", "--pretty=format:%ae")
-	cmd.Stdout = buf
-	err := cmd.Run()
-	if err != nil {
-		log.Fatal(err)
+func getContributions(authors []author, idx *authorIndex, commits []commit) {
+	days := make(map[int]stringSet)
+	for _, c := range commits {
+		ai, ok := idx.email(c.email)
+		if !ok {
+			continue
+		}
+		a := &authors[ai]
+		a.commits++
+		if days[ai] == nil {
+			days[ai] = make(stringSet)
+		}
+		days[ai].add(c.date.UTC().Format("2006-01-02"))
+		if a.first.IsZero() || c.date.Before(a.first) {
+			a.first = c.date
+		}
+		if c.date.After(a.last) {
+			a.last = c.date
+		}
 	}
 
-	// email -> authors idx
-	emailIdx := make(map[string]int)
 	for i := range authors {
-		for _, email := range authors[i].emails {
-			emailIdx[email] = i
-		}
+		authors[i].daysActive = len(days[i])
+		authors[i].dayStreak, authors[i].weekStreak = longestStreaks(days[i])
+		authors[i].geekrank = geekrankOf(authors[i].commits)
 	}
+}
 
-	for _, line := range strings.Split(buf.String(), "\n") {
-		if idx, ok := emailIdx[line]; ok {
-			authors[idx].commits++
+// geekrankOf returns the geekrank for the number of commits, which is just
+// its log2.
+func geekrankOf(commits int) int {
+	if commits <= 0 {
+		return 0
+	}
+	return int(math.Log2(float64(commits)))
+}
+
+// An identity is an email address and the most recent name used with it.
+type identity struct {
+	email string
+	name  string
+}
+
+// allAuthors returns the identities in the given commits, in the order of
+// their first commit.
+func allAuthors(commits []commit) []identity {
+	names := make(map[string]string)
+	var emails []string
+	for i := len(commits) - 1; i >= 0; i-- {
+		c := commits[i]
+		if _, ok := names[c.email]; !ok {
+			emails = append(emails, c.email)
 		}
+		// Commits are newest first, so the last name seen is the latest
+		names[c.email] = c.name
 	}
+	res := make([]identity, len(emails))
+	for i, e := range emails {
+		res[i] = identity{email: e, name: names[e]}
+	}
+	return res
+}
 
-	for i := range authors {
-		// geekrank is just log2 of the number of commits
-		authors[i].geekrank = int(math.Log2(float64(authors[i].commits)))
+// lessByName orders authors by name, case insensitively, and then by first
+// email. It is the tie-breaker for all other orders, so that authors of
+// equal rank don't swap places between runs.
+func lessByName(a, b author) bool {
+	aname, bname := strings.ToLower(a.name), strings.ToLower(b.name)
+	if aname != bname {
+		return aname < bname
 	}
+	return firstEmail(a) < firstEmail(b)
 }
 
-// allAuthors returns the set of authors in the git commit log, except those
-// in excluded commits.
-func allAuthors(exclude stringSet) map[string]string {
-	args := []string{"log", "--format=%H %ae %an"}
-	cmd := exec.Command("git", args...)
-	bs, err := cmd.Output()
-	if err != nil {
-		log.Fatal("git:", err)
+func firstEmail(a author) string {
+	if len(a.emails) == 0 {
+		return ""
 	}
+	return strings.ToLower(a.emails[0])
+}
 
-	names := make(map[string]string)
-	for _, line := range bytes.Split(bs, []byte{'\n'}) {
-		fields := strings.SplitN(string(line), " ", 3)
-		if len(fields) != 3 {
-			continue
+// sortAuthors sorts the authors by the given key, descending, with ties
+// broken by name.
+func sortAuthors(authors []author, key func(author) float64) {
+	sort.SliceStable(authors, func(a, b int) bool {
+		ka, kb := key(authors[a]), key(authors[b])
+		if ka != kb {
+			return ka > kb
 		}
-		hash, email, name := fields[0], fields[1], fields[2]
+		return lessByName(authors[a], authors[b])
+	})
+}
 
-		if exclude.has(hash) {
-			continue
+// placePinned moves the pinned authors to their positions, keeping the
+// order of the rest.
+func placePinned(authors []author) {
+	var pinned, rest []author
+	for _, a := range authors {
+		if a.pin > 0 {
+			pinned = append(pinned, a)
+		} else {
+			rest = append(rest, a)
 		}
-
-		if names[email] == "" {
-			names[email] = name
+	}
+	if len(pinned) == 0 {
+		return
+	}
+	sort.SliceStable(pinned, func(a, b int) bool {
+		return pinned[a].pin < pinned[b].pin
+	})
+
+	res := make([]author, 0, len(authors))
+	for _, p := range pinned {
+		for len(res) < p.pin-1 && len(rest) > 0 {
+			res = append(res, rest[0])
+			rest = rest[1:]
 		}
+		res = append(res, p)
 	}
-
-	return names
+	res = append(res, rest...)
+	copy(authors, res)
 }
 
-type byGeekrank []author
-
-func (l byGeekrank) Len() int { return len(l) }
+func sortByName(authors []author) {
+	sort.SliceStable(authors, func(a, b int) bool {
+		return lessByName(authors[a], authors[b])
+	})
+}
 
-func (l byGeekrank) Less(a, b int) bool {
-	return l[a].geekrank > l[b].geekrank
+func sortByGeekrank(authors []author) {
+	sortAuthors(authors, func(a author) float64 { return float64(a.geekrank) })
 }
 
-func (l byGeekrank) Swap(a, b int) { l[a], l[b] = l[b], l[a] }
+func sortByDaysActive(authors []author) {
+	sortAuthors(authors, func(a author) float64 { return float64(a.daysActive) })
+}
 
-type byName []author
+// A string slice that can be given multiple times on the command line
 
-func (l byName) Len() int { return len(l) }
+type stringList []string
 
-func (l byName) Less(a, b int) bool {
-	aname := strings.ToLower(l[a].name)
-	bname := strings.ToLower(l[b].name)
-	return aname < bname
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
 }
 
-func (l byName) Swap(a, b int) { l[a], l[b] = l[b], l[a] }
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
 
 // A simple string set type
 
@@ -0,0 +1,361 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sortedEmails returns the first email of each author, or the name for
+// authors without one.
+func sortedEmails(authors []author) []string {
+	res := make([]string, len(authors))
+	for i, a := range authors {
+		if len(a.emails) == 0 {
+			res[i] = a.name
+			continue
+		}
+		res[i] = a.emails[0]
+	}
+	return res
+}
+
+func TestSortTies(t *testing.T) {
+	// Every permutation of the input must give the same order
+	authors := []author{
+		{name: "bob", emails: []string{"bob@b.example.com"}, geekrank: 2, daysActive: 4},
+		{name: "Alice", emails: []string{"alice@example.com"}, geekrank: 2, daysActive: 1},
+		{name: "Bob", emails: []string{"Bob@a.example.com"}, geekrank: 2, daysActive: 4},
+		{name: "Carol", emails: []string{"carol@example.com"}, geekrank: 5, daysActive: 1},
+		{name: "Bob", geekrank: 2, daysActive: 4},
+	}
+	cases := []struct {
+		name string
+		sort func([]author)
+		want []string
+	}{
+		{"name", sortByName, []string{"alice@example.com", "Bob", "Bob@a.example.com", "bob@b.example.com", "carol@example.com"}},
+		{"geekrank", sortByGeekrank, []string{"carol@example.com", "alice@example.com", "Bob", "Bob@a.example.com", "bob@b.example.com"}},
+		{"days active", sortByDaysActive, []string{"Bob", "Bob@a.example.com", "bob@b.example.com", "alice@example.com", "carol@example.com"}},
+	}
+	for _, tc := range cases {
+		permute(len(authors), func(perm []int) {
+			input := make([]author, len(authors))
+			for i, p := range perm {
+				input[i] = authors[p]
+			}
+			tc.sort(input)
+			if got := sortedEmails(input); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("sort by %s of permutation %v = %v; want %v", tc.name, perm, got, tc.want)
+			}
+		})
+	}
+}
+
+// permute calls fn with every permutation of 0..n-1.
+func permute(n int, fn func([]int)) {
+	perm := make([]int, n)
+	for i := range perm {
+		perm[i] = i
+	}
+	var rec func(k int)
+	rec = func(k int) {
+		if k == n {
+			fn(perm)
+			return
+		}
+		for i := k; i < n; i++ {
+			perm[k], perm[i] = perm[i], perm[k]
+			rec(k + 1)
+			perm[k], perm[i] = perm[i], perm[k]
+		}
+	}
+	rec(0)
+}
+
+// benchmarkHistory returns authors and a history of commits by them,
+// shaped like that of a large project.
+func benchmarkHistory(nAuthors, nCommits int) ([]author, []commit) {
+	authors := make([]author, nAuthors)
+	for i := range authors {
+		authors[i] = author{name: fmt.Sprintf("Author %d", i), emails: []string{fmt.Sprintf("author%d@example.com", i)}}
+	}
+	commits := make([]commit, nCommits)
+	start := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range commits {
+		// A few authors make most of the commits, now and then from an
+		// address that is only matched by name
+		a := (i * i) % nAuthors
+		email := fmt.Sprintf("author%d@example.com", a)
+		if i%10 == 0 {
+			email = fmt.Sprintf("author%d@laptop.example.com", a)
+		}
+		commits[i] = commit{
+			hash:    fmt.Sprintf("%040x", i),
+			email:   email,
+			name:    fmt.Sprintf("Author %d", a),
+			date:    start.Add(time.Duration(i) * time.Hour),
+			message: fmt.Sprintf("Change number %d\n\nWith a body.\n", i),
+		}
+	}
+	return authors, commits
+}
+
+func BenchmarkParseCommit(b *testing.B) {
+	_, commits := benchmarkHistory(1000, 10000)
+	recs := make([][]byte, len(commits))
+	for i, c := range commits {
+		recs[i] = []byte(fmt.Sprintf("%s\x1f%s\x1f%s\x1f%d\x1f%s\x1f%s", c.hash, c.email, c.name, c.date.Unix(), c.hash, c.message))
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		strs := newInterner()
+		for _, rec := range recs {
+			if _, ok := parseCommit(rec, strs); !ok {
+				b.Fatal("unparseable record")
+			}
+		}
+	}
+}
+
+func BenchmarkParseAuthors(b *testing.B) {
+	authors, _ := benchmarkHistory(5000, 0)
+	var buf bytes.Buffer
+	if err := writeAuthors(&buf, authors, 0); err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(buf.Len()))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if len(parseAuthors(buf.Bytes())) != len(authors) {
+			b.Fatal("wrong number of authors")
+		}
+	}
+}
+
+// BenchmarkMatchAuthors matches the identities of the history to the
+// authors, by email or else by name, as the analysis does.
+func BenchmarkMatchAuthors(b *testing.B) {
+	authors, commits := benchmarkHistory(1000, 100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		idx := newAuthorIndex(authors)
+		for _, id := range allAuthors(commits) {
+			if _, ok := idx.email(id.email); ok {
+				continue
+			}
+			if j, ok := idx.name(id.name); ok {
+				idx.addEmail(id.email, j)
+				continue
+			}
+			b.Fatalf("%s <%s> not matched", id.name, id.email)
+		}
+	}
+}
+
+func BenchmarkGetContributions(b *testing.B) {
+	authors, commits := benchmarkHistory(1000, 100000)
+	idx := newAuthorIndex(authors)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		counted := make([]author, len(authors))
+		copy(counted, authors)
+		getContributions(counted, idx, commits)
+	}
+}
+
+func TestPlacePinned(t *testing.T) {
+	authors := []author{{name: "A"}, {name: "B", pin: 4}, {name: "C"}, {name: "D", pin: 1}, {name: "E"}, {name: "F", pin: 9}}
+	placePinned(authors)
+	var names []string
+	for _, a := range authors {
+		names = append(names, a.name)
+	}
+	// Pins beyond the end are placed last, in the order of their pins
+	if expected := []string{"D", "A", "C", "B", "E", "F"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("placed %q, expected %q", names, expected)
+	}
+}
+
+func TestPinned(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	list := "# git-contributors: pin\nCarol C <carol@example.com>\nAlice A <alice@example.com>\n"
+	authors := writeTestFile(t, dir, "AUTHORS", list)
+
+	// The new author is sorted in among the others, below the pinned one
+	expected := "# git-contributors: pin\nCarol C <carol@example.com>\nAlice A <alice@example.com>\nBob B <bob@example.com>\n"
+	if out := mustRunMain(t, dir, "-read-authors", authors, "-authors"); out != expected {
+		t.Errorf("unexpected output\n%s\nexpected\n%s", out, expected)
+	}
+	// and the pinned entry is not out of order
+	if stdout, _, code := runMain(t, dir, "lint", authors); code != 0 {
+		t.Errorf("exit code %d\n%s", code, stdout)
+	}
+}
+
+func TestMergeAuthorLists(t *testing.T) {
+	first := parseAuthors([]byte("Alice A (alice) <alice@example.com>\nBob B <bob@example.com>\nBob B <bob@example.net>\n"))
+	second := parseAuthors([]byte("Alice Ann (ally) <alice@example.net> <alice@example.com> https://alice.example.com\nCarol C <carol@example.com>\nBob B <bob@example.org>\n"))
+
+	merged := mergeAuthorLists([][]author{first, second}, false)
+	var buf bytes.Buffer
+	if err := writeAuthors(&buf, merged, 0); err != nil {
+		t.Fatal(err)
+	}
+	// The two Bobs in the first file are different people, and the one in
+	// the second is a third without name matching
+	expected := "Alice A (alice) <alice@example.com> <alice@example.net> https://alice.example.com\n" +
+		"Bob B <bob@example.com>\n" +
+		"Bob B <bob@example.net>\n" +
+		"Carol C <carol@example.com>\n" +
+		"Bob B <bob@example.org>\n"
+	if buf.String() != expected {
+		t.Errorf("merged\n%s\nexpected\n%s", buf.String(), expected)
+	}
+	if !reflect.DeepEqual(merged[0].nicknames, []string{"ally"}) {
+		t.Errorf("additional nicknames %q", merged[0].nicknames)
+	}
+
+	merged = mergeAuthorLists([][]author{first, second}, true)
+	if len(merged) != 4 || len(merged[1].emails)+len(merged[2].emails) != 3 {
+		t.Errorf("merged by name %+v", merged)
+	}
+}
+
+func TestPlausiblySamePerson(t *testing.T) {
+	a := author{emails: []string{"jdoe@corp.example.com"}}
+	cases := map[string]bool{
+		"jane@corp.example.com":  true,
+		"jane@CORP.example.com":  true,
+		"jane@gmail.com":         true,
+		"jane":                   true,
+		"jane@other.example.com": false,
+	}
+	for email, expected := range cases {
+		if got := plausiblySamePerson(a, email); got != expected {
+			t.Errorf("plausiblySamePerson(%q) = %v, expected %v", email, got, expected)
+		}
+	}
+	if !plausiblySamePerson(author{emails: []string{"jdoe@outlook.com"}}, "jane@other.example.com") {
+		t.Error("a freemail author is not plausibly the same")
+	}
+}
+
+func TestAuthorID(t *testing.T) {
+	a := author{name: "Alice A", emails: []string{"alice@example.com"}}
+	id := a.id()
+	if len(id) != 16 {
+		t.Errorf("id %q, expected 16 hex digits", id)
+	}
+	for _, b := range []author{
+		{name: "Alice Anderson", emails: []string{"alice@example.com"}},
+		{name: "Alice A", emails: []string{"Alice@Example.com", "alice@example.net"}},
+	} {
+		if b.id() != id {
+			t.Errorf("id %q for %v, expected %q", b.id(), b, id)
+		}
+	}
+	if b := (author{name: "Alice A", emails: []string{"alice@example.net", "alice@example.com"}}); b.id() == id {
+		t.Errorf("same id for another canonical email")
+	}
+	if b := (author{name: "Alice A"}); b.id() != "" {
+		t.Errorf("id %q without emails", b.id())
+	}
+}
+
+func TestDisambiguate(t *testing.T) {
+	authors := []author{
+		{name: "Jane Doe", nickname: "janedoe", emails: []string{"jane@example.com"}},
+		{name: "Jane Doe", emails: []string{"jane@corp.example.com"}},
+		{name: "John Smith", emails: []string{"john@gmail.com"}},
+		{name: "john smith", emails: []string{"jsmith@gmail.com"}},
+		{name: "Bob B", emails: []string{"bob@example.com"}},
+		{name: "Bob B", nickname: "bobby", emails: []string{"bob@example.net"}},
+	}
+	disambiguate(authors)
+	expected := []string{
+		"Jane Doe (janedoe)",
+		"Jane Doe [corp.example.com]",
+		"John Smith [john@gmail.com]",
+		"john smith [jsmith@gmail.com]",
+		"Bob B",
+		"Bob B (bobby)",
+	}
+	for i, a := range authors {
+		if a.displayName() != expected[i] {
+			t.Errorf("displayName %q, expected %q", a.displayName(), expected[i])
+		}
+	}
+
+	// Qualifiers are recomputed for the authors at hand
+	disambiguate(authors[:1])
+	if authors[0].qualifier != "" {
+		t.Errorf("qualifier %q for a unique name", authors[0].qualifier)
+	}
+}
+
+func TestDaysActive(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Alice A <alice@example.com>", date: "2020-01-01T08:00:00Z", message: "One"},
+		testCommit{author: "Alice A <alice@example.com>", date: "2020-01-01T12:00:00Z", message: "Two"},
+		testCommit{author: "Alice A <alice@example.com>", date: "2020-01-01T23:00:00Z", message: "Three"},
+		testCommit{author: "Bob B <bob@example.com>", date: "2020-01-01T12:00:00Z", message: "One"},
+		testCommit{author: "Bob B <bob@example.com>", date: "2020-01-02T12:00:00Z", message: "Two"},
+	)
+	defer cleanup()
+
+	if out := mustRunMain(t, dir, "-stats"); out != "    3  1 Alice A\n    2  1 Bob B\n" {
+		t.Errorf("unexpected output\n%s", out)
+	}
+	if out := mustRunMain(t, dir, "-days-active", "-stats"); out != "    2  1 Bob B\n    3  1 Alice A\n" {
+		t.Errorf("unexpected output with -days-active\n%s", out)
+	}
+	if out := mustRunMain(t, dir, "-json"); !strings.Contains(out, `"daysActive": 2`) || !strings.Contains(out, `"daysActive": 1`) {
+		t.Errorf("no days active in JSON\n%s", out)
+	}
+}
+
+func TestSplitNote(t *testing.T) {
+	cases := []struct {
+		line, entry, note string
+	}{
+		{"Jane Doe <jane@example.com> # maintainer 2015-2019", "Jane Doe <jane@example.com>", "maintainer 2015-2019"},
+		{"Jane Doe <jane@example.com> #", "Jane Doe <jane@example.com>", ""},
+		{"Jane Doe <jane#doe@example.com>", "Jane Doe <jane#doe@example.com>", ""},
+		{"Jane Doe <jane@example.com>", "Jane Doe <jane@example.com>", ""},
+	}
+	for _, c := range cases {
+		if entry, note := splitNote(c.line); entry != c.entry || note != c.note {
+			t.Errorf("splitNote(%q) = %q, %q, expected %q, %q", c.line, entry, note, c.entry, c.note)
+		}
+	}
+}
+
+func TestAuthorNotes(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	authors := writeTestFile(t, dir, "AUTHORS", "Alice A <alice@example.com> # original author\nBob B <bob@example.com>\n")
+	tpl := writeTestFile(t, dir, "authors.tpl", "{{range .Authors}}{{.Name}}: {{.Note}}{{\"\\n\"}}{{end}}")
+
+	out := mustRunMain(t, dir, "-read-authors", authors, "-authors")
+	expected := "Alice A <alice@example.com> # original author\nBob B <bob@example.com>\nCarol C <carol@example.com>\n"
+	if out != expected {
+		t.Errorf("output\n%s\nexpected\n%s", out, expected)
+	}
+	if out := mustRunMain(t, dir, "-read-authors", authors, "-template", tpl); !containsLine(out, "Alice A: original author") || !containsLine(out, "Bob B: ") {
+		t.Errorf("unexpected template output\n%s", out)
+	}
+	if stdout, _, code := runMain(t, dir, "lint", authors); code != 0 {
+		t.Errorf("exit code %d linting a note\n%s", code, stdout)
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// yamlAuthor is an entry in the YAML AUTHORS format, which can express
+// more than the plain text format.
+type yamlAuthor struct {
+	Name      string   `yaml:"name"`
+	Nicknames []string `yaml:"nicknames,omitempty"`
+	Emails    []string `yaml:"emails"`
+	More      []string `yaml:"moreEmails,omitempty"`
+	Tags      []string `yaml:"tags,omitempty"`
+	URLs      []string `yaml:"urls,omitempty"`
+	Pinned    bool     `yaml:"pinned,omitempty"`
+	Note      string   `yaml:"note,omitempty"`
+}
+
+func isYAMLFile(file string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		return true
+	default:
+		return false
+	}
+}
+
+// parseYAMLAuthors parses the YAML AUTHORS format, a list of entries.
+func parseYAMLAuthors(bs []byte) ([]author, error) {
+	var entries []yamlAuthor
+	if err := yaml.UnmarshalStrict(bs, &entries); err != nil {
+		return nil, err
+	}
+
+	authors := make([]author, len(entries))
+	for i, e := range entries {
+		authors[i] = author{
+			name:   e.Name,
+			emails: append(e.Emails, e.More...),
+			tags:   e.Tags,
+			urls:   e.URLs,
+			note:   e.Note,
+		}
+		if e.Pinned {
+			authors[i].pin = i + 1
+		}
+		if len(e.Nicknames) > 0 {
+			authors[i].nickname = e.Nicknames[0]
+			authors[i].nicknames = e.Nicknames[1:]
+		}
+	}
+	return authors, nil
+}
+
+// writeAuthors writes the authors in the plain text AUTHORS format, with
+// at most maxEmails emails per author on the entry's line unless it's zero.
+// The others are kept in a directive before it.
+func writeAuthors(w io.Writer, authors []author, maxEmails int) error {
+	bw := bufio.NewWriter(w)
+	for _, author := range authors {
+		if author.pin > 0 {
+			fmt.Fprintf(bw, "%s\n", pinDirective)
+		}
+		emails := limitEmails(author.emails, maxEmails)
+		if more := author.emails[len(emails):]; len(more) > 0 {
+			fmt.Fprintf(bw, "%s", moreEmailsDirective)
+			for _, email := range more {
+				fmt.Fprintf(bw, " <%s>", email)
+			}
+			fmt.Fprintf(bw, "\n")
+		}
+		fmt.Fprintf(bw, "%s", author.fullName())
+		for _, email := range emails {
+			fmt.Fprintf(bw, " <%s>", email)
+		}
+		for _, url := range author.urls {
+			fmt.Fprintf(bw, " %s", url)
+		}
+		if author.note != "" {
+			fmt.Fprintf(bw, " # %s", author.note)
+		}
+		fmt.Fprintf(bw, "\n")
+	}
+	return bw.Flush()
+}
+
+// writeYAMLAuthors writes the authors in the YAML AUTHORS format, with at
+// most maxEmails emails per author unless it's zero. The others are kept
+// as moreEmails.
+func writeYAMLAuthors(w io.Writer, authors []author, maxEmails int) error {
+	entries := make([]yamlAuthor, len(authors))
+	for i, a := range authors {
+		emails := limitEmails(a.emails, maxEmails)
+		entries[i] = yamlAuthor{
+			Name:   a.name,
+			Emails: emails,
+			More:   a.emails[len(emails):],
+			Tags:   a.tags,
+			URLs:   a.urls,
+			Pinned: a.pin > 0,
+			Note:   a.note,
+		}
+		if a.nickname != "" {
+			entries[i].Nicknames = append([]string{a.nickname}, a.nicknames...)
+		}
+	}
+	bs, err := yaml.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(bs)
+	return err
+}
+
+// The authorComments are the comments of an AUTHORS file, kept when the
+// file is rewritten: the header before the first entry, the comment lines
+// before each entry, and those after the last.
+type authorComments struct {
+	header  []string
+	before  map[string][]string // entry key -> comment lines
+	trailer []string
+}
+
+// commentKeys returns the keys the comments before an entry for the
+// author are found by: the emails, or the name when there are none.
+func commentKeys(a author) []string {
+	if len(a.emails) == 0 {
+		return []string{"name:" + strings.ToLower(a.name)}
+	}
+	keys := make([]string, len(a.emails))
+	for i, e := range a.emails {
+		keys[i] = strings.ToLower(e)
+	}
+	return keys
+}
+
+// readAuthorComments returns the comments of the AUTHORS file, given its
+// contents and the authors parsed from them. Pin and more emails
+// directives aren't comments; they are written with the entries.
+func readAuthorComments(bs []byte, yamlFormat bool, authors []author) authorComments {
+	c := authorComments{before: make(map[string][]string)}
+	entry := -1
+	var pending []string
+	for _, line := range strings.Split(strings.TrimRight(string(bs), "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		isEntry := trimmed != "" && line[0] != '#'
+		if yamlFormat {
+			isEntry = strings.HasPrefix(line, "-")
+		}
+		_, isMore := moreEmails(line)
+		switch {
+		case trimmed == pinDirective || isMore:
+		case isEntry:
+			entry++
+			if entry == 0 {
+				c.header, pending = pending, nil
+			}
+			if len(pending) > 0 && entry < len(authors) {
+				key := commentKeys(authors[entry])[0]
+				c.before[key] = append(c.before[key], pending...)
+				pending = nil
+			}
+		case strings.HasPrefix(trimmed, "#"):
+			pending = append(pending, line)
+		case entry < 0:
+			// Blank lines are kept in the header only
+			pending = append(pending, line)
+		}
+	}
+	if entry < 0 {
+		c.header = pending
+	} else {
+		c.trailer = pending
+	}
+	return c
+}
+
+// writeAuthorsWithComments writes the authors in the plain text or YAML
+// format with the comments put back: each entry's before it, and those of
+// entries that are gone at the end.
+func writeAuthorsWithComments(w io.Writer, authors []author, yamlFormat bool, c authorComments) error {
+	write := writeAuthors
+	if yamlFormat {
+		write = writeYAMLAuthors
+	}
+	bw := bufio.NewWriter(w)
+	for _, line := range c.header {
+		fmt.Fprintln(bw, line)
+	}
+	if len(authors) == 0 {
+		if err := write(bw, nil, 0); err != nil {
+			return err
+		}
+	}
+	used := make(map[string]bool)
+	for _, a := range authors {
+		for _, key := range commentKeys(a) {
+			if used[key] {
+				continue
+			}
+			used[key] = true
+			for _, line := range c.before[key] {
+				fmt.Fprintln(bw, line)
+			}
+		}
+		if err := write(bw, []author{a}, 0); err != nil {
+			return err
+		}
+	}
+	var orphaned []string
+	for key := range c.before {
+		if !used[key] {
+			orphaned = append(orphaned, key)
+		}
+	}
+	sort.Strings(orphaned)
+	for _, key := range orphaned {
+		for _, line := range c.before[key] {
+			fmt.Fprintln(bw, line)
+		}
+	}
+	for _, line := range c.trailer {
+		fmt.Fprintln(bw, line)
+	}
+	return bw.Flush()
+}
+
+// limitEmails returns the first max emails, or all of them if max is zero.
+// The first email is the canonical one, so that is always among them.
+func limitEmails(emails []string, max int) []string {
+	if max <= 0 || len(emails) <= max {
+		return emails
+	}
+	return emails[:max]
+}
+
+// convertAuthors reads the AUTHORS file from and writes it to the file to,
+// with the formats given by the file extensions.
+func convertAuthors(from, to string, backup bool) {
+	authors := getAuthors(from)
+	err := writeFileAtomic(to, backup, func(w io.Writer) error {
+		if isYAMLFile(to) {
+			return writeYAMLAuthors(w, authors, 0)
+		}
+		return writeAuthors(w, authors, 0)
+	})
+	if err != nil {
+		fatal(err)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestYAMLAuthors(t *testing.T) {
+	yml := `# The authors
+- name: Alice A
+  nicknames: [alice, al]
+  emails: [alice@example.com, alice@example.net]
+  tags: [maintainer]
+  urls: [https://alice.example.com]
+  pinned: true
+  note: original author
+- name: Bob B
+  emails: [bob@example.com]
+`
+	authors, err := parseYAMLAuthors([]byte(yml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []author{
+		{
+			name:      "Alice A",
+			nickname:  "alice",
+			nicknames: []string{"al"},
+			emails:    []string{"alice@example.com", "alice@example.net"},
+			tags:      []string{"maintainer"},
+			urls:      []string{"https://alice.example.com"},
+			pin:       1,
+			note:      "original author",
+		},
+		{name: "Bob B", emails: []string{"bob@example.com"}},
+	}
+	if !reflect.DeepEqual(authors, expected) {
+		t.Errorf("parsed\n%#v\nexpected\n%#v", authors, expected)
+	}
+
+	var buf bytes.Buffer
+	if err := writeYAMLAuthors(&buf, authors, 0); err != nil {
+		t.Fatal(err)
+	}
+	if read, err := parseYAMLAuthors(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(read, authors) {
+		t.Errorf("read back\n%#v\nexpected\n%#v", read, authors)
+	}
+
+	// Unknown keys are errors rather than silently lost
+	if _, err := parseYAMLAuthors([]byte("- name: Alice A\n  email: [alice@example.com]\n")); err == nil {
+		t.Error("no error for an unknown key")
+	}
+}
+
+func TestConvert(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	text := "Alice A (alice) <alice@example.com> https://alice.example.com # original author\nBob B <bob@example.com>\n"
+	from := writeTestFile(t, dir, "AUTHORS", text)
+	yml := filepath.Join(dir, "AUTHORS.yaml")
+	back := filepath.Join(dir, "AUTHORS.txt")
+
+	mustRunMain(t, dir, "convert", from, yml)
+	bs, err := ioutil.ReadFile(yml)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "- name: Alice A\n  nicknames:\n  - alice\n  emails:\n  - alice@example.com\n  urls:\n  - https://alice.example.com\n  note: original author\n- name: Bob B\n  emails:\n  - bob@example.com\n"
+	if string(bs) != expected {
+		t.Errorf("converted to\n%s\nexpected\n%s", bs, expected)
+	}
+
+	mustRunMain(t, dir, "convert", yml, back)
+	if bs, err := ioutil.ReadFile(back); err != nil {
+		t.Fatal(err)
+	} else if string(bs) != text {
+		t.Errorf("converted back to\n%s\nexpected\n%s", bs, text)
+	}
+}
+
+func TestMaxEmails(t *testing.T) {
+	authors := []author{
+		{name: "Alice A", emails: []string{"alice@example.com", "alice@work.example.com", "alice@old.example.com"}},
+		{name: "Bob B", emails: []string{"bob@example.com"}},
+	}
+
+	var buf bytes.Buffer
+	if err := writeAuthors(&buf, authors, 1); err != nil {
+		t.Fatal(err)
+	}
+	expected := "# git-contributors: emails <alice@work.example.com> <alice@old.example.com>\nAlice A <alice@example.com>\nBob B <bob@example.com>\n"
+	if buf.String() != expected {
+		t.Errorf("got\n%s\nexpected\n%s", buf.String(), expected)
+	}
+	if read := parseAuthors(buf.Bytes()); !reflect.DeepEqual(read, authors) {
+		t.Errorf("read back %v, expected %v", read, authors)
+	}
+
+	buf.Reset()
+	if err := writeYAMLAuthors(&buf, authors, 1); err != nil {
+		t.Fatal(err)
+	}
+	read, err := parseYAMLAuthors(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, authors) {
+		t.Errorf("read back %v from YAML, expected %v", read, authors)
+	}
+}
+
+func TestMaxEmailsCheck(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Alice A <alice@example.com>", message: "One"},
+		testCommit{author: "Alice A <alice@work.example.com>", message: "Two"},
+		testCommit{author: "Bob B <bob@example.com>", message: "Three"},
+	)
+	defer cleanup()
+
+	// The list written with a limit is still complete when read back
+	out := mustRunMain(t, dir, "-authors", "-max-emails", "1")
+	authors := writeTestFile(t, dir, "AUTHORS", out)
+	if stdout, _, code := runMain(t, dir, "-read-authors", authors, "-check"); code != 0 {
+		t.Errorf("check of\n%s\nfailed:\n%s", out, stdout)
+	}
+	if again := mustRunMain(t, dir, "-read-authors", authors, "-authors", "-max-emails", "1"); again != out {
+		t.Errorf("rewritten as\n%s\nexpected\n%s", again, out)
+	}
+}
+
+func TestReadSeveralAuthors(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	writeTestFile(t, dir, "AUTHORS", "Alice A (alice) <alice@example.com>\n")
+	if err := os.Mkdir(filepath.Join(dir, "more"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, "more/AUTHORS.team", "Bob B <bob@example.com> <bob@example.net>\n")
+	writeTestFile(t, dir, "more/AUTHORS.alice", "Alice Ann <alice@example.com> https://alice.example.com\n")
+
+	out := mustRunMain(t, dir, "-read-authors", "AUTHORS", "-read-authors", "more/AUTHORS.*", "-authors")
+	expected := "Alice A (alice) <alice@example.com> https://alice.example.com\n" +
+		"Bob B <bob@example.com> <bob@example.net>\n" +
+		"Carol C <carol@example.com>\n"
+	if out != expected {
+		t.Errorf("output\n%s\nexpected\n%s", out, expected)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// forgeAvatarURL returns the URL of the author's avatar at the given pixel
+// size, based on a forge account we can deduce from the emails or URLs, or
+// the empty string.
+func forgeAvatarURL(a author, size int) string {
+	user := ""
+	for _, e := range a.emails {
+		if user = githubUsername(e); user != "" {
+			break
+		}
+	}
+	if user == "" {
+		for _, u := range a.urls {
+			if user = githubProfileUser(u); user != "" {
+				break
+			}
+		}
+	}
+	if user == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://github.com/%s.png?size=%d", url.PathEscape(user), size)
+}
+
+// gravatarURL returns the Gravatar URL for the email address at the given
+// pixel size. Gravatar serves a generated image for unknown addresses.
+func gravatarURL(email string, size int) string {
+	hash := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return fmt.Sprintf("https://www.gravatar.com/avatar/%s?s=%d&d=identicon", hex.EncodeToString(hash[:]), size)
+}
+
+// githubProfileUser returns the user name from a GitHub profile URL like
+// "https://github.com/jdoe", or the empty string.
+func githubProfileUser(profile string) string {
+	u, err := url.Parse(profile)
+	if err != nil || (u.Host != "github.com" && u.Host != "www.github.com") {
+		return ""
+	}
+	p := strings.Trim(u.Path, "/")
+	if p == "" || strings.Contains(p, "/") {
+		return ""
+	}
+	return p
+}
+
+// resolveAvatars sets the avatar URLs for the authors at the given size
+// and twice that, for high resolution displays. Authors without a forge
+// account get a Gravatar, if enabled. If cacheDir is set the images are
+// downloaded there, unless already present, and the local copies are
+// referenced instead.
+func resolveAvatars(authors []author, size int, cacheDir string, gravatar bool) error {
+	for i := range authors {
+		a := &authors[i]
+		a.avatar = forgeAvatarURL(*a, size)
+		a.avatar2x = forgeAvatarURL(*a, 2*size)
+		if a.chosenAvatar != "" {
+			a.avatar, a.avatar2x = a.chosenAvatar, a.chosenAvatar
+		}
+		if a.avatar == "" && gravatar && len(a.emails) > 0 {
+			a.avatar = gravatarURL(a.emails[0], size)
+			a.avatar2x = gravatarURL(a.emails[0], 2*size)
+		}
+		if a.avatar == "" || cacheDir == "" {
+			continue
+		}
+
+		// With -offline, images not yet cached are linked rather than
+		// fetched
+		local, err := cacheAvatar(a.avatar, cacheDir, fmt.Sprintf("%s-%d", a.id(), size))
+		if errors.Is(err, errOffline) {
+			local, err = a.avatar, nil
+		}
+		if err != nil {
+			return err
+		}
+		local2x, err := cacheAvatar(a.avatar2x, cacheDir, fmt.Sprintf("%s-%d", a.id(), 2*size))
+		if errors.Is(err, errOffline) {
+			local2x, err = a.avatar2x, nil
+		}
+		if err != nil {
+			return err
+		}
+		a.avatar, a.avatar2x = local, local2x
+	}
+	return nil
+}
+
+// cacheAvatar downloads the image at the URL to a file with the given base
+// name in dir, unless it already exists, and returns the path to it.
+func cacheAvatar(src, dir, name string) (string, error) {
+	dst := filepath.Join(dir, name+".png")
+	if _, err := os.Stat(dst); err == nil {
+		return filepath.ToSlash(dst), nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	resp, err := httpClient.Get(src)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", src, resp.Status)
+	}
+
+	tmp := dst + ".tmp"
+	fd, err := os.Create(tmp)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(fd, resp.Body); err != nil {
+		fd.Close()
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := syncClose(fd); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(dst), nil
+}
@@ -0,0 +1,144 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// redirectTransport sends all requests to the test server instead.
+type redirectTransport struct {
+	server *url.URL
+}
+
+func (r redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = r.server.Scheme
+	req.URL.Host = r.server.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestGithubProfileUser(t *testing.T) {
+	cases := map[string]string{
+		"https://github.com/jdoe":         "jdoe",
+		"https://www.github.com/jdoe/":    "jdoe",
+		"https://github.com/jdoe/project": "",
+		"https://github.com/":             "",
+		"https://gitlab.com/jdoe":         "",
+		"https://jdoe.example.com/":       "",
+		"://github.com/jdoe":              "",
+	}
+	for profile, expected := range cases {
+		if user := githubProfileUser(profile); user != expected {
+			t.Errorf("githubProfileUser(%q) = %q, expected %q", profile, user, expected)
+		}
+	}
+}
+
+func TestForgeAvatarURL(t *testing.T) {
+	cases := []struct {
+		a        author
+		expected string
+	}{
+		{author{emails: []string{"jdoe@example.com", "1234+jdoe@users.noreply.github.com"}}, "https://github.com/jdoe.png?size=32"},
+		{author{emails: []string{"jdoe@example.com"}, urls: []string{"https://jdoe.example.com", "https://github.com/jdoe"}}, "https://github.com/jdoe.png?size=32"},
+		{author{emails: []string{"jdoe@example.com"}}, ""},
+	}
+	for _, c := range cases {
+		if u := forgeAvatarURL(c.a, 32); u != c.expected {
+			t.Errorf("forgeAvatarURL(%v) = %q, expected %q", c.a, u, c.expected)
+		}
+	}
+}
+
+func TestCacheAvatars(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/jdoe.png" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("image " + r.URL.Query().Get("size")))
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(c *http.Client) { httpClient = c }(httpClient)
+	httpClient = &http.Client{Transport: redirectTransport{u}}
+
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	cache := filepath.Join(dir, "avatars")
+	authors := []author{
+		{name: "J Doe", emails: []string{"1234+jdoe@users.noreply.github.com"}},
+		{name: "Bob B", emails: []string{"bob@example.com"}},
+	}
+	if err := resolveAvatars(authors, 32, cache, false); err != nil {
+		t.Fatal(err)
+	}
+	id := authors[0].id()
+	expected := []string{filepath.ToSlash(filepath.Join(cache, id+"-32.png")), filepath.ToSlash(filepath.Join(cache, id+"-64.png"))}
+	if authors[0].avatar != expected[0] || authors[0].avatar2x != expected[1] {
+		t.Errorf("avatars %q, %q, expected %q", authors[0].avatar, authors[0].avatar2x, expected)
+	}
+	if bs, err := ioutil.ReadFile(expected[1]); err != nil || string(bs) != "image 64" {
+		t.Errorf("cached %q, %v", bs, err)
+	}
+	if authors[1].avatar != "" {
+		t.Errorf("avatar %q without a forge account", authors[1].avatar)
+	}
+
+	// Already cached
+	if err := resolveAvatars(authors[:1], 32, cache, false); err != nil || requests != 2 {
+		t.Errorf("%d requests, %v", requests, err)
+	}
+
+	authors = []author{{name: "Missing", urls: []string{"https://github.com/missing"}}}
+	if err := resolveAvatars(authors, 32, cache, false); err == nil {
+		t.Error("no error for a missing image")
+	}
+}
+
+func TestGravatar(t *testing.T) {
+	u := gravatarURL("alice@example.com", 32)
+	if !strings.HasPrefix(u, "https://www.gravatar.com/avatar/") || !strings.HasSuffix(u, "?s=32&d=identicon") {
+		t.Errorf("unexpected URL %q", u)
+	}
+	if other := gravatarURL(" Alice@Example.com ", 32); other != u {
+		t.Errorf("URL %q, expected %q as for the normalized email", other, u)
+	}
+
+	authors := []author{
+		{name: "J Doe", emails: []string{"1234+jdoe@users.noreply.github.com"}},
+		{name: "Alice A", emails: []string{"alice@example.com"}},
+		{name: "Nobody"},
+	}
+	if err := resolveAvatars(authors, 32, "", false); err != nil || authors[1].avatar != "" {
+		t.Errorf("avatar %q without -gravatar, %v", authors[1].avatar, err)
+	}
+	if err := resolveAvatars(authors, 32, "", true); err != nil {
+		t.Fatal(err)
+	}
+	if authors[0].avatar != "https://github.com/jdoe.png?size=32" {
+		t.Errorf("Gravatar %q for a forge account", authors[0].avatar)
+	}
+	if authors[1].avatar != u || authors[1].avatar2x != gravatarURL("alice@example.com", 64) {
+		t.Errorf("avatars %q, %q, expected Gravatars", authors[1].avatar, authors[1].avatar2x)
+	}
+	if authors[2].avatar != "" {
+		t.Errorf("avatar %q without an email", authors[2].avatar)
+	}
+}
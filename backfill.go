@@ -0,0 +1,159 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// A prAuthor is the GitHub user who opened a pull request. The login is
+// empty when the commit wasn't merged from a pull request.
+type prAuthor struct {
+	Login string `json:"login"`
+	ID    int    `json:"id"`
+}
+
+// githubMergedPR returns the author of the merged pull request the commit
+// is the merge commit of, using the token in $GITHUB_TOKEN if set.
+func githubMergedPR(repo, hash string) (prAuthor, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("https://api.github.com/repos/%s/commits/%s/pulls", repo, hash), nil)
+	if err != nil {
+		return prAuthor{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return prAuthor{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusUnprocessableEntity {
+		// Not a commit GitHub knows
+		return prAuthor{}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return prAuthor{}, fmt.Errorf("%s: %s", req.URL, resp.Status)
+	}
+	var prs []struct {
+		MergeCommitSHA string   `json:"merge_commit_sha"`
+		MergedAt       string   `json:"merged_at"`
+		User           prAuthor `json:"user"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&prs); err != nil {
+		return prAuthor{}, err
+	}
+	for _, pr := range prs {
+		if pr.MergedAt != "" && pr.MergeCommitSHA == hash {
+			return pr.User, nil
+		}
+	}
+	return prAuthor{}, nil
+}
+
+// A backfillCache maps commit hashes to the author of the pull request
+// they were merged from. Merged commits don't change, so the entries stay
+// valid.
+type backfillCache map[string]prAuthor
+
+func loadBackfillCache(file string) backfillCache {
+	cache := make(backfillCache)
+	bs, err := ioutil.ReadFile(file)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(bs, &cache); err != nil {
+		warn(warning{Kind: warnCache, File: file, Message: fmt.Sprintf("ignoring corrupt backfill cache: %v", err)})
+		return make(backfillCache)
+	}
+	return cache
+}
+
+func (c backfillCache) save(file string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	bs, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(file, false, func(w io.Writer) error {
+		_, err := w.Write(bs)
+		return err
+	})
+}
+
+// defaultBackfillCache returns the path of the backfill cache inside the
+// git directory.
+func defaultBackfillCache() string {
+	cmd := exec.Command("git", "rev-parse", "--git-path", "git-contributors/backfill-cache.json")
+	cmd.Stderr = os.Stderr
+	bs, err := cmd.Output()
+	if err != nil {
+		fatal("git:", err)
+	}
+	return strings.TrimSpace(string(bs))
+}
+
+// backfillCommits reattributes the commits made under the GitHub noreply
+// address of someone other than the author of the pull request they were
+// squash merged from, as happens when the merger's address ends up on the
+// commit. The commit is given to the pull request author, under an email
+// they commit with elsewhere in the history if there is one. Returns the
+// number of commits reattributed.
+func backfillCommits(commits []commit, repo string, cacheFile string) (int, error) {
+	// Emails and names by GitHub user, from the history
+	known := make(map[string]commit)
+	for i := len(commits) - 1; i >= 0; i-- {
+		if user := strings.ToLower(githubUsername(commits[i].email)); user != "" {
+			known[user] = commits[i]
+		}
+	}
+
+	cache := loadBackfillCache(cacheFile)
+	reattributed := 0
+	var err error
+	for i, c := range commits {
+		user := githubUsername(c.email)
+		if user == "" {
+			continue
+		}
+		pr, ok := cache[c.hash]
+		if !ok {
+			pr, err = githubMergedPR(repo, c.hash)
+			if err != nil {
+				break
+			}
+			cache[c.hash] = pr
+		}
+		if pr.Login == "" || strings.EqualFold(pr.Login, user) {
+			continue
+		}
+		if k, ok := known[strings.ToLower(pr.Login)]; ok {
+			commits[i].email, commits[i].name = k.email, k.name
+		} else {
+			commits[i].email = fmt.Sprintf("%d+%s@users.noreply.github.com", pr.ID, pr.Login)
+			commits[i].name = pr.Login
+		}
+		reattributed++
+	}
+
+	// Keep what we learned, even if the API gave up on us half way
+	if serr := cache.save(cacheFile); serr != nil {
+		warn(warning{Kind: warnCache, File: cacheFile, Message: serr.Error()})
+	}
+	return reattributed, err
+}
@@ -0,0 +1,101 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBackfillCommits(t *testing.T) {
+	// The pull requests by merge commit
+	prs := map[string]string{
+		"h1": `[{"merge_commit_sha": "h1", "merged_at": "2020-01-01T12:00:00Z", "user": {"login": "Alice", "id": 2}}]`,
+		"h2": `[{"merge_commit_sha": "h2", "merged_at": "2020-01-02T12:00:00Z", "user": {"login": "zed", "id": 9}}]`,
+		"h3": `[{"merge_commit_sha": "h3", "merged_at": "2020-01-03T12:00:00Z", "user": {"login": "alice", "id": 2}}]`,
+		"h5": `[{"merge_commit_sha": "other", "merged_at": "2020-01-05T12:00:00Z", "user": {"login": "zed", "id": 9}}]`,
+	}
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(r.URL.Path, "/")
+		if len(parts) != 7 || parts[2] != "example" || parts[3] != "project" || parts[6] != "pulls" {
+			t.Errorf("unexpected request %s", r.URL)
+			return
+		}
+		hash := parts[5]
+		requested = append(requested, hash)
+		switch pr, ok := prs[hash]; {
+		case hash == "h6":
+			w.WriteHeader(http.StatusInternalServerError)
+		case !ok:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		default:
+			fmt.Fprint(w, pr)
+		}
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(c *http.Client) { httpClient = c }(httpClient)
+	httpClient = &http.Client{Transport: redirectTransport{u}}
+
+	merger := "1+merger@users.noreply.github.com"
+	history := func() []commit {
+		return []commit{
+			{hash: "h1", email: merger, name: "Merger"},
+			{hash: "h2", email: merger, name: "Merger"},
+			{hash: "h3", email: "2+alice@users.noreply.github.com", name: "Alice A"},
+			{hash: "h4", email: merger, name: "Merger"},
+			{hash: "h5", email: merger, name: "Merger"},
+			{hash: "h7", email: "bob@example.com", name: "Bob B"},
+		}
+	}
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	cache := filepath.Join(dir, "cache", "backfill.json")
+
+	commits := history()
+	n, err := backfillCommits(commits, "example/project", cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("%d commits reattributed, expected 2", n)
+	}
+	// To Alice under the identity in the rest of the history, and to Zed
+	// under a noreply address made up from the pull request
+	expected := history()
+	expected[0].email, expected[0].name = "2+alice@users.noreply.github.com", "Alice A"
+	expected[1].email, expected[1].name = "9+zed@users.noreply.github.com", "zed"
+	for i := range commits {
+		if !reflect.DeepEqual(commits[i], expected[i]) {
+			t.Errorf("commit %+v, expected %+v", commits[i], expected[i])
+		}
+	}
+	if strings.Join(requested, " ") != "h1 h2 h3 h4 h5" {
+		t.Errorf("requested %q", requested)
+	}
+
+	// Cached
+	requested = nil
+	if n, err := backfillCommits(history(), "example/project", cache); err != nil || n != 2 || len(requested) != 0 {
+		t.Errorf("%d reattributed, %v, requested %q from the cache", n, err, requested)
+	}
+
+	commits = append(history(), commit{hash: "h6", email: merger})
+	if _, err := backfillCommits(commits, "example/project", cache); err == nil || !strings.Contains(err.Error(), "500 Internal Server Error") {
+		t.Errorf("error %v for a failing API", err)
+	}
+}
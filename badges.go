@@ -0,0 +1,83 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// certificateTemplate is the SVG contributor certificate. The logo is a
+// placeholder unless a logo image is given.
+var certificateTemplate = template.Must(template.New("certificate").Funcs(template.FuncMap{
+	"esc": html.EscapeString,
+}).Parse(`<svg xmlns="http://www.w3.org/2000/svg" width="600" height="400" viewBox="0 0 600 400">
+<rect x="0" y="0" width="600" height="400" fill="#fdfcf7"/>
+<rect x="12" y="12" width="576" height="376" fill="none" stroke="#8a6d1d" stroke-width="4"/>
+{{if .Logo}}<image x="260" y="36" width="80" height="80" href="{{esc .Logo}}"/>
+{{else}}<circle cx="300" cy="76" r="40" fill="#e8e2cc"/>
+<text x="300" y="82" font-family="sans-serif" font-size="14" fill="#8a6d1d" text-anchor="middle">LOGO</text>
+{{end}}<text x="300" y="160" font-family="serif" font-size="26" fill="#333" text-anchor="middle">Certificate of Contribution</text>
+<text x="300" y="200" font-family="sans-serif" font-size="14" fill="#666" text-anchor="middle">{{esc .Project}} thanks</text>
+<text x="300" y="250" font-family="serif" font-size="32" font-weight="bold" fill="#222" text-anchor="middle">{{esc .Name}}</text>
+<text x="300" y="295" font-family="sans-serif" font-size="16" fill="#444" text-anchor="middle">{{.Commits}} commit{{if ne .Commits 1}}s{{end}}{{if .Years}}, {{.Years}}{{end}} · contributor #{{.Rank}} of {{.Total}}</text>
+</svg>
+`))
+
+type certificate struct {
+	Project string
+	Logo    string
+	Name    string
+	Commits int
+	Years   string
+	Rank    int
+	Total   int
+}
+
+// projectName returns the name of the repository.
+func projectName() string {
+	r, err := currentRepository()
+	if err != nil {
+		return ""
+	}
+	return r.name()
+}
+
+// writeBadges writes an SVG contributor certificate for each author to the
+// directory, named by the author id. Authors are ranked by commits.
+func writeBadges(dir string, authors []author, project, logo string, backup bool) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	ranked := make([]author, len(authors))
+	copy(ranked, authors)
+	sortAuthors(ranked, func(a author) float64 { return float64(a.commits) })
+
+	for i, a := range ranked {
+		c := certificate{
+			Project: project,
+			Logo:    logo,
+			Name:    a.displayName(),
+			Commits: a.commits,
+			Years:   a.years(),
+			Rank:    i + 1,
+			Total:   len(ranked),
+		}
+		file := filepath.Join(dir, fmt.Sprintf("%s.svg", a.id()))
+		err := writeFileAtomic(file, backup, func(w io.Writer) error {
+			return certificateTemplate.Execute(w, c)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteBadges(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	day := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	authors := []author{
+		{name: "Bob <B>", emails: []string{"bob@example.com"}, commits: 1, first: day, last: day},
+		{name: "Alice & A", emails: []string{"alice@example.com"}, commits: 2, first: day.AddDate(-1, 0, 0), last: day},
+	}
+	out := filepath.Join(dir, "badges")
+
+	if err := writeBadges(out, authors, "Proj \"X\"", "logo.png", false); err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		a        author
+		expected []string
+	}{
+		{authors[1], []string{">Alice &amp; A</text>", ">2 commits, 2019-2020 · contributor #1 of 2</text>", ">Proj &#34;X&#34; thanks</text>", `href="logo.png"`}},
+		{authors[0], []string{">Bob &lt;B&gt;</text>", ">1 commit, 2020 · contributor #2 of 2</text>"}},
+	} {
+		bs, err := ioutil.ReadFile(filepath.Join(out, c.a.id()+".svg"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range c.expected {
+			if !strings.Contains(string(bs), s) {
+				t.Errorf("no %q in the certificate for %s:\n%s", s, c.a.name, bs)
+			}
+		}
+	}
+
+	// Without a logo there's a placeholder
+	if err := writeBadges(out, authors[:1], "Proj", "", false); err != nil {
+		t.Fatal(err)
+	}
+	if bs, err := ioutil.ReadFile(filepath.Join(out, authors[0].id()+".svg")); err != nil {
+		t.Fatal(err)
+	} else if !strings.Contains(string(bs), ">LOGO</text>") || strings.Contains(string(bs), "<image") {
+		t.Errorf("no placeholder logo:\n%s", bs)
+	}
+}
+
+func TestBadges(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	out := filepath.Join(dir, "badges")
+
+	mustRunMain(t, dir, "-badges", out, "-badge-project", "Example")
+	files, err := filepath.Glob(filepath.Join(out, "*.svg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Errorf("badges %v, expected one per author", files)
+	}
+}
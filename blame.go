@@ -0,0 +1,214 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// A blameCache maps "blob path" keys to the number of lines per author
+// email in that file. The blame for a given file content at a given path
+// only changes when history is rewritten, so this is a good enough key to
+// avoid rerunning blame for unchanged files.
+type blameCache map[string]map[string]int
+
+func loadBlameCache(file string) blameCache {
+	cache := make(blameCache)
+	bs, err := ioutil.ReadFile(file)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(bs, &cache); err != nil {
+		warn(warning{Kind: warnCache, File: file, Message: fmt.Sprintf("ignoring corrupt blame cache: %v", err)})
+		return make(blameCache)
+	}
+	return cache
+}
+
+func (c blameCache) save(file string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	bs, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(file, false, func(w io.Writer) error {
+		_, err := w.Write(bs)
+		return err
+	})
+}
+
+// defaultBlameCache returns the path of the blame cache inside the git
+// directory.
+func defaultBlameCache() string {
+	cmd := exec.Command("git", "rev-parse", "--git-path", "git-contributors/blame-cache.json")
+	cmd.Stderr = os.Stderr
+	bs, err := cmd.Output()
+	if err != nil {
+		fatal("git:", err)
+	}
+	return strings.TrimSpace(string(bs))
+}
+
+// blameLines returns the number of surviving lines at HEAD per author
+// email, running blame for the files in parallel. Results are cached in
+// the given file, if set.
+func blameLines(parallel int, cacheFile string) map[string]int {
+	cache := make(blameCache)
+	if cacheFile != "" {
+		cache = loadBlameCache(cacheFile)
+	}
+
+	type file struct {
+		key  string
+		path string
+	}
+	files := make(chan file)
+	go func() {
+		defer close(files)
+		for _, f := range trackedFiles() {
+			files <- file{key: f.blob + " " + f.path, path: f.path}
+		}
+	}()
+
+	var mut sync.Mutex
+	newCache := make(blameCache)
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for f := range files {
+				counts, ok := cache[f.key]
+				if !ok {
+					counts = blameFile(f.path)
+				}
+				mut.Lock()
+				newCache[f.key] = counts
+				mut.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if cacheFile != "" {
+		// Only files still present are kept, so the cache doesn't grow
+		// forever.
+		if err := newCache.save(cacheFile); err != nil {
+			warnf(warnCache, "saving blame cache: %v", err)
+		}
+	}
+
+	lines := make(map[string]int)
+	for _, counts := range newCache {
+		for email, n := range counts {
+			lines[email] += n
+		}
+	}
+	return lines
+}
+
+type trackedFile struct {
+	blob string
+	path string
+}
+
+// trackedFiles returns the regular files in the tree at HEAD, wherever in
+// the work tree we are.
+func trackedFiles() []trackedFile {
+	cmd := exec.Command("git", "ls-tree", "--full-tree", "-r", "-z", "HEAD")
+	cmd.Stderr = os.Stderr
+	bs, err := cmd.Output()
+	if err != nil {
+		fatal("git:", err)
+	}
+
+	var files []trackedFile
+	for _, entry := range bytes.Split(bs, []byte{0}) {
+		// <mode> SP <type> SP <object> TAB <file>
+		tab := bytes.IndexByte(entry, '\t')
+		if tab < 0 {
+			continue
+		}
+		fields := strings.Fields(string(entry[:tab]))
+		if len(fields) != 3 || fields[1] != "blob" {
+			continue
+		}
+		files = append(files, trackedFile{blob: fields[2], path: string(entry[tab+1:])})
+	}
+	return files
+}
+
+// blameFile returns the number of lines per author email in the file at
+// HEAD, given relative to the top of the work tree.
+func blameFile(path string) map[string]int {
+	cmd := exec.Command("git", "blame", "--line-porcelain", "HEAD", "--", path)
+	cmd.Dir = mustRepository().workTree
+	cmd.Stderr = os.Stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		fatal("git:", err)
+	}
+
+	counts := make(map[string]int)
+	sc := bufio.NewScanner(out)
+	sc.Buffer(make([]byte, 64<<10), maxRecordSize)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "author-mail <") {
+			counts[string(toUTF8([]byte(strings.TrimSuffix(line[len("author-mail <"):], ">"))))]++
+		}
+	}
+	if err := sc.Err(); err != nil {
+		fatal("git:", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		fatal("git:", err)
+	}
+	return counts
+}
+
+// getBlameLines attributes the surviving lines per email, after the
+// rewrite rules, to authors.
+func getBlameLines(authors []author, idx *authorIndex, lines map[string]int, rewrites []rewriteRule) {
+	for email, n := range lines {
+		if i, ok := idx.email(rewrite(rewrites, email)); ok {
+			authors[i].blameLines += n
+		}
+	}
+}
+
+// writeBlameLines writes the number of surviving lines per author, most
+// lines first, skipping authors without any.
+func writeBlameLines(w io.Writer, authors []author) error {
+	sorted := make([]author, len(authors))
+	copy(sorted, authors)
+	sortAuthors(sorted, func(a author) float64 { return float64(a.blameLines) })
+
+	bw := bufio.NewWriter(w)
+	for _, a := range sorted {
+		if a.blameLines == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "%7d %s\n", a.blameLines, a.displayName())
+	}
+	return bw.Flush()
+}
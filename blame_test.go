@@ -0,0 +1,52 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestBlame(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	cacheFile := filepath.Join(dir, "cache", "blame.json")
+
+	expected := "      2 Alice A\n      1 Bob B\n      1 Carol C\n"
+	if out := mustRunMain(t, dir, "-blame", "-blame-cache", cacheFile); out != expected {
+		t.Errorf("output\n%s\nexpected\n%s", out, expected)
+	}
+
+	bs, err := ioutil.ReadFile(cacheFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cache blameCache
+	if err := json.Unmarshal(bs, &cache); err != nil {
+		t.Fatal(err)
+	}
+	key := revParse(t, dir, "HEAD:alice.txt") + " alice.txt"
+	if cache[key]["alice@example.com"] != 2 || len(cache) != 3 {
+		t.Errorf("unexpected cache %v", cache)
+	}
+
+	// The cached counts are used instead of running blame
+	cache[key]["alice@example.com"] = 10
+	bs, err = json.Marshal(cache)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, dir, "cache/blame.json", string(bs))
+	if out := mustRunMain(t, dir, "-blame", "-blame-cache", cacheFile); !containsLine(out, "     10 Alice A") {
+		t.Errorf("cache not used\n%s", out)
+	}
+	if out := mustRunMain(t, dir, "-blame", "-no-blame-cache"); out != expected {
+		t.Errorf("output without cache\n%s", out)
+	}
+}
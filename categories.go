@@ -0,0 +1,106 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// defaultCategoryRules map conventional commit types to categories.
+var defaultCategoryRules = map[string]string{
+	"feat":     "feature",
+	"fix":      "fix",
+	"docs":     "docs",
+	"chore":    "chore",
+	"refactor": "refactor",
+	"perf":     "performance",
+	"test":     "test",
+	"build":    "build",
+	"ci":       "build",
+	"style":    "style",
+	"revert":   "revert",
+}
+
+// otherCategory is used for commits not matching any rule.
+const otherCategory = "other"
+
+// readCategoryRules reads a file of "prefix category" lines.
+func readCategoryRules(file string) map[string]string {
+	rules := make(map[string]string)
+	for _, line := range readLines(file) {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			fatalf("%s: malformed rule %q", file, line)
+		}
+		rules[strings.ToLower(strings.TrimSuffix(fields[0], ":"))] = fields[1]
+	}
+	return rules
+}
+
+// subjectPrefix returns the lower cased part of the commit subject before
+// the first colon, without any conventional commit scope or breaking change
+// marker. I.e., "feat(ui)!: Add thing" becomes "feat".
+func subjectPrefix(message string) string {
+	subject := commitSubject(message)
+	idx := strings.IndexByte(subject, ':')
+	if idx < 0 {
+		return ""
+	}
+	prefix := strings.TrimSuffix(subject[:idx], "!")
+	if idx := strings.IndexByte(prefix, '('); idx >= 0 {
+		prefix = prefix[:idx]
+	}
+	return strings.ToLower(strings.TrimSpace(prefix))
+}
+
+// getCategories counts the commits per category for each author.
+func getCategories(authors []author, idx *authorIndex, commits []commit, rules map[string]string) {
+	for _, c := range commits {
+		i, ok := idx.email(c.email)
+		if !ok {
+			continue
+		}
+		cat, ok := rules[subjectPrefix(c.message)]
+		if !ok {
+			cat = otherCategory
+		}
+		if authors[i].categories == nil {
+			authors[i].categories = make(map[string]int)
+		}
+		authors[i].categories[cat]++
+	}
+}
+
+// writeCategories writes the category counts for each author, most common
+// category first.
+func writeCategories(w io.Writer, authors []author) error {
+	bw := bufio.NewWriter(w)
+	for _, a := range authors {
+		cats := make([]string, 0, len(a.categories))
+		for cat := range a.categories {
+			cats = append(cats, cat)
+		}
+		sort.Slice(cats, func(i, j int) bool {
+			ci, cj := a.categories[cats[i]], a.categories[cats[j]]
+			if ci != cj {
+				return ci > cj
+			}
+			return cats[i] < cats[j]
+		})
+
+		fmt.Fprintf(bw, "%5d %s:", a.commits, a.displayName())
+		for _, cat := range cats {
+			fmt.Fprintf(bw, " %s=%d", cat, a.categories[cat])
+		}
+		fmt.Fprintf(bw, "\n")
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,54 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSubjectPrefix(t *testing.T) {
+	cases := map[string]string{
+		"feat: Add thing":                 "feat",
+		"Feat(ui)!: Add thing\n\nBody: x": "feat",
+		"fix(lib/model): Handle nil":      "fix",
+		"lib/model: Handle nil":           "lib/model",
+		"Add thing":                       "",
+	}
+	for msg, expected := range cases {
+		if got := subjectPrefix(msg); got != expected {
+			t.Errorf("subjectPrefix(%q) = %q, expected %q", msg, got, expected)
+		}
+	}
+}
+
+func TestCategories(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Alice A <alice@example.com>", message: "feat: One"},
+		testCommit{author: "Alice A <alice@example.com>", message: "fix(x): Two"},
+		testCommit{author: "Alice A <alice@example.com>", message: "Fix: Three"},
+		testCommit{author: "Alice A <alice@example.com>", message: "gui: Four"},
+		testCommit{author: "Bob B <bob@example.com>", message: "Five"},
+	)
+	defer cleanup()
+
+	out := mustRunMain(t, dir, "-categories")
+	if out != "    4 Alice A: fix=2 feature=1 other=1\n    1 Bob B: other=1\n" {
+		t.Errorf("unexpected output\n%s", out)
+	}
+
+	rules := writeTestFile(t, dir, "rules", "# Our prefixes\ngui: frontend\nFIX bugfix\n")
+	out = mustRunMain(t, dir, "-categories", "-category-rules", rules)
+	if out != "    4 Alice A: bugfix=2 frontend=1 other=1\n    1 Bob B: other=1\n" {
+		t.Errorf("unexpected output with rules\n%s", out)
+	}
+
+	bad := writeTestFile(t, dir, "bad", "gui\n")
+	if _, stderr, code := runMain(t, dir, "-categories", "-category-rules", bad); code == 0 || !strings.Contains(stderr, `malformed rule "gui"`) {
+		t.Errorf("exit code %d for a bad rule\n%s", code, stderr)
+	}
+}
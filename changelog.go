@@ -0,0 +1,121 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// changelogHeaderRe matches the header of a changelog entry, capturing the
+// commit it was made at.
+var changelogHeaderRe = regexp.MustCompile(`(?m)^## \S+ \S+ (?:[0-9a-f]+\.\.)?([0-9a-f]+)$`)
+
+// readAuthorsIfExists returns the authors in the file, or nil if there is
+// no such file.
+func readAuthorsIfExists(file string) []author {
+	if _, err := os.Stat(file); err != nil {
+		return nil
+	}
+	return getAuthors(file)
+}
+
+// diffAuthors describes the differences between the old and new author
+// lists, one line per added, removed or changed author. Authors are
+// matched by any of their emails.
+func diffAuthors(old, new []author) []string {
+	idx := newAuthorIndex(old)
+	matched := make([]bool, len(old))
+	var lines []string
+	for _, a := range new {
+		i, ok := -1, false
+		for _, e := range a.emails {
+			if i, ok = idx.email(e); ok {
+				break
+			}
+		}
+		if !ok {
+			line := "Added: " + a.fullName()
+			for _, e := range a.emails {
+				line += " <" + e + ">"
+			}
+			lines = append(lines, line)
+			continue
+		}
+		matched[i] = true
+
+		var changes []string
+		if o := old[i]; o.fullName() != a.fullName() {
+			changes = append(changes, fmt.Sprintf("renamed from %s", o.fullName()))
+		}
+		oldEmails := stringSetFromStrings(old[i].emails)
+		newEmails := stringSetFromStrings(a.emails)
+		for _, e := range a.emails {
+			if !oldEmails.has(e) {
+				changes = append(changes, "added <"+e+">")
+			}
+		}
+		for _, e := range old[i].emails {
+			if !newEmails.has(e) {
+				changes = append(changes, "removed <"+e+">")
+			}
+		}
+		if len(changes) > 0 {
+			lines = append(lines, fmt.Sprintf("Changed: %s: %s", a.fullName(), strings.Join(changes, ", ")))
+		}
+	}
+	for i, o := range old {
+		if !matched[i] {
+			lines = append(lines, "Removed: "+o.fullName())
+		}
+	}
+	return lines
+}
+
+// appendChangelog appends an entry describing the changes from the old to
+// the new authors in the AUTHORS file to the changelog, if there are any.
+// The entry is headed by the date of now and the commit range since the
+// previous entry.
+func appendChangelog(changelog, authorsFile string, old, new []author, now time.Time) error {
+	lines := diffAuthors(old, new)
+	if len(lines) == 0 {
+		return nil
+	}
+
+	head := "unknown"
+	if bs, err := exec.Command("git", "rev-parse", "--short", "HEAD").Output(); err == nil {
+		head = strings.TrimSpace(string(bs))
+	}
+	rng := head
+	if bs, err := ioutil.ReadFile(changelog); err == nil {
+		if m := changelogHeaderRe.FindAllStringSubmatch(string(bs), -1); len(m) > 0 {
+			rng = m[len(m)-1][1] + ".." + head
+		}
+	}
+
+	fd, err := os.OpenFile(changelog, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(fd)
+	fmt.Fprintf(bw, "## %s %s %s\n\n", now.UTC().Format("2006-01-02"), authorsFile, rng)
+	for _, line := range lines {
+		fmt.Fprintf(bw, "- %s\n", line)
+	}
+	fmt.Fprintf(bw, "\n")
+	if err := bw.Flush(); err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
@@ -0,0 +1,59 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestDiffAuthors(t *testing.T) {
+	old := parseAuthors([]byte("Alice A <alice@example.com>\nBob B <bob@example.com> <bob@old.example.com>\nCarol C <carol@example.com>\n"))
+	new := parseAuthors([]byte("Alice Anderson (alice) <alice@example.com>\nBob B <bob@example.com> <bob@new.example.com>\nDave D <dave@example.com> <dd@example.com>\n"))
+	expected := []string{
+		"Changed: Alice Anderson (alice): renamed from Alice A",
+		"Changed: Bob B: added <bob@new.example.com>, removed <bob@old.example.com>",
+		"Added: Dave D <dave@example.com> <dd@example.com>",
+		"Removed: Carol C",
+	}
+	if lines := diffAuthors(old, new); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("diff %q, expected %q", lines, expected)
+	}
+	if lines := diffAuthors(old, old); len(lines) != 0 {
+		t.Errorf("diff of the same lists %q", lines)
+	}
+}
+
+func TestAuthorsChangelog(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors[:3]...)
+	defer cleanup()
+	writeTestFile(t, dir, "AUTHORS", "Alice A <alice@example.com>\nBob B <bob@example.com>\n")
+	args := []string{"-read-authors", "AUTHORS", "-out", "authors=AUTHORS", "-authors-changelog", "CHANGES"}
+
+	// Nothing changes, so there is no entry
+	mustRunMain(t, dir, args...)
+	commitFiles(t, dir, threeAuthors[3])
+	first := revParse(t, dir, "HEAD")[:7]
+	mustRunMain(t, dir, args...)
+	mustRunMain(t, dir, args...)
+	commitFiles(t, dir, testCommit{author: "Alice A <alice@laptop.example.com>", date: "2020-05-01T12:00:00Z", message: "Again"})
+	second := revParse(t, dir, "HEAD")[:7]
+	mustRunMain(t, dir, append(args, "-no-name-matching")...)
+
+	bs, err := ioutil.ReadFile(filepath.Join(dir, "CHANGES"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := regexp.MustCompile(`^## \d{4}-\d{2}-\d{2} AUTHORS ` + first + "\n\n- Added: Carol C <carol@example.com>\n\n" +
+		`## \d{4}-\d{2}-\d{2} AUTHORS ` + first + `\.\.` + second + "\n\n- Added: Alice A <alice@laptop.example.com>\n\n$")
+	if !expected.Match(bs) {
+		t.Errorf("unexpected changelog\n%s", bs)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// A checkResult holds the differences between the AUTHORS file and the git
+// history.
+type checkResult struct {
+	Missing   []authorView `json:"missing"`   // authors not in the AUTHORS file
+	NewEmails []newEmails  `json:"newEmails"` // unlisted emails for listed authors
+	Stale     []authorView `json:"stale"`     // listed authors without commits
+
+	// Results for path scoped AUTHORS files, by file name
+	Scopes map[string]checkResult `json:"scopes,omitempty"`
+}
+
+type newEmails struct {
+	Author authorView `json:"author"`
+	Emails []string   `json:"emails"`
+}
+
+func (r checkResult) ok() bool {
+	for _, s := range r.Scopes {
+		if !s.ok() {
+			return false
+		}
+	}
+	return len(r.Missing) == 0 && len(r.NewEmails) == 0 && len(r.Stale) == 0
+}
+
+func (r checkResult) writeText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	r.writeTextTo(bw)
+
+	files := make([]string, 0, len(r.Scopes))
+	for file := range r.Scopes {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		if s := r.Scopes[file]; !s.ok() {
+			fmt.Fprintf(bw, "\n%s:\n", file)
+			s.writeTextTo(bw)
+		}
+	}
+	return bw.Flush()
+}
+
+func (r checkResult) writeTextTo(bw *bufio.Writer) {
+	for _, a := range r.Missing {
+		fmt.Fprintf(bw, "Missing author: %s", a.DisplayName)
+		for _, e := range a.Emails {
+			fmt.Fprintf(bw, " <%s>", e)
+		}
+		fmt.Fprintf(bw, "\n")
+	}
+	for _, n := range r.NewEmails {
+		fmt.Fprintf(bw, "New email for %s:", n.Author.DisplayName)
+		for _, e := range n.Emails {
+			fmt.Fprintf(bw, " <%s>", e)
+		}
+		fmt.Fprintf(bw, "\n")
+	}
+	for _, a := range r.Stale {
+		fmt.Fprintf(bw, "Stale author: %s\n", a.DisplayName)
+	}
+}
+
+func (r checkResult) writeJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// checkAuthors compares the (filtered) authors against what was listed in
+// the AUTHORS file.
+func checkAuthors(authors, stale []author, listedEmails stringSet) checkResult {
+	res := checkResult{
+		Missing:   []authorView{},
+		NewEmails: []newEmails{},
+		Stale:     newAuthorViews(stale),
+	}
+	for _, a := range authors {
+		if !a.listed {
+			res.Missing = append(res.Missing, newAuthorView(a))
+			continue
+		}
+		var emails []string
+		for _, e := range a.emails {
+			if !listedEmails.has(e) {
+				emails = append(emails, e)
+			}
+		}
+		if len(emails) > 0 {
+			res.NewEmails = append(res.NewEmails, newEmails{
+				Author: newAuthorView(a),
+				Emails: emails,
+			})
+		}
+	}
+	return res
+}
+
+// withoutStale returns the result without the stale authors, including
+// those of the scopes. When only part of the history is read, listed
+// authors without commits in it may well have older ones.
+func (r checkResult) withoutStale() checkResult {
+	r.Stale = []authorView{}
+	if r.Scopes != nil {
+		scopes := make(map[string]checkResult, len(r.Scopes))
+		for file, s := range r.Scopes {
+			scopes[file] = s.withoutStale()
+		}
+		r.Scopes = scopes
+	}
+	return r
+}
+
+// checkListed compares the authors against the listed entries by email
+// only. This is used for the scoped AUTHORS files, where the authors come
+// from the top level analysis.
+func checkListed(authors, listed []author) checkResult {
+	listedEmails := make(stringSet)
+	for _, l := range listed {
+		for _, e := range l.emails {
+			listedEmails.add(e)
+		}
+	}
+	authorEmails := make(stringSet)
+	for i := range authors {
+		a := &authors[i]
+		for _, e := range a.emails {
+			authorEmails.add(e)
+			if listedEmails.has(e) {
+				a.listed = true
+			}
+		}
+	}
+
+	var stale []author
+	for _, l := range listed {
+		found := false
+		for _, e := range l.emails {
+			if authorEmails.has(e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			stale = append(stale, l)
+		}
+	}
+
+	return checkAuthors(authors, stale, listedEmails)
+}
+
+// staleAuthors returns the authors from the AUTHORS file that have no
+// commits, nor tags when credited.
+func staleAuthors(authors []author) []author {
+	var stale []author
+	for _, a := range authors {
+		if a.listed && a.commits == 0 && a.tagged == 0 {
+			stale = append(stale, a)
+		}
+	}
+	return stale
+}
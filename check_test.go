@@ -0,0 +1,53 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	commits := append(threeAuthors[:len(threeAuthors):len(threeAuthors)],
+		testCommit{author: "Alice A <alice@laptop.local>", date: "2020-05-01T12:00:00Z", message: "Fix alice"})
+	dir, cleanup := newHistoryRepo(t, commits...)
+	defer cleanup()
+	authors := writeTestFile(t, dir, "AUTHORS", "Alice A <alice@example.com>\nBob B <bob@example.com>\nDave D <dave@example.com>\n")
+
+	stdout, _, code := runMain(t, dir, "-read-authors", authors, "-check")
+	if code != 1 {
+		t.Errorf("exit code %d for differences", code)
+	}
+	expected := "Missing author: Carol C <carol@example.com>\n" +
+		"New email for Alice A: <alice@laptop.local>\n" +
+		"Stale author: Dave D\n"
+	if stdout != expected {
+		t.Errorf("output\n%s\nexpected\n%s", stdout, expected)
+	}
+
+	stdout, _, code = runMain(t, dir, "-read-authors", authors, "-check", "-check-json")
+	if code != 1 {
+		t.Errorf("exit code %d for differences", code)
+	}
+	var res checkResult
+	if err := json.Unmarshal([]byte(stdout), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Missing) != 1 || res.Missing[0].Name != "Carol C" ||
+		len(res.NewEmails) != 1 || res.NewEmails[0].Author.Name != "Alice A" || len(res.NewEmails[0].Emails) != 1 ||
+		len(res.Stale) != 1 || res.Stale[0].Name != "Dave D" {
+		t.Errorf("unexpected JSON\n%s", stdout)
+	}
+
+	writeTestFile(t, dir, "AUTHORS", "Alice A <alice@example.com> <alice@laptop.local>\nBob B <bob@example.com>\nCarol C <carol@example.com>\n")
+	if out := mustRunMain(t, dir, "-read-authors", authors, "-check"); out != "" {
+		t.Errorf("unexpected output for an up to date file\n%s", out)
+	}
+	if out := mustRunMain(t, dir, "-read-authors", authors, "-check", "-check-json"); out != "{\n  \"missing\": [],\n  \"newEmails\": [],\n  \"stale\": []\n}\n" {
+		t.Errorf("unexpected JSON for an up to date file\n%s", out)
+	}
+}
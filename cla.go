@@ -0,0 +1,75 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// readCLA reads the file of emails and user names that have signed the
+// CLA, lower cased, as case doesn't matter; like a policy file.
+func readCLA(file string) stringSet {
+	signed := make(stringSet)
+	for _, line := range readLines(file) {
+		signed.add(strings.ToLower(line))
+	}
+	return signed
+}
+
+// missingCLA returns the authors with commits after the given time who
+// aren't in the lower cased signed set by any of their emails, nickname or
+// GitHub user name.
+func missingCLA(authors []author, signed stringSet, since time.Time) []author {
+	var missing []author
+	for _, a := range authors {
+		if a.last.Before(since) || hasSigned(a, signed) {
+			continue
+		}
+		missing = append(missing, a)
+	}
+	return missing
+}
+
+func hasSigned(a author, signed stringSet) bool {
+	if a.nickname != "" && signed.has(strings.ToLower(a.nickname)) {
+		return true
+	}
+	for _, e := range a.emails {
+		e = strings.ToLower(e)
+		if signed.has(e) {
+			return true
+		}
+		if user := githubUsername(e); user != "" && signed.has(user) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeMissingCLA writes a line for each author missing a CLA, with their
+// email, if they have one, and the date of their last commit. Authors read
+// from the AUTHORS file need not have an email, and with -min 0 they are
+// listed even without commits.
+func writeMissingCLA(w io.Writer, missing []author) error {
+	bw := bufio.NewWriter(w)
+	for _, a := range missing {
+		fmt.Fprintf(bw, "Missing CLA: %s", a.displayName())
+		if len(a.emails) > 0 {
+			fmt.Fprintf(bw, " <%s>", a.emails[0])
+		}
+		if a.last.IsZero() {
+			fmt.Fprintf(bw, " (no commits)\n")
+			continue
+		}
+		fmt.Fprintf(bw, " (last commit %s)\n", a.last.Format("2006-01-02"))
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,68 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHasSigned(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	signed := readCLA(writeTestFile(t, dir, "CLA", "# Signed\nalice@example.com\nBobUser\ncarol\n"))
+
+	cases := []struct {
+		a      author
+		signed bool
+	}{
+		{author{emails: []string{"alice@example.com"}}, true},
+		{author{emails: []string{"Alice@Example.com"}}, true},
+		{author{emails: []string{"other@example.com", "ALICE@example.com"}}, true},
+		{author{emails: []string{"123+bobuser@users.noreply.github.com"}}, true},
+		{author{emails: []string{"BobUser@users.noreply.github.com"}}, true},
+		{author{nickname: "Carol", emails: []string{"c@example.com"}}, true},
+		{author{emails: []string{"dave@example.com"}}, false},
+		{author{emails: []string{"bobuser@example.com"}}, false},
+		{author{}, false},
+	}
+	for _, c := range cases {
+		if s := hasSigned(c.a, signed); s != c.signed {
+			t.Errorf("hasSigned(%v) = %v, expected %v", c.a, s, c.signed)
+		}
+	}
+}
+
+func TestMissingCLA(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	cla := writeTestFile(t, dir, "CLA", "ALICE@example.com\n")
+	authors := writeTestFile(t, dir, "AUTHORS", "Alice A <alice@example.com>\nNo Email\n")
+
+	stdout, _, code := runMain(t, dir, "-cla", cla, "-read-authors", authors, "-min", "0")
+	expected := "Missing CLA: Bob B <bob@example.com> (last commit 2020-01-01)\nMissing CLA: Carol C <carol@example.com> (last commit 2020-04-01)\nMissing CLA: No Email (no commits)\n"
+	if code != 1 || stdout != expected {
+		t.Errorf("exit code %d, output\n%s\nexpected\n%s", code, stdout, expected)
+	}
+
+	stdout, _, code = runMain(t, dir, "-cla", cla, "-cla-since", "2020-03-01")
+	expected = "Missing CLA: Carol C <carol@example.com> (last commit 2020-04-01)\n"
+	if code != 1 || stdout != expected {
+		t.Errorf("exit code %d, output\n%s\nexpected\n%s", code, stdout, expected)
+	}
+}
+
+func TestMissingCLASince(t *testing.T) {
+	authors := []author{
+		{name: "Old", emails: []string{"old@example.com"}, last: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "New", emails: []string{"new@example.com"}, last: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	missing := missingCLA(authors, make(stringSet), time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	if len(missing) != 1 || missing[0].name != "New" {
+		t.Errorf("missing %v, expected only New", missing)
+	}
+}
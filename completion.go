@@ -0,0 +1,125 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// commands are the subcommands, given after the flags.
+var commands = []string{"apply", "completion", "convert", "doctor", "export", "help", "import", "install-hook", "lint", "merge-authors", "name-conflicts", "review", "serve", "version"}
+
+// flagValues returns the possible values of the flags that take one of a
+// known set, for completion.
+func flagValues() map[string][]string {
+	var outs []string
+	for _, name := range outputNames() {
+		outs = append(outs, name+"=")
+	}
+	return map[string][]string{
+		"authors-format": {"text", "yaml"},
+		"module-format":  {"names", "stats"},
+		"name-style":     {"full", "short", "initials"},
+		"out":            outs,
+	}
+}
+
+type completionFlag struct {
+	name    string
+	usage   string
+	isBool  bool
+	choices []string
+}
+
+func completionFlags() []completionFlag {
+	values := flagValues()
+	var flags []completionFlag
+	flag.VisitAll(func(f *flag.Flag) {
+		b, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{
+			name:    f.Name,
+			usage:   f.Usage,
+			isBool:  ok && b.IsBoolFlag(),
+			choices: values[f.Name],
+		})
+	})
+	sort.Slice(flags, func(a, b int) bool {
+		return flags[a].name < flags[b].name
+	})
+	return flags
+}
+
+// writeCompletion writes a completion script for the shell, one of bash,
+// zsh or fish.
+func writeCompletion(w io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		return writeBashCompletion(w, false)
+	case "zsh":
+		return writeBashCompletion(w, true)
+	case "fish":
+		return writeFishCompletion(w)
+	default:
+		return fmt.Errorf("unsupported shell %q (bash, zsh, fish)", shell)
+	}
+}
+
+// writeBashCompletion writes a bash completion script, which zsh can also
+// use through bashcompinit.
+func writeBashCompletion(w io.Writer, zsh bool) error {
+	flags := completionFlags()
+	var names, valued []string
+	for _, f := range flags {
+		names = append(names, "-"+f.name)
+		if !f.isBool {
+			valued = append(valued, "-"+f.name)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	if zsh {
+		fmt.Fprintf(bw, "autoload -U +X bashcompinit && bashcompinit\n\n")
+	}
+	fmt.Fprintf(bw, "_git_contributors() {\n")
+	fmt.Fprintf(bw, "\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	fmt.Fprintf(bw, "\tcase \"$prev\" in\n")
+	for _, f := range flags {
+		if len(f.choices) > 0 {
+			fmt.Fprintf(bw, "\t-%s|--%s)\n\t\tCOMPREPLY=($(compgen -W %s -- \"$cur\"))\n\t\treturn ;;\n", f.name, f.name, shellQuote(strings.Join(f.choices, " ")))
+		}
+	}
+	fmt.Fprintf(bw, "\t%s)\n\t\tCOMPREPLY=($(compgen -f -- \"$cur\"))\n\t\treturn ;;\n", strings.Join(valued, "|"))
+	fmt.Fprintf(bw, "\tesac\n")
+	fmt.Fprintf(bw, "\tcase \"$cur\" in\n")
+	fmt.Fprintf(bw, "\t-*) COMPREPLY=($(compgen -W %s -- \"$cur\")) ;;\n", shellQuote(strings.Join(names, " ")))
+	fmt.Fprintf(bw, "\t*) COMPREPLY=($(compgen -W %s -- \"$cur\") $(compgen -f -- \"$cur\")) ;;\n", shellQuote(strings.Join(commands, " ")))
+	fmt.Fprintf(bw, "\tesac\n")
+	fmt.Fprintf(bw, "}\n\n")
+	fmt.Fprintf(bw, "complete -F _git_contributors git-contributors\n")
+	return bw.Flush()
+}
+
+func writeFishCompletion(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "complete -c git-contributors -n __fish_use_subcommand -f -a %s\n", shellQuote(strings.Join(commands, " ")))
+	for _, f := range completionFlags() {
+		fmt.Fprintf(bw, "complete -c git-contributors -o %s -d %s", f.name, shellQuote(f.usage))
+		switch {
+		case len(f.choices) > 0:
+			fmt.Fprintf(bw, " -x -a %s", shellQuote(strings.Join(f.choices, " ")))
+		case !f.isBool:
+			fmt.Fprintf(bw, " -r -F")
+		}
+		fmt.Fprintf(bw, "\n")
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestBashCompletion(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("no bash")
+	}
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	script := writeTestFile(t, dir, "completion.bash", mustRunMain(t, dir, "completion", "bash"))
+
+	// complete returns the completions of the last word of the command line
+	complete := func(words ...string) []string {
+		t.Helper()
+		line := "source " + shellQuote(script) + "; COMP_WORDS=(git-contributors"
+		for _, w := range words {
+			line += " " + shellQuote(w)
+		}
+		line += "); COMP_CWORD=${#COMP_WORDS[@]}; COMP_CWORD=$((COMP_CWORD-1)); _git_contributors; printf '%s\\n' \"${COMPREPLY[@]}\""
+		cmd := exec.Command(bash, "-c", line)
+		cmd.Dir = dir
+		bs, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("%v\n%s", err, bs)
+		}
+		return strings.Fields(string(bs))
+	}
+
+	cases := []struct {
+		words    []string
+		expected string
+	}{
+		{[]string{"-authors-f"}, "-authors-format"},
+		{[]string{"-authors-format", ""}, "text yaml"},
+		{[]string{"-name-style", "s"}, "short"},
+		{[]string{"comp"}, "completion completion.bash"},
+		{[]string{"-read-authors", "compl"}, "completion.bash"},
+	}
+	for _, c := range cases {
+		if res := strings.Join(complete(c.words...), " "); res != c.expected {
+			t.Errorf("completion of %q: %q, expected %q", c.words, res, c.expected)
+		}
+	}
+}
+
+func TestFishCompletion(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	out := mustRunMain(t, dir, "completion", "fish")
+	for _, line := range []string{
+		"complete -c git-contributors -o authors-format -d 'Format of the AUTHORS list (text, yaml)' -x -a 'text yaml'",
+		"complete -c git-contributors -o stats -d 'Print the statistics'",
+	} {
+		if !containsLine(out, line) {
+			t.Errorf("no %q in\n%s", line, out)
+		}
+	}
+	if !strings.Contains(out, "-o read-authors -d ") || !strings.Contains(out, " -r -F\n") {
+		t.Errorf("no file completion in\n%s", out)
+	}
+}
+
+func TestCompletionShells(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	if out := mustRunMain(t, dir, "completion", "zsh"); !strings.HasPrefix(out, "autoload -U +X bashcompinit && bashcompinit\n") {
+		t.Errorf("unexpected zsh completion\n%s", out)
+	}
+	if _, stderr, code := runMain(t, dir, "completion", "tcsh"); code == 0 || !strings.Contains(stderr, `unsupported shell "tcsh"`) {
+		t.Errorf("exit code %d for an unsupported shell\n%s", code, stderr)
+	}
+}
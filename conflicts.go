@@ -0,0 +1,123 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// A nameConflict is a person, as recognized by shared emails, listed
+// under different names in different AUTHORS files.
+type nameConflict struct {
+	Emails []string     `json:"emails"`
+	Names  []listedName `json:"names"`
+}
+
+// A listedName is the name an AUTHORS file lists a person under.
+type listedName struct {
+	File string `json:"file"`
+	Name string `json:"name"`
+}
+
+// nameConflicts returns the people listed under different names in the
+// AUTHORS files, where entries sharing an email, compared case
+// insensitively, are the same person.
+func nameConflicts(files []string) []nameConflict {
+	type entry struct {
+		file string
+		a    author
+	}
+	var entries []entry
+	for _, file := range files {
+		for _, a := range getAuthors(file) {
+			entries = append(entries, entry{file, a})
+		}
+	}
+
+	// Union the entries sharing an email
+	parent := make([]int, len(entries))
+	for i := range parent {
+		parent[i] = i
+	}
+	var root func(i int) int
+	root = func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+	byEmail := make(map[string]int)
+	for i, e := range entries {
+		for _, email := range e.a.emails {
+			key := strings.ToLower(email)
+			if j, ok := byEmail[key]; ok {
+				parent[root(i)] = root(j)
+				continue
+			}
+			byEmail[key] = i
+		}
+	}
+
+	groups := make(map[int][]int)
+	var roots []int
+	for i := range entries {
+		r := root(i)
+		if _, ok := groups[r]; !ok {
+			roots = append(roots, r)
+		}
+		groups[r] = append(groups[r], i)
+	}
+
+	var res []nameConflict
+	for _, r := range roots {
+		var c nameConflict
+		names := make(stringSet)
+		emails := make(stringSet)
+		for _, i := range groups[r] {
+			e := entries[i]
+			for _, email := range e.a.emails {
+				if key := strings.ToLower(email); !emails.has(key) {
+					emails.add(key)
+					c.Emails = append(c.Emails, email)
+				}
+			}
+			c.Names = append(c.Names, listedName{e.file, e.a.name})
+			names.add(normalizeName(e.a.name))
+		}
+		if len(names) > 1 {
+			sort.Strings(c.Emails)
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+func writeConflictsText(w io.Writer, conflicts []nameConflict) error {
+	bw := bufio.NewWriter(w)
+	for _, c := range conflicts {
+		fmt.Fprintf(bw, "<%s>:\n", strings.Join(c.Emails, "> <"))
+		for _, n := range c.Names {
+			fmt.Fprintf(bw, "  %s: %s\n", n.File, n.Name)
+		}
+	}
+	return bw.Flush()
+}
+
+func writeConflictsJSON(w io.Writer, conflicts []nameConflict) error {
+	if conflicts == nil {
+		conflicts = []nameConflict{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(conflicts)
+}
@@ -0,0 +1,61 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestNameConflicts(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	// Alice is linked across the files by an email only in the second one,
+	// Bob differs only by case and spacing
+	a := writeTestFile(t, dir, "a", "Alice A <alice@example.com>\nBob B <bob@example.com>\nCarol C <carol@example.com>\n")
+	b := writeTestFile(t, dir, "b", "Alice Ann <alice@example.net> <ALICE@example.com>\nbob  b <bob@example.com>\n")
+	c := writeTestFile(t, dir, "c", "A. Alice <alice@example.net>\nCarol C <carol@example.com>\n")
+
+	conflicts := nameConflicts([]string{a, b, c})
+	expected := []nameConflict{{
+		Emails: []string{"alice@example.com", "alice@example.net"},
+		Names:  []listedName{{a, "Alice A"}, {b, "Alice Ann"}, {c, "A. Alice"}},
+	}}
+	if !reflect.DeepEqual(conflicts, expected) {
+		t.Errorf("conflicts\n%+v\nexpected\n%+v", conflicts, expected)
+	}
+}
+
+func TestNameConflictsCommand(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	a := writeTestFile(t, dir, "a", "Alice A <alice@example.com>\n")
+	b := writeTestFile(t, dir, "b", "Alice Ann <alice@example.com>\n")
+
+	stdout, _, code := runMain(t, dir, "name-conflicts", a, b)
+	if code != 1 {
+		t.Errorf("exit code %d for a conflict", code)
+	}
+	expected := "<alice@example.com>:\n  " + a + ": Alice A\n  " + b + ": Alice Ann\n"
+	if stdout != expected {
+		t.Errorf("output\n%s\nexpected\n%s", stdout, expected)
+	}
+
+	stdout, _, _ = runMain(t, dir, "-check-json", "name-conflicts", a, b)
+	var res []nameConflict
+	if err := json.Unmarshal([]byte(stdout), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 || len(res[0].Names) != 2 {
+		t.Errorf("unexpected JSON\n%s", stdout)
+	}
+
+	if out := mustRunMain(t, dir, "-check-json", "name-conflicts", a, a); out != "[]\n" {
+		t.Errorf("unexpected output without conflicts\n%s", out)
+	}
+}
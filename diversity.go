@@ -0,0 +1,75 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// diversity describes how concentrated the commits are among the
+// contributors.
+type diversity struct {
+	contributors int
+	commits      int
+	gini         float64 // 0 when all contribute equally, towards 1 when one does everything
+	top10Share   float64 // share of commits by the most active tenth of contributors
+	top10Count   int     // the number of contributors in that tenth
+	half         int     // the fewest contributors accounting for half the commits
+}
+
+func getDiversity(authors []author) diversity {
+	counts := make([]int, len(authors))
+	d := diversity{contributors: len(authors)}
+	for i, a := range authors {
+		counts[i] = a.commits
+		d.commits += a.commits
+	}
+	if d.contributors == 0 || d.commits == 0 {
+		return d
+	}
+
+	sort.Ints(counts)
+	n := float64(len(counts))
+	weighted := 0.0
+	for i, c := range counts {
+		weighted += float64(i+1) * float64(c)
+	}
+	d.gini = 2*weighted/(n*float64(d.commits)) - (n+1)/n
+
+	d.top10Count = int(math.Ceil(n / 10))
+	top := 0
+	for _, c := range counts[len(counts)-d.top10Count:] {
+		top += c
+	}
+	d.top10Share = float64(top) / float64(d.commits)
+
+	sum := 0
+	for i := len(counts) - 1; i >= 0; i-- {
+		sum += counts[i]
+		d.half++
+		if 2*sum >= d.commits {
+			break
+		}
+	}
+	return d
+}
+
+// writeDiversity writes the contributor concentration report.
+func writeDiversity(w io.Writer, authors []author) error {
+	d := getDiversity(authors)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "Contributors:              %d\n", d.contributors)
+	fmt.Fprintf(bw, "Commits:                   %d\n", d.commits)
+	fmt.Fprintf(bw, "Gini coefficient:          %.2f\n", d.gini)
+	fmt.Fprintf(bw, "Top 10%% share:             %.0f%% (%d of %d contributors)\n", 100*d.top10Share, d.top10Count, d.contributors)
+	fmt.Fprintf(bw, "Contributors for half:     %d\n", d.half)
+	return bw.Flush()
+}
@@ -0,0 +1,56 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGetDiversity(t *testing.T) {
+	withCommits := func(counts ...int) []author {
+		authors := make([]author, len(counts))
+		for i, c := range counts {
+			authors[i].commits = c
+		}
+		return authors
+	}
+	cases := []struct {
+		authors  []author
+		expected diversity
+	}{
+		{nil, diversity{}},
+		{withCommits(0, 0), diversity{contributors: 2}},
+		{withCommits(5, 5, 5, 5), diversity{contributors: 4, commits: 20, gini: 0, top10Share: 0.25, top10Count: 1, half: 2}},
+		{withCommits(0, 10, 0, 0), diversity{contributors: 4, commits: 10, gini: 0.75, top10Share: 1, top10Count: 1, half: 1}},
+		{withCommits(1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 10), diversity{contributors: 11, commits: 20, gini: 9.0 / 22, top10Share: 11.0 / 20, top10Count: 2, half: 1}},
+	}
+	for _, c := range cases {
+		d := getDiversity(c.authors)
+		if math.Abs(d.gini-c.expected.gini) < 1e-9 {
+			d.gini = c.expected.gini
+		}
+		if d != c.expected {
+			t.Errorf("getDiversity(%d authors) = %+v, expected %+v", len(c.authors), d, c.expected)
+		}
+	}
+}
+
+func TestDiversity(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+
+	out := mustRunMain(t, dir, "-diversity")
+	expected := "Contributors:              3\n" +
+		"Commits:                   4\n" +
+		"Gini coefficient:          0.17\n" +
+		"Top 10% share:             50% (1 of 3 contributors)\n" +
+		"Contributors for half:     1\n"
+	if out != expected {
+		t.Errorf("output\n%s\nexpected\n%s", out, expected)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// minGitVersion is the oldest git we are known to work with.
+var minGitVersion = [2]int{2, 20}
+
+var gitVersionRe = regexp.MustCompile(`(\d+)\.(\d+)`)
+
+// Diagnosis levels, in increasing severity.
+const (
+	diagOK      = "ok"
+	diagInfo    = "info"
+	diagWarning = "warning"
+	diagError   = "error"
+)
+
+// A diagnosis is the outcome of one of the doctor's checks.
+type diagnosis struct {
+	level   string
+	subject string
+	message string
+}
+
+// doctor checks the prerequisites and configuration for a run with the
+// options, returning the diagnoses.
+func doctor(opts *options) []diagnosis {
+	var res []diagnosis
+	report := func(level, subject, format string, args ...interface{}) {
+		res = append(res, diagnosis{level, subject, fmt.Sprintf(format, args...)})
+	}
+
+	// git itself
+	bs, err := exec.Command("git", "version").Output()
+	if err != nil {
+		report(diagError, "git", "not runnable (%v); install git and make sure it is in $PATH", err)
+		return res
+	}
+	version := strings.TrimSpace(string(bs))
+	if m := gitVersionRe.FindStringSubmatch(version); m != nil {
+		major, _ := strconv.Atoi(m[1])
+		minor, _ := strconv.Atoi(m[2])
+		if major < minGitVersion[0] || major == minGitVersion[0] && minor < minGitVersion[1] {
+			report(diagWarning, "git", "%s is older than %d.%d; some features may fail", version, minGitVersion[0], minGitVersion[1])
+		} else {
+			report(diagOK, "git", "%s", version)
+		}
+	} else {
+		report(diagWarning, "git", "unrecognized version %q", version)
+	}
+
+	// The repository
+	r, repoErr := currentRepository()
+	if repoErr != nil {
+		report(diagError, "repository", "%v", repoErr)
+	} else {
+		if r.bare() {
+			report(diagOK, "repository", "%s (bare; use -read-authors-ref)", r.gitDir)
+		} else {
+			report(diagOK, "repository", "%s", r.workTree)
+		}
+		if err := exec.Command("git", "rev-parse", "--verify", "--quiet", "HEAD").Run(); err != nil {
+			report(diagError, "repository", "HEAD does not point to a commit; there is no history to analyze")
+		}
+		if !r.bare() {
+			if _, err := os.Stat(r.path(".mailmap")); err == nil {
+				report(diagWarning, ".mailmap", "present but not applied; move its mappings to the AUTHORS file or -rewrite-emails")
+			}
+		}
+	}
+
+	// The AUTHORS files
+	if len(opts.authorsFiles) == 0 && opts.authorsRef == "" {
+		report(diagInfo, "authors", "no -read-authors given; all authors will come from the history")
+	}
+	for _, file := range opts.authorsFiles {
+		bs, err := ioutil.ReadFile(file)
+		if err != nil {
+			report(diagError, file, "%v", err)
+			continue
+		}
+		var authors []author
+		if isYAMLFile(file) {
+			authors, err = parseYAMLAuthors(bs)
+			if err != nil {
+				report(diagError, file, "%v", err)
+				continue
+			}
+		} else {
+			authors = parseAuthors(bs)
+		}
+		if findings := lintAuthors(file); len(findings) > 0 {
+			report(diagWarning, file, "%d authors, %d problems; run the lint command for details", len(authors), len(findings))
+		} else {
+			report(diagOK, file, "%d authors", len(authors))
+		}
+	}
+	if opts.authorsRef != "" && repoErr == nil {
+		if _, err := readBlob(opts.authorsRef); err != nil {
+			report(diagError, opts.authorsRef, "%v", err)
+		} else {
+			report(diagOK, opts.authorsRef, "readable")
+		}
+	}
+
+	// Input files named by flags
+	for _, f := range []struct{ flag, file string }{
+		{"exclude-commits", opts.excludeHashes},
+		{"imports", opts.importsFile},
+		{"rewrite-emails", opts.rewriteFile},
+		{"modules", opts.modulesFile},
+		{"scopes", opts.scopesFile},
+		{"sso-map", opts.ssoFile},
+		{"profiles", opts.profilesDir},
+	} {
+		if f.file == "" {
+			continue
+		}
+		if _, err := os.Stat(f.file); err != nil {
+			report(diagError, "-"+f.flag, "%v", err)
+		}
+	}
+
+	// Helper programs
+	for _, t := range []struct {
+		needed bool
+		prog   string
+		flag   string
+	}{
+		{opts.sqliteFile != "", "sqlite3", "sqlite"},
+		{opts.ldap != "", "ldapsearch", "ldap"},
+	} {
+		if !t.needed {
+			continue
+		}
+		if path, err := exec.LookPath(t.prog); err != nil {
+			report(diagError, t.prog, "not found in $PATH; needed for -%s", t.flag)
+		} else {
+			report(diagOK, t.prog, "%s", path)
+		}
+	}
+
+	// The GitHub API token
+	if flags := opts.networkFlags(); opts.offline && len(flags) > 0 {
+		report(diagError, "-offline", "%s not possible without network access", strings.Join(flags, ", "))
+	}
+	if token := os.Getenv("GITHUB_TOKEN"); token == "" {
+		report(diagInfo, "GITHUB_TOKEN", "not set; GitHub API calls are unauthenticated and limited to 60 an hour")
+	} else if opts.offline {
+		report(diagInfo, "GITHUB_TOKEN", "not checked with -offline")
+	} else {
+		level, msg := checkGitHubToken(token)
+		report(level, "GITHUB_TOKEN", "%s", msg)
+	}
+
+	return res
+}
+
+// checkGitHubToken verifies the token against the GitHub API.
+func checkGitHubToken(token string) (string, string) {
+	req, err := http.NewRequest("GET", "https://api.github.com/rate_limit", nil)
+	if err != nil {
+		return diagError, err.Error()
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return diagWarning, fmt.Sprintf("could not reach the GitHub API: %v", err)
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return diagOK, fmt.Sprintf("valid, %s of %s API calls left", resp.Header.Get("X-RateLimit-Remaining"), resp.Header.Get("X-RateLimit-Limit"))
+	case http.StatusUnauthorized:
+		return diagError, "rejected by GitHub; it may have expired or been revoked"
+	default:
+		return diagWarning, fmt.Sprintf("unexpected response from GitHub: %s", resp.Status)
+	}
+}
+
+// writeDiagnoses writes the diagnoses, one per line.
+func writeDiagnoses(w io.Writer, diags []diagnosis) error {
+	bw := bufio.NewWriter(w)
+	for _, d := range diags {
+		fmt.Fprintf(bw, "%-8s %s: %s\n", d.level, d.subject, d.message)
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestDoctor(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	authors := writeTestFile(t, dir, "AUTHORS", "Alice A <alice@example.com>\nBob B <bob@example.com>\n")
+	writeTestFile(t, dir, ".mailmap", "Alice A <alice@example.com> <alice@laptop.local>\n")
+
+	stdout, _, code := runMain(t, dir, "-read-authors", authors, "doctor")
+	if code != 0 {
+		t.Errorf("exit code %d\n%s", code, stdout)
+	}
+	for _, line := range []string{
+		"ok       " + authors + ": 2 authors",
+		"warning  .mailmap: present but not applied; move its mappings to the AUTHORS file or -rewrite-emails",
+		"info     GITHUB_TOKEN: not set; GitHub API calls are unauthenticated and limited to 60 an hour",
+	} {
+		if !containsLine(stdout, line) {
+			t.Errorf("no %q in\n%s", line, stdout)
+		}
+	}
+	if !strings.HasPrefix(stdout, "ok       git: git version ") {
+		t.Errorf("no git version in\n%s", stdout)
+	}
+
+	stdout, _, code = runMain(t, dir, "-read-authors", authors, "-exclude-commits", "missing", "doctor")
+	if code != 1 || !strings.Contains(stdout, "error    -exclude-commits: ") {
+		t.Errorf("exit code %d for a missing file\n%s", code, stdout)
+	}
+
+	dir, cleanup2 := tempDir(t)
+	defer cleanup2()
+	runGit(t, dir, "init", "-q")
+	stdout, _, code = runMain(t, dir, "doctor")
+	if code != 1 || !containsLine(stdout, "error    repository: HEAD does not point to a commit; there is no history to analyze") {
+		t.Errorf("exit code %d for an empty repository\n%s", code, stdout)
+	}
+}
+
+func TestCheckGitHubToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer good":
+			w.Header().Set("X-RateLimit-Remaining", "4999")
+			w.Header().Set("X-RateLimit-Limit", "5000")
+		case "Bearer revoked":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusTeapot)
+		}
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(c *http.Client) { httpClient = c }(httpClient)
+	httpClient = &http.Client{Transport: redirectTransport{u}}
+
+	cases := []struct {
+		token, level, message string
+	}{
+		{"good", diagOK, "valid, 4999 of 5000 API calls left"},
+		{"revoked", diagError, "rejected by GitHub; it may have expired or been revoked"},
+		{"odd", diagWarning, "unexpected response from GitHub: 418 I'm a teapot"},
+	}
+	for _, c := range cases {
+		if level, msg := checkGitHubToken(c.token); level != c.level || msg != c.message {
+			t.Errorf("%s: %s %q, expected %s %q", c.token, level, msg, c.level, c.message)
+		}
+	}
+}
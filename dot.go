@@ -0,0 +1,77 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// dotEscaper escapes text for a quoted Graphviz ID.
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func dotQuote(s string) string {
+	return `"` + dotEscaper.Replace(s) + `"`
+}
+
+// writeDot writes the identity graph in Graphviz format: each author with
+// their emails, labeled by the rule that gave the email to the author, and
+// the names each email was used with in the history. A name shared by
+// emails shows why they were merged by name.
+func writeDot(w io.Writer, a *analysis, authors []author) error {
+	names := make(map[string]stringSet)
+	for _, c := range a.commits {
+		if names[c.email] == nil {
+			names[c.email] = make(stringSet)
+		}
+		names[c.email].add(c.name)
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "graph identities {\n")
+	fmt.Fprintf(bw, "\trankdir=LR;\n")
+	fmt.Fprintf(bw, "\tnode [shape=box];\n")
+	usedNames := make(stringSet)
+	for i, au := range authors {
+		id := dotQuote("author:" + au.id())
+		if au.id() == "" {
+			// Listed without an email; each is still a node of its own
+			id = dotQuote(fmt.Sprintf("author#%d", i))
+		}
+		fmt.Fprintf(bw, "\t%s [label=%s, style=bold];\n", id, dotQuote(au.displayName()))
+		for _, e := range au.emails {
+			how := au.provenance[e]
+			if how == "" {
+				how = provenanceListed
+			}
+			fmt.Fprintf(bw, "\t%s -- %s [label=%s];\n", id, dotQuote("email:"+e), dotQuote(how))
+			fmt.Fprintf(bw, "\t%s [label=%s, shape=ellipse];\n", dotQuote("email:"+e), dotQuote(e))
+			var used []string
+			for name := range names[e] {
+				used = append(used, name)
+			}
+			sort.Strings(used)
+			for _, name := range used {
+				fmt.Fprintf(bw, "\t%s -- %s [style=dashed];\n", dotQuote("email:"+e), dotQuote("name:"+name))
+				usedNames.add(name)
+			}
+		}
+	}
+	var all []string
+	for name := range usedNames {
+		all = append(all, name)
+	}
+	sort.Strings(all)
+	for _, name := range all {
+		fmt.Fprintf(bw, "\t%s [label=%s, shape=plaintext];\n", dotQuote("name:"+name), dotQuote(name))
+	}
+	fmt.Fprintf(bw, "}\n")
+	return bw.Flush()
+}
@@ -0,0 +1,43 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDot(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Alice A <alice@example.com>", message: "One"},
+		testCommit{author: "Alice A <alice@work.example.com>", message: "Two"},
+		testCommit{author: "Bob B <bob@example.com>", message: "Three"},
+	)
+	defer cleanup()
+	authors := writeTestFile(t, dir, "AUTHORS", "Alice A <alice@example.com>\nBob B <bob@example.com>\nNo Email\nNo Email Either\n")
+
+	out := exportImport(t, dir, "-dot", "-read-authors", authors, "-min", "0")
+	if !strings.HasPrefix(out, "graph identities {\n") || !strings.HasSuffix(out, "}\n") {
+		t.Errorf("not a graph:\n%s", out)
+	}
+	alice := (author{emails: []string{"alice@example.com"}}).id()
+	for _, line := range []string{
+		`	"author:` + alice + `" -- "email:alice@example.com" [label="listed"];`,
+		`	"author:` + alice + `" -- "email:alice@work.example.com" [label="name"];`,
+		// The name shared by both emails is a single node
+		`	"email:alice@example.com" -- "name:Alice A" [style=dashed];`,
+		`	"email:alice@work.example.com" -- "name:Alice A" [style=dashed];`,
+		`	"name:Alice A" [label="Alice A", shape=plaintext];`,
+		// Authors without emails are nodes of their own
+		`	"author#2" [label="No Email", style=bold];`,
+		`	"author#3" [label="No Email Either", style=bold];`,
+	} {
+		if !containsLine(out, line) {
+			t.Errorf("missing %s in\n%s", line, out)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// cp1252 maps the bytes 0x80 to 0x9f in Windows-1252 to their runes; the
+// rest of the upper half is as in ISO-8859-1. Undefined bytes map to
+// themselves, as control characters.
+var cp1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8d, 'Ž', 0x8f,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9d, 'ž', 'Ÿ',
+}
+
+// toUTF8 returns the bytes as UTF-8. Commits declaring their encoding are
+// transcoded by git, so anything that still isn't valid UTF-8 is taken to
+// be in the most common legacy encoding, Windows-1252, a superset of
+// ISO-8859-1. Git itself assumes ISO-8859-1 for some invalid text, which
+// turns the Windows-1252 punctuation and letters into control
+// characters; those are mapped to what they were meant to be.
+func toUTF8(bs []byte) []byte {
+	if utf8.Valid(bs) {
+		if !bytes.Contains(bs, []byte{0xc2}) {
+			return bs
+		}
+		var res []byte
+		for _, r := range string(bs) {
+			if r >= 0x80 && r < 0xa0 {
+				r = cp1252[r-0x80]
+			}
+			res = append(res, string(r)...)
+		}
+		return res
+	}
+	res := make([]byte, 0, len(bs)+len(bs)/2)
+	for _, b := range bs {
+		switch {
+		case b < 0x80:
+			res = append(res, b)
+		case b < 0xa0:
+			res = append(res, string(cp1252[b-0x80])...)
+		default:
+			res = append(res, string(rune(b))...)
+		}
+	}
+	return res
+}
@@ -0,0 +1,37 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import "testing"
+
+func TestToUTF8(t *testing.T) {
+	cases := map[string]string{
+		"plain ascii":             "plain ascii",
+		"Jos\xc3\xa9 already":     "José already",
+		"Jos\xe9 in latin-1":      "José in latin-1",
+		"\x93quoted\x94 \x80 5":   "“quoted” € 5",
+		"\xc2\x93mangled\xc2\x94": "“mangled”",
+		"undefined \x81":          "undefined \u0081",
+	}
+	for in, expected := range cases {
+		if got := string(toUTF8([]byte(in))); got != expected {
+			t.Errorf("toUTF8(%q) = %q, expected %q", in, got, expected)
+		}
+	}
+}
+
+func TestLegacyEncodedAuthor(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "René R <rene@example.com>", date: "2020-01-01T12:00:00Z", message: "Modern"},
+		testCommit{author: "Ren\xe9 R <rene@example.com>", date: "2020-02-01T12:00:00Z", message: "Legacy"},
+	)
+	defer cleanup()
+
+	if out := mustRunMain(t, dir, "-authors"); out != "René R <rene@example.com>\n" {
+		t.Errorf("unexpected output\n%q", out)
+	}
+}
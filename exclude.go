@@ -0,0 +1,176 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	fullHashRe  = regexp.MustCompile(`^[0-9a-f]{40}$`)
+	dateRangeRe = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})?\.\.(\d{4}-\d{2}-\d{2})?$`)
+)
+
+// excludes are the commits to ignore, by hash or by author date.
+type excludes struct {
+	hashes stringSet
+	ranges []dateRange
+}
+
+// A dateRange is the half open interval [from, until). Either end may be
+// zero, for no limit.
+type dateRange struct {
+	from  time.Time
+	until time.Time
+}
+
+func (r dateRange) contains(t time.Time) bool {
+	return (r.from.IsZero() || !t.Before(r.from)) && (r.until.IsZero() || t.Before(r.until))
+}
+
+// filter returns the commits that aren't excluded.
+func (e excludes) filter(commits []commit) []commit {
+	commits = filterCommits(commits, e.hashes)
+	if len(e.ranges) == 0 {
+		return commits
+	}
+	var res []commit
+	for _, c := range commits {
+		if !e.matches(c) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// matches returns true if the commit is excluded.
+func (e excludes) matches(c commit) bool {
+	if e.hashes.has(c.hash) {
+		return true
+	}
+	for _, r := range e.ranges {
+		if r.contains(c.date) {
+			return true
+		}
+	}
+	return false
+}
+
+// readExcludes returns the commits to ignore according to the exclude
+// file. Each line is either a date range like "2019-03-01..2019-03-05",
+// inclusive and with either end optional, or starts with a hash, possibly
+// abbreviated and followed by the subject as in the output of git log
+// --oneline. Abbreviated hashes are expanded. Entries that aren't commits
+// in this repository are warned about and ignored or, if strict, fatal.
+func readExcludes(file string, strict bool) excludes {
+	res := excludes{hashes: make(stringSet)}
+	var full []string
+	bad := 0
+	for _, line := range readLines(file) {
+		if m := dateRangeRe.FindStringSubmatch(line); m != nil {
+			r, err := parseDateRange(m[1], m[2])
+			if err != nil {
+				warn(warning{Kind: warnBadExclude, File: file, Message: fmt.Sprintf("ignoring %s: %v", line, err)})
+				bad++
+				continue
+			}
+			res.ranges = append(res.ranges, r)
+			continue
+		}
+		hash := strings.ToLower(strings.Fields(line)[0])
+		if fullHashRe.MatchString(hash) {
+			full = append(full, hash)
+			continue
+		}
+		expanded, err := resolveCommit(hash)
+		if err != nil {
+			warn(warning{Kind: warnBadExclude, File: file, Message: fmt.Sprintf("ignoring %s: %v", hash, err)})
+			bad++
+			continue
+		}
+		res.hashes.add(expanded)
+	}
+
+	types := objectTypes(full)
+	for i, hash := range full {
+		if types[i] != "commit" {
+			warn(warning{Kind: warnBadExclude, File: file, Message: fmt.Sprintf("ignoring %s: not a commit in this repository", hash)})
+			bad++
+			continue
+		}
+		res.hashes.add(hash)
+	}
+
+	if strict && bad > 0 {
+		fatalf("strict: %d invalid entries in %s", bad, file)
+	}
+	return res
+}
+
+// parseDateRange returns the range from the start of the first date to the
+// end of the last one, in UTC.
+func parseDateRange(from, to string) (dateRange, error) {
+	var r dateRange
+	var err error
+	if from != "" {
+		if r.from, err = time.Parse("2006-01-02", from); err != nil {
+			return r, err
+		}
+	}
+	if to != "" {
+		if r.until, err = time.Parse("2006-01-02", to); err != nil {
+			return r, err
+		}
+		r.until = r.until.AddDate(0, 0, 1)
+	}
+	return r, nil
+}
+
+// resolveCommit returns the full hash of the commit named by the
+// abbreviated hash.
+func resolveCommit(abbrev string) (string, error) {
+	bs, err := exec.Command("git", "rev-parse", "--verify", "--quiet", abbrev+"^{commit}").Output()
+	if err == nil {
+		return strings.TrimSpace(string(bs)), nil
+	}
+	bs, _ = exec.Command("git", "rev-parse", "--disambiguate="+abbrev).Output()
+	if n := len(strings.Fields(string(bs))); n > 1 {
+		return "", fmt.Errorf("ambiguous, matches %d objects", n)
+	}
+	return "", fmt.Errorf("not a commit")
+}
+
+// objectTypes returns the type of each of the objects, or "missing".
+func objectTypes(hashes []string) []string {
+	if len(hashes) == 0 {
+		return nil
+	}
+	cmd := exec.Command("git", "cat-file", "--batch-check=%(objecttype)")
+	cmd.Stdin = strings.NewReader(strings.Join(hashes, "\n") + "\n")
+	cmd.Stderr = os.Stderr
+	bs, err := cmd.Output()
+	if err != nil {
+		fatal("git:", err)
+	}
+	types := make([]string, len(hashes))
+	for i, line := range bytes.Split(bs, []byte("\n")) {
+		if i >= len(types) {
+			break
+		}
+		// Unknown objects are reported as "<hash> missing"
+		if f := strings.Fields(string(line)); len(f) > 0 {
+			types[i] = f[len(f)-1]
+		}
+	}
+	return types
+}
@@ -0,0 +1,111 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// revParse returns the full hash of the revision in the repository.
+func revParse(t *testing.T, dir, rev string) string {
+	t.Helper()
+	cmd := exec.Command("git", "rev-parse", rev)
+	cmd.Dir = dir
+	bs, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git rev-parse %s: %v", rev, err)
+	}
+	return strings.TrimSpace(string(bs))
+}
+
+func TestExcludeAbbreviated(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	bob, carol := revParse(t, dir, "HEAD~3"), revParse(t, dir, "HEAD")
+	excludes := writeTestFile(t, dir, "excludes", "# Not counted\n"+bob[:12]+" Add bob\n"+strings.ToUpper(carol)+"\n")
+
+	out := mustRunMain(t, dir, "-exclude-commits", excludes, "-stats")
+	if out != "    2  1 Alice A\n" {
+		t.Errorf("unexpected output\n%s", out)
+	}
+}
+
+func TestExcludeInvalid(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	tree := revParse(t, dir, "HEAD^{tree}")
+	excludes := writeTestFile(t, dir, "excludes", "deadbeef Not here\n"+tree+"\n")
+
+	stdout, stderr, code := runMain(t, dir, "-exclude-commits", excludes, "-stats")
+	if code != 0 {
+		t.Fatalf("exit code %d\n%s", code, stderr)
+	}
+	for _, msg := range []string{"ignoring deadbeef: not a commit", "ignoring " + tree + ": not a commit in this repository"} {
+		if !strings.Contains(stderr, msg) {
+			t.Errorf("no warning %q in\n%s", msg, stderr)
+		}
+	}
+	if !containsLine(stdout, "    1  0 Bob B") {
+		t.Errorf("unexpected output\n%s", stdout)
+	}
+
+	if _, stderr, code := runMain(t, dir, "-exclude-commits", excludes, "-strict", "-stats"); code == 0 || !strings.Contains(stderr, "strict: 2 invalid entries in") {
+		t.Errorf("exit code %d with -strict\n%s", code, stderr)
+	}
+}
+
+func TestParseDateRange(t *testing.T) {
+	r, err := parseDateRange("2020-02-01", "2020-03-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := map[string]bool{
+		"2020-01-31T23:59:59Z": false,
+		"2020-02-01T00:00:00Z": true,
+		"2020-03-01T23:59:59Z": true,
+		"2020-03-02T00:00:00Z": false,
+	}
+	for s, expected := range cases {
+		tm, _ := time.Parse(time.RFC3339, s)
+		if got := r.contains(tm); got != expected {
+			t.Errorf("contains(%s) = %v, expected %v", s, got, expected)
+		}
+	}
+	if open, _ := parseDateRange("", ""); !open.contains(time.Time{}) || !open.contains(time.Now()) {
+		t.Error("an open range doesn't contain everything")
+	}
+	if _, err := parseDateRange("2020-13-01", ""); err == nil {
+		t.Error("no error for an invalid date")
+	}
+}
+
+func TestExcludeDateRange(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	excludes := writeTestFile(t, dir, "excludes", "..2020-01-01\n2020-03-01..2020-04-01\n")
+
+	out := mustRunMain(t, dir, "-exclude-commits", excludes, "-stats")
+	if out != "    1  0 Alice A\n" {
+		t.Errorf("unexpected output\n%s", out)
+	}
+}
+
+func TestExcludeReverts(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	commitFiles(t, dir, testCommit{author: "Dave D <dave@example.com>", date: "2020-05-01T12:00:00Z", message: "Revert \"Add carol\"\n\nThis reverts commit " + revParse(t, dir, "HEAD") + "."})
+
+	if out := mustRunMain(t, dir, "-stats"); !containsLine(out, "    1  0 Dave D") {
+		t.Errorf("unexpected output\n%s", out)
+	}
+	if out := mustRunMain(t, dir, "-exclude-reverts", "-stats"); out != "    2  1 Alice A\n    1  0 Bob B\n" {
+		t.Errorf("unexpected output with -exclude-reverts\n%s", out)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A droppedAuthor is an author left out of the lists, and why.
+type droppedAuthor struct {
+	author author
+	reason string
+}
+
+// Reasons for dropping an author, as counted in the run summary.
+const (
+	dropExcluded = iota + 1
+	dropBelowMin
+)
+
+// dropReason returns why the author is left out of the lists, as one of
+// the drop constants and an explanation, or zero if they are kept.
+func dropReason(a author, opts *options) (int, string) {
+	if strings.Contains(a.name, opts.excludePattern) {
+		return dropExcluded, fmt.Sprintf("name contains the -exclude-pattern %q", opts.excludePattern)
+	}
+	if a.commits+a.tagged < opts.minContributions {
+		unit := "commits"
+		if a.commits == 1 {
+			unit = "commit"
+		}
+		if a.tagged > 0 {
+			return dropBelowMin, fmt.Sprintf("%d %s and %d tags, fewer than -min %d", a.commits, unit, a.tagged, opts.minContributions)
+		}
+		return dropBelowMin, fmt.Sprintf("%d %s, fewer than -min %d", a.commits, unit, opts.minContributions)
+	}
+	return 0, ""
+}
+
+// writeDropped writes the authors left out of the lists, with the rule
+// that removed each.
+func writeDropped(w io.Writer, dropped []droppedAuthor) error {
+	bw := bufio.NewWriter(w)
+	for _, d := range dropped {
+		fmt.Fprintf(bw, "%s", d.author.displayName())
+		if len(d.author.emails) > 0 {
+			fmt.Fprintf(bw, " <%s>", d.author.emails[0])
+		}
+		fmt.Fprintf(bw, ": %s\n", d.reason)
+	}
+	return bw.Flush()
+}
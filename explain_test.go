@@ -0,0 +1,20 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import "testing"
+
+func TestExplainFilter(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+
+	out := exportImport(t, dir, "-explain-filter", "-min", "2", "-exclude-pattern", "Carol")
+	expected := "Bob B <bob@example.com>: 1 commit, fewer than -min 2\nCarol C <carol@example.com>: name contains the -exclude-pattern \"Carol\"\n"
+	if out != expected {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+}
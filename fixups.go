@@ -0,0 +1,75 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import "strings"
+
+// fixupPrefixes are the subject prefixes of commits meant to be folded into
+// an earlier one by git rebase --autosquash.
+var fixupPrefixes = []string{"fixup! ", "squash! ", "amend! "}
+
+// commitSubject returns the first line of the commit message.
+func commitSubject(message string) string {
+	if idx := strings.IndexByte(message, '\n'); idx >= 0 {
+		message = message[:idx]
+	}
+	return strings.TrimSpace(message)
+}
+
+// fixupTarget returns the subject, or hash, of the commit a fixup or squash
+// commit targets, and whether the subject is that of one at all.
+func fixupTarget(subject string) (string, bool) {
+	found := false
+	for {
+		trimmed := subject
+		for _, p := range fixupPrefixes {
+			trimmed = strings.TrimPrefix(trimmed, p)
+		}
+		if trimmed == subject {
+			return subject, found
+		}
+		subject, found = trimmed, true
+	}
+}
+
+// foldFixups returns the commits with fixup and squash commits attributed
+// to the author of the commit they target, i.e. the most recent earlier
+// commit with the target subject or hash. Fixups without a target are left
+// as they are.
+func foldFixups(commits []commit) []commit {
+	res := make([]commit, len(commits))
+	copy(res, commits)
+
+	// Commits are newest first, so we go from the end to see each target
+	// before its fixups.
+	bySubject := make(map[string]int)
+	var targets []int
+	for i := len(res) - 1; i >= 0; i-- {
+		subject := commitSubject(res[i].message)
+		target, ok := fixupTarget(subject)
+		if !ok {
+			bySubject[subject] = i
+			targets = append(targets, i)
+			continue
+		}
+
+		t, ok := bySubject[target]
+		if !ok && len(target) >= 7 {
+			for _, j := range targets {
+				if strings.HasPrefix(res[j].hash, target) {
+					t, ok = j, true
+					break
+				}
+			}
+		}
+		if ok {
+			res[i].email = res[t].email
+			res[i].name = res[t].name
+		}
+	}
+	return res
+}
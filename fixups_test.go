@@ -0,0 +1,61 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import "testing"
+
+func TestFixupTarget(t *testing.T) {
+	cases := []struct {
+		subject string
+		target  string
+		ok      bool
+	}{
+		{"Add feature", "Add feature", false},
+		{"fixup! Add feature", "Add feature", true},
+		{"squash! fixup! Add feature", "Add feature", true},
+		{"amend! 1234abcd", "1234abcd", true},
+		{"Fixup! Add feature", "Fixup! Add feature", false},
+	}
+	for _, c := range cases {
+		if target, ok := fixupTarget(c.subject); target != c.target || ok != c.ok {
+			t.Errorf("fixupTarget(%q) = %q, %v, expected %q, %v", c.subject, target, ok, c.target, c.ok)
+		}
+	}
+}
+
+func TestFoldFixups(t *testing.T) {
+	// Newest first, as from git log
+	commits := []commit{
+		{hash: "eeee", email: "carol@example.com", message: "fixup! Lost target"},
+		{hash: "dddd", email: "carol@example.com", message: "squash! bbbbbbb1"},
+		{hash: "cccc", email: "bob@example.com", message: "fixup! Add feature\n\nTypo"},
+		{hash: "bbbbbbb1", email: "bob@example.com", message: "Other change"},
+		{hash: "aaaa", email: "alice@example.com", message: "Add feature"},
+	}
+	res := foldFixups(commits)
+	expected := []string{"carol@example.com", "bob@example.com", "alice@example.com", "bob@example.com", "alice@example.com"}
+	for i, c := range res {
+		if c.email != expected[i] {
+			t.Errorf("%s attributed to %s, expected %s", c.hash, c.email, expected[i])
+		}
+	}
+	if commits[2].email != "bob@example.com" {
+		t.Error("the commits were modified in place")
+	}
+}
+
+func TestFoldFixupsCommand(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Alice A <alice@example.com>", date: "2020-01-01T12:00:00Z", message: "Add feature"},
+		testCommit{author: "Bob B <bob@example.com>", date: "2020-02-01T12:00:00Z", message: "fixup! Add feature"},
+	)
+	defer cleanup()
+
+	if out := mustRunMain(t, dir, "-fold-fixups", "-stats"); out != "    2  1 Alice A\n" {
+		t.Errorf("unexpected output\n%s", out)
+	}
+}
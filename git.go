@@ -0,0 +1,390 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var revertRe = regexp.MustCompile(`This reverts commit ([0-9a-f]{7,40})`)
+
+// A commit is the subset of the commit metadata that we care about.
+type commit struct {
+	hash    string
+	email   string
+	name    string
+	date    time.Time // author date
+	parents []string
+	message string
+}
+
+// readCommits returns the commits in the git log for the given revisions
+// (HEAD, if none), newest first. The log is read as a stream and the
+// commits are parsed by the given number of goroutines in parallel. Git
+// transcodes commits with an encoding header to UTF-8 for us.
+func readCommits(revs []string, parallel int) []commit {
+	args := []string{"log", "-z", "--encoding=UTF-8", "--format=%H%x1f%ae%x1f%an%x1f%at%x1f%P%x1f%B"}
+	args = append(args, revs...)
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		fatal("git:", err)
+	}
+
+	// Stage one reads NUL separated records from git, tagged with their
+	// sequence number so that we can restore the order afterwards.
+	type record struct {
+		seq  int
+		data []byte
+	}
+	records := make(chan record, 4*parallel)
+	var scanErr error
+	go func() {
+		defer close(records)
+		sc := bufio.NewScanner(out)
+		sc.Buffer(make([]byte, 64<<10), maxRecordSize)
+		sc.Split(splitNUL)
+		for seq := 0; sc.Scan(); seq++ {
+			data := make([]byte, len(sc.Bytes()))
+			copy(data, sc.Bytes())
+			records <- record{seq, data}
+		}
+		scanErr = sc.Err()
+	}()
+
+	// Stage two parses the records into commits.
+	type parsed struct {
+		seq int
+		c   commit
+		ok  bool
+	}
+	results := make(chan parsed, 4*parallel)
+	strs := newInterner()
+	var wg sync.WaitGroup
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rec := range records {
+				c, ok := parseCommit(rec.data, strs)
+				results <- parsed{rec.seq, c, ok}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Collect the commits in their original order.
+	var all []parsed
+	for res := range results {
+		for len(all) <= res.seq {
+			all = append(all, parsed{})
+		}
+		all[res.seq] = res
+	}
+	if scanErr != nil {
+		fatal("git:", scanErr)
+	}
+	if err := cmd.Wait(); err != nil {
+		fatal("git:", err)
+	}
+
+	commits := make([]commit, 0, len(all))
+	for _, res := range all {
+		if res.ok {
+			commits = append(commits, res.c)
+		}
+	}
+	return commits
+}
+
+// maxRecordSize is the largest commit record, mostly the commit message,
+// that we accept from git.
+const maxRecordSize = 64 << 20
+
+// splitNUL is a bufio.SplitFunc for NUL terminated records.
+func splitNUL(data []byte, atEOF bool) (int, []byte, error) {
+	if idx := bytes.IndexByte(data, 0); idx >= 0 {
+		return idx + 1, data[:idx], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// parseCommit parses a record in the format requested by readCommits. The
+// returned commit does not reference the record memory.
+func parseCommit(rec []byte, strs *interner) (commit, bool) {
+	fields := bytes.SplitN(rec, []byte{0x1f}, 6)
+	if len(fields) != 6 {
+		return commit{}, false
+	}
+	secs, err := strconv.ParseInt(string(fields[3]), 10, 64)
+	if err != nil {
+		return commit{}, false
+	}
+	return commit{
+		hash:    string(fields[0]),
+		email:   strs.intern(toUTF8(fields[1])),
+		name:    strs.intern(toUTF8(fields[2])),
+		date:    time.Unix(secs, 0).UTC(),
+		parents: strings.Fields(string(fields[4])),
+		message: string(toUTF8(fields[5])),
+	}, true
+}
+
+// An interner returns a single canonical string for each distinct value,
+// so that the emails and names repeated over thousands of commits share
+// memory. It is safe for concurrent use.
+type interner struct {
+	mut  sync.Mutex
+	strs map[string]string
+}
+
+func newInterner() *interner {
+	return &interner{strs: make(map[string]string)}
+}
+
+func (in *interner) intern(bs []byte) string {
+	in.mut.Lock()
+	defer in.mut.Unlock()
+	if s, ok := in.strs[string(bs)]; ok {
+		return s
+	}
+	s := string(bs)
+	in.strs[s] = s
+	return s
+}
+
+// filterCommits returns the commits whose hash is not in the exclude set.
+func filterCommits(commits []commit, exclude stringSet) []commit {
+	if len(exclude) == 0 {
+		return commits
+	}
+	var res []commit
+	for _, c := range commits {
+		if !exclude.has(c.hash) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// patchIDs returns a map from commit hash to the stable patch ID of the
+// change it introduces, for all non-merge commits in the given revisions.
+// Commits that introduce no change are not included.
+func patchIDs(revs []string) map[string]string {
+	args := append([]string{"log", "-p", "--no-merges", "--format=commit %H"}, revs...)
+	logCmd := exec.Command("git", args...)
+	idCmd := exec.Command("git", "patch-id", "--stable")
+
+	pipe, err := logCmd.StdoutPipe()
+	if err != nil {
+		fatal(err)
+	}
+	idCmd.Stdin = pipe
+	buf := new(bytes.Buffer)
+	idCmd.Stdout = buf
+
+	if err := idCmd.Start(); err != nil {
+		fatal("git:", err)
+	}
+	if err := logCmd.Run(); err != nil {
+		fatal("git:", err)
+	}
+	if err := idCmd.Wait(); err != nil {
+		fatal("git:", err)
+	}
+
+	ids := make(map[string]string)
+	for _, line := range strings.Split(buf.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		ids[fields[1]] = fields[0]
+	}
+	return ids
+}
+
+// dedupPatches removes commits introducing the same change as an older
+// commit, as identified by the patch ID. This is typically cherry picks
+// between branches.
+func dedupPatches(commits []commit, ids map[string]string) []commit {
+	seen := make(stringSet)
+	dups := make(stringSet)
+	for i := len(commits) - 1; i >= 0; i-- {
+		id, ok := ids[commits[i].hash]
+		if !ok {
+			continue
+		}
+		if seen.has(id) {
+			dups.add(commits[i].hash)
+			continue
+		}
+		seen.add(id)
+	}
+	return filterCommits(commits, dups)
+}
+
+// revertPairs returns the set of hashes of commits that were reverted,
+// together with the commits that reverted them. A revert that has itself
+// been reverted does not cancel out the original commit.
+func revertPairs(commits []commit) stringSet {
+	hashes := make([]string, len(commits))
+	for i, c := range commits {
+		hashes[i] = c.hash
+	}
+
+	// reverted hash -> hashes of the commits reverting it
+	revertedBy := make(map[string][]string)
+	for _, c := range commits {
+		for _, m := range revertRe.FindAllStringSubmatch(c.message, -1) {
+			target := expandHash(m[1], hashes)
+			revertedBy[target] = append(revertedBy[target], c.hash)
+		}
+	}
+
+	// A commit is cancelled if it was reverted by a commit that is not
+	// itself cancelled.
+	cancelledBy := make(map[string]string)
+	var cancelled func(hash string) bool
+	cancelled = func(hash string) bool {
+		if by, ok := cancelledBy[hash]; ok {
+			return by != ""
+		}
+		cancelledBy[hash] = ""
+		for _, r := range revertedBy[hash] {
+			if !cancelled(r) {
+				cancelledBy[hash] = r
+				break
+			}
+		}
+		return cancelledBy[hash] != ""
+	}
+
+	res := make(stringSet)
+	for _, h := range hashes {
+		if cancelled(h) {
+			res.add(h)
+			res.add(cancelledBy[h])
+		}
+	}
+	return res
+}
+
+// expandHash returns the full hash from the list that starts with the given
+// possibly abbreviated hash, or the hash itself if there is no such entry.
+func expandHash(hash string, hashes []string) string {
+	if len(hash) == 40 {
+		return hash
+	}
+	for _, h := range hashes {
+		if strings.HasPrefix(h, hash) {
+			return h
+		}
+	}
+	return hash
+}
+
+// firstParents returns the set of commits on the first parent chains from
+// the given revisions (HEAD, if none), i.e. the mainline history of each
+// branch analyzed.
+func firstParents(revs []string) stringSet {
+	if !hasRevision(revs) {
+		revs = append(revs[:len(revs):len(revs)], "HEAD")
+	}
+	args := append([]string{"rev-list", "--first-parent"}, revs...)
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = os.Stderr
+	bs, err := cmd.Output()
+	if err != nil {
+		fatal("git:", err)
+	}
+	return stringSetFromStrings(strings.Fields(string(bs)))
+}
+
+// hasRevision returns whether the arguments name any revisions, rather
+// than only options; git log defaults to HEAD when they don't, but git
+// rev-list fails.
+func hasRevision(args []string) bool {
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") || arg == "--all" {
+			return true
+		}
+		for _, opt := range []string{"--branches", "--tags", "--remotes", "--glob="} {
+			if strings.HasPrefix(arg, opt) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addedFiles returns a map from commit hash to the paths of the files
+// added in that commit, for the given revisions.
+func addedFiles(revs []string) map[string][]string {
+	return logPaths(append([]string{"--diff-filter=A"}, revs...))
+}
+
+// logPaths returns a map from commit hash to the paths git log lists for
+// the commit with the given arguments, relative to the top of the
+// repository. The paths are read NUL terminated, as git would otherwise
+// quote those with special characters.
+func logPaths(args []string) map[string][]string {
+	args = append([]string{"log", "-z", "--name-only", "--format=%x01%H"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = os.Stderr
+	bs, err := cmd.Output()
+	if err != nil {
+		fatal("git:", err)
+	}
+
+	// The commit lines and paths are NUL terminated, with a newline
+	// between the commit line and the first path
+	paths := make(map[string][]string)
+	var hash string
+	for _, field := range strings.Split(string(bs), "\x00") {
+		field = strings.TrimPrefix(field, "\n")
+		switch {
+		case strings.HasPrefix(field, "\x01"):
+			hash = field[1:]
+		case field != "" && hash != "":
+			paths[hash] = append(paths[hash], field)
+		}
+	}
+	return paths
+}
+
+// pathCommits returns the set of commits in the given revisions that touch
+// the path, relative to the top of the work tree.
+func pathCommits(revs []string, path string) stringSet {
+	args := append([]string{"log", "--format=%H"}, revs...)
+	args = append(args, "--", ":(top)"+path)
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = os.Stderr
+	bs, err := cmd.Output()
+	if err != nil {
+		fatal("git:", err)
+	}
+	return stringSetFromStrings(strings.Fields(string(bs)))
+}
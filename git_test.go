@@ -0,0 +1,119 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHasRevision(t *testing.T) {
+	cases := []struct {
+		args []string
+		has  bool
+	}{
+		{nil, false},
+		{[]string{"--max-count=10"}, false},
+		{[]string{"--no-merges", "--max-count=10"}, false},
+		{[]string{"HEAD"}, true},
+		{[]string{"--max-count=10", "main"}, true},
+		{[]string{"--branches"}, true},
+		{[]string{"--glob=refs/heads/release-*"}, true},
+		{[]string{"--all"}, true},
+	}
+	for _, c := range cases {
+		if has := hasRevision(c.args); has != c.has {
+			t.Errorf("hasRevision(%q) = %v, expected %v", c.args, has, c.has)
+		}
+	}
+}
+
+func TestRevertPairs(t *testing.T) {
+	hash := func(c byte) string { return strings.Repeat(string(c), 40) }
+	commits := []commit{
+		{hash: hash('e'), message: "Revert \"Revert \"Add c\"\"\n\nThis reverts commit " + hash('d') + "."},
+		{hash: hash('d'), message: "Revert \"Add c\"\n\nThis reverts commit cccccccc."},
+		{hash: hash('c'), message: "Add c"},
+		{hash: hash('b'), message: "Revert \"Add a\"\n\nThis reverts commit aaaaaaa."},
+		{hash: hash('a'), message: "Add a"},
+	}
+
+	// The revert of c was itself reverted, so c stands
+	res := revertPairs(commits)
+	expected := []string{hash('a'), hash('b'), hash('d'), hash('e')}
+	if len(res) != len(expected) {
+		t.Errorf("reverted %v, expected %v", res, expected)
+	}
+	for _, h := range expected {
+		if !res.has(h) {
+			t.Errorf("%s not reverted", h[:7])
+		}
+	}
+}
+
+func TestDedupPatches(t *testing.T) {
+	commits := []commit{{hash: "d"}, {hash: "c"}, {hash: "b"}, {hash: "a"}}
+	ids := map[string]string{"a": "1", "b": "2", "d": "1"}
+
+	// The newer copy goes; c, without a patch ID, stays
+	res := dedupPatches(commits, ids)
+	var hashes []string
+	for _, c := range res {
+		hashes = append(hashes, c.hash)
+	}
+	if strings.Join(hashes, " ") != "c b a" {
+		t.Errorf("commits %q, expected c b a", hashes)
+	}
+}
+
+func TestParseCommit(t *testing.T) {
+	rec := []byte(strings.Repeat("a", 40) + "\x1falice@example.com\x1fAlice A\x1f1577880000\x1f" + strings.Repeat("b", 40) + "\x1fAdd alice\n")
+	strs := newInterner()
+	c, ok := parseCommit(rec, strs)
+	if !ok {
+		t.Fatal("unparseable record")
+	}
+	expected := commit{
+		hash:    strings.Repeat("a", 40),
+		email:   "alice@example.com",
+		name:    "Alice A",
+		date:    time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC),
+		parents: []string{strings.Repeat("b", 40)},
+		message: "Add alice\n",
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("commit %+v, expected %+v", c, expected)
+	}
+
+	// The commit does not reference the record
+	for i := range rec {
+		rec[i] = 'x'
+	}
+	if !reflect.DeepEqual(c, expected) {
+		t.Errorf("commit %+v after reusing the record", c)
+	}
+
+	for _, bad := range []string{"", "a\x1fb\x1fc\x1fd\x1fe", "a\x1fb\x1fc\x1fnot a time\x1fe\x1ff"} {
+		if _, ok := parseCommit([]byte(bad), strs); ok {
+			t.Errorf("parsed %q", bad)
+		}
+	}
+}
+
+func TestInterner(t *testing.T) {
+	strs := newInterner()
+	s := strs.intern([]byte("alice@example.com"))
+	if s != "alice@example.com" {
+		t.Errorf("interned %q", s)
+	}
+	bs := []byte("alice@example.com")
+	if allocs := testing.AllocsPerRun(10, func() { strs.intern(bs) }); allocs != 0 {
+		t.Errorf("%v allocations for an interned string", allocs)
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/calmh/git-contributors
 
-go 1.14
+go 1.18
+
+require gopkg.in/yaml.v2 v2.4.0
@@ -0,0 +1,276 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// helpTopics are the long form descriptions shown by the help command and
+// included in the man page, by topic name.
+var helpTopics = map[string]string{
+	"authors": `The AUTHORS file lists one author per line, as the name followed by an
+optional nickname in parentheses, one or more email addresses in angle
+brackets and optionally home page URLs:
+
+    Jane Doe (jdoe) <jane@example.com> <jdoe@users.noreply.github.com> https://jane.example.com
+
+Lines starting with # are comments. Text after " #" on an entry is a note
+about the author, such as "original author", which is kept when the file
+is rewritten and available to templates as .Note:
+
+    Jane Doe <jane@example.com> # maintainer 2015-2019
+
+The line
+
+    # git-contributors: pin
+
+pins the entry following it to its position, so that it stays there when
+the rest of the list is sorted. Likewise, a line like
+
+    # git-contributors: emails <jane@old.example.com> <jdoe@example.net>
+
+adds the emails to the entry following it. The AUTHORS list is written
+that way with -max-emails, so that the emails beyond the limit are still
+used for matching when the file is read back.
+
+Files named *.yaml or *.yml are read and written as YAML instead: a list
+of entries with the keys name, nicknames, emails, moreEmails, tags, urls
+and pinned.
+Use the convert command to move between the formats.
+
+When -read-authors is given more than once, or as a glob, the files are
+merged: entries sharing an email, or the same name unless
+-no-name-matching, become one author. The first file is the one written
+to by apply.
+
+Contributors can describe themselves in a file of their own in the
+-profiles directory, conventionally contributors.d, instead of editing a
+shared file. Each *.yaml file has the emails identifying the author and
+optionally a preferred name, pronouns, a url and an avatar image URL:
+
+    emails: [jane@example.com]
+    name: Jane Doe
+    pronouns: she/her
+    url: https://jane.example.com
+
+With -ldap url,basedn, such as ldaps://ldap.example.com,dc=example,dc=com,
+authors are looked up in the directory by email using ldapsearch. The
+displayName found replaces the name, and the department is available to
+templates as .Department. Set $LDAP_BIND_DN and $LDAP_BIND_PASSWORD to bind
+as a user instead of anonymously. Profiles still take precedence.
+
+With -read-authors-ref rev:path, such as main:AUTHORS, the file is read as
+committed in that revision instead of from the work tree, as is necessary
+in a bare repository. With -check this compares the history against what
+is committed, ignoring local edits, and the -scopes AUTHORS files are read
+from the same revision.`,
+
+	"matching": `Each commit is attributed to an author by the commit's author email, as
+an exact match against the emails in the AUTHORS file or seen earlier in
+the history.
+
+Before that, the rules in the -rewrite-emails file are applied, the first
+matching one for each email. This handles domain migrations without
+listing every old address:
+
+    *@oldcorp.com -> *@newcorp.com
+    jdoe@oldcorp.com -> jane.doe@newcorp.com
+
+The -sso-map file, a CSV export from the corporate single sign-on, maps
+people to the emails they commit as. The header row names the columns
+identity, name and email:
+
+    identity,name,email
+    E1234,Jane Doe,jane@corp.example.com
+    E1234,Jane Doe,jdoe@laptop.local
+
+Emails of the same identity belong to one author, the listed one if any,
+else a new author under the name from the export.
+
+With -signing-keys, an email that isn't known but signed commits with the
+same GPG or SSH key as a known email belongs to that author.
+
+An email that isn't known otherwise is matched on the author name instead, compared
+case insensitively and with white space collapsed. On a match the email is
+added to that author, with a warning. With -strict this fails instead when
+the domains of the emails differ and neither is a freemail provider, as
+that suggests two different people of the same name. With
+-no-name-matching names are not matched at all.
+
+In repositories that squash merge on GitHub, commits sometimes carry the
+noreply address of whoever merged them. With -github-backfill owner/repo,
+each commit under a noreply address is looked up in the GitHub API and
+given to the author of the pull request it was merged from. The answers
+are cached in the git directory, and $GITHUB_TOKEN is used if set.
+
+Emails matching no author by email or name become new authors, under the
+most recent name used with that email. Identities are considered in the
+order of their first commit, so that the first email seen is an author's
+primary one.
+
+Authors whose name contains the -exclude-pattern, by default "[bot]", or
+with fewer than -min commits are left out of the lists.`,
+
+	"excludes": `The -exclude-commits file lists commits to leave out of all counts, one
+per line. A line is either a commit hash, or the start of one as in the
+output of git log --oneline, possibly followed by the subject:
+
+    4f0ffc518111 Reformat everything
+
+or an author date range, inclusive, where either end may be left out:
+
+    2019-03-01..2019-03-05
+
+Lines starting with # are comments. Entries that aren't commits in this
+repository are warned about and ignored or, with -strict, fatal.
+
+Notes in refs/notes/contributors, or the -notes-ref, like
+
+    credit: Jane Doe <jane@example.com>
+
+attribute the commit to that person instead of its author.
+
+Independently of the file, -exclude-reverts leaves out reverted commits
+together with their reverts, and -fold-fixups counts fixup! and squash!
+commits toward the author of the commit they target. When counting
+several branches with -all-branches or -refs, the same change on more
+than one branch is counted once. With -max-commits only the most recent
+commits are read at all, which keeps -check fast enough for hooks; it
+then doesn't report stale authors, as their commits may just be older.
+
+The -imports file has the same format, listing commits that still count
+but whose changed lines aren't attributed in -lines, such as drops of
+vendored or generated code. With -import-threshold, larger commits are
+treated as imports too; -imports-report lists them all, ready to be
+copied to the file once confirmed.`,
+
+	"commands": `The commands are given after the flags:
+
+    apply <file>                 apply the approved changes in a review file to AUTHORS
+    completion <bash|zsh|fish>   print a shell completion script
+    convert <from> <to>          convert an AUTHORS file between formats
+    doctor                       check the prerequisites and configuration for a run
+    export <file>                save the analysis to a file
+    help [topic]                 show help on a topic
+    import <file>                render the outputs from a saved analysis
+    install-hook [kind]          install a pre-push or post-merge hook running -check
+    lint [file]                  check the AUTHORS file for problems
+    merge-authors <base> <ours> <theirs>
+                                 merge AUTHORS files by entry, as a git merge driver
+    name-conflicts <file>...     list people under different names in several AUTHORS files
+    review <file>                write the proposed changes to AUTHORS for review
+    serve                        serve the outputs and a contributor count badge over HTTP
+    version                      print the version and build information
+
+To use merge-authors as the merge driver for AUTHORS files, add
+
+    [merge "authors"]
+        name = AUTHORS merge
+        driver = git-contributors merge-authors %O %A %B
+
+to the git config, and "AUTHORS merge=authors" to .gitattributes.
+
+When aggregating the AUTHORS files of several repositories, name-conflicts
+lists the people who share an email across the files but are listed under
+different names, so that the names can be made consistent at the source:
+
+    git-contributors name-conflicts */AUTHORS
+
+Without a command the history is analyzed and the outputs selected by the
+flags are printed.`,
+}
+
+func helpTopicNames() []string {
+	names := make([]string, 0, len(helpTopics))
+	for name := range helpTopics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeHelp writes the help text for the topic, or the list of topics if
+// the topic is empty.
+func writeHelp(w io.Writer, topic string) error {
+	if topic == "" {
+		_, err := fmt.Fprintf(w, "Help topics: %s\n", strings.Join(helpTopicNames(), ", "))
+		return err
+	}
+	text, ok := helpTopics[topic]
+	if !ok {
+		return fmt.Errorf("unknown help topic %q (%s)", topic, strings.Join(helpTopicNames(), ", "))
+	}
+	_, err := fmt.Fprintln(w, text)
+	return err
+}
+
+// manEscaper escapes text for roff.
+var manEscaper = strings.NewReplacer(`\`, `\e`, "-", `\-`, "'", `\(aq`)
+
+// writeMan writes a man page in roff format, with the flags and the help
+// topics.
+func writeMan(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, ".TH GIT-CONTRIBUTORS 1\n")
+	fmt.Fprintf(bw, ".SH NAME\ngit\\-contributors \\- list and check the contributors to a git repository\n")
+	fmt.Fprintf(bw, ".SH SYNOPSIS\n.B git\\-contributors\n[\\fIflags\\fR] [\\fIcommand\\fR [\\fIargs\\fR]]\n")
+
+	fmt.Fprintf(bw, ".SH OPTIONS\n")
+	flag.VisitAll(func(f *flag.Flag) {
+		fmt.Fprintf(bw, ".TP\n.B \\-%s", manEscaper.Replace(f.Name))
+		if name, _ := flag.UnquoteUsage(f); name != "" {
+			fmt.Fprintf(bw, " \\fI%s\\fR", manEscaper.Replace(name))
+		}
+		fmt.Fprintf(bw, "\n")
+		_, usage := flag.UnquoteUsage(f)
+		fmt.Fprintf(bw, "%s", manText(usage))
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+			fmt.Fprintf(bw, " (default %s)", manEscaper.Replace(f.DefValue))
+		}
+		fmt.Fprintf(bw, "\n")
+	})
+
+	for _, topic := range []string{"commands", "authors", "matching", "excludes"} {
+		fmt.Fprintf(bw, ".SH %s\n", strings.ToUpper(topic))
+		writeManText(bw, helpTopics[topic])
+	}
+	return bw.Flush()
+}
+
+// writeManText writes the help text as roff paragraphs, with indented
+// lines as literal examples.
+func writeManText(w io.Writer, text string) {
+	for _, para := range strings.Split(text, "\n\n") {
+		if strings.HasPrefix(para, "    ") {
+			fmt.Fprintf(w, ".PP\n.nf\n.RS\n")
+			for _, line := range strings.Split(para, "\n") {
+				fmt.Fprintf(w, "%s\n", manText(strings.TrimPrefix(line, "    ")))
+			}
+			fmt.Fprintf(w, ".RE\n.fi\n")
+			continue
+		}
+		fmt.Fprintf(w, ".PP\n%s\n", manText(para))
+	}
+}
+
+// manText escapes the text, also guarding lines that would otherwise be
+// taken as roff requests.
+func manText(s string) string {
+	lines := strings.Split(manEscaper.Replace(s), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,74 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteHelp(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := writeHelp(buf, ""); err != nil || buf.String() != "Help topics: authors, commands, excludes, matching\n" {
+		t.Errorf("topics %q, %v", buf.String(), err)
+	}
+
+	buf.Reset()
+	if err := writeHelp(buf, "authors"); err != nil || buf.String() != helpTopics["authors"]+"\n" {
+		t.Errorf("unexpected help text %q, %v", buf.String(), err)
+	}
+
+	if err := writeHelp(buf, "nope"); err == nil || !strings.Contains(err.Error(), `unknown help topic "nope"`) {
+		t.Errorf("error %v for an unknown topic", err)
+	}
+}
+
+func TestManText(t *testing.T) {
+	cases := map[string]string{
+		"-read-authors":       `\-read\-authors`,
+		`a\b`:                 `a\eb`,
+		"it's":                `it\(aqs`,
+		"one\n.two\nthree.":   "one\n\\&.two\nthree.",
+		"plain text, really.": "plain text, really.",
+	}
+	for text, expected := range cases {
+		if res := manText(text); res != expected {
+			t.Errorf("manText(%q) = %q, expected %q", text, res, expected)
+		}
+	}
+}
+
+func TestWriteManText(t *testing.T) {
+	buf := new(bytes.Buffer)
+	writeManText(buf, "Some text\nwrapped.\n\n    example -one\n    example two\n\nMore.")
+	expected := ".PP\nSome text\nwrapped.\n" +
+		".PP\n.nf\n.RS\nexample \\-one\nexample two\n.RE\n.fi\n" +
+		".PP\nMore.\n"
+	if buf.String() != expected {
+		t.Errorf("output\n%s\nexpected\n%s", buf.String(), expected)
+	}
+}
+
+func TestMan(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	out := mustRunMain(t, dir, "-man")
+	if !strings.HasPrefix(out, ".TH GIT-CONTRIBUTORS 1\n") {
+		t.Errorf("no title in\n%s", out)
+	}
+	for _, line := range []string{
+		".B \\-active\\-window \\fIstring\\fR",
+		".B \\-all\\-branches",
+		".SH MATCHING",
+		".SH EXCLUDES",
+	} {
+		if !containsLine(out, line) {
+			t.Errorf("no %q in\n%s", line, out)
+		}
+	}
+}
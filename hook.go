@@ -0,0 +1,85 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// hookMarker identifies hooks installed by us, which we may overwrite.
+const hookMarker = "# Installed by git-contributors install-hook"
+
+// installHook installs a git hook of the given kind that runs the check
+// mode with the given flags.
+func installHook(kind string, flags []string, backup bool) error {
+	switch kind {
+	case "pre-push", "post-merge":
+	default:
+		return fmt.Errorf("unsupported hook %q", kind)
+	}
+
+	if _, err := currentRepository(); err != nil {
+		return err
+	}
+
+	// Hooks are shared by linked worktrees, unless core.hooksPath says
+	// otherwise; git knows where.
+	cmd := exec.Command("git", "rev-parse", "--git-path", "hooks")
+	cmd.Stderr = os.Stderr
+	bs, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("git: %w", err)
+	}
+	file := filepath.Join(strings.TrimSpace(string(bs)), kind)
+
+	if cur, err := ioutil.ReadFile(file); err == nil && !bytes.Contains(cur, []byte(hookMarker)) {
+		return fmt.Errorf("%s exists and was not installed by us; remove it first", file)
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	args := []string{self}
+	hasCheck := false
+	for _, f := range flags {
+		if f == "-check" || f == "--check" {
+			hasCheck = true
+		}
+		args = append(args, f)
+	}
+	if !hasCheck {
+		args = append(args, "-check")
+	}
+	for i := range args {
+		args[i] = shellQuote(args[i])
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	err = writeFileAtomic(file, backup, func(w io.Writer) error {
+		_, err := fmt.Fprintf(w, "#!/bin/sh\n%s\nexec %s\n", hookMarker, strings.Join(args, " "))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return os.Chmod(file, 0755)
+}
+
+// shellQuote quotes the string for use as a single word in a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,70 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	cases := map[string]string{
+		"plain":           `'plain'`,
+		"with space":      `'with space'`,
+		"it's":            `'it'\''s'`,
+		"$HOME `x` \"y\"": `'$HOME ` + "`x`" + ` "y"'`,
+	}
+	for in, expected := range cases {
+		if got := shellQuote(in); got != expected {
+			t.Errorf("shellQuote(%q) = %s, expected %s", in, got, expected)
+		}
+	}
+}
+
+func TestInstallHook(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	hook := filepath.Join(dir, ".git", "hooks", "pre-push")
+
+	mustRunMain(t, dir, "-read-authors", "my AUTHORS", "install-hook")
+	bs, err := ioutil.ReadFile(hook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(bs), "\n")
+	if len(lines) != 4 || lines[0] != "#!/bin/sh" || lines[1] != hookMarker || !strings.HasSuffix(lines[2], `' '-read-authors' 'my AUTHORS' '-check'`) {
+		t.Errorf("unexpected hook\n%s", bs)
+	}
+	if fi, err := os.Stat(hook); err != nil {
+		t.Fatal(err)
+	} else if fi.Mode()&0100 == 0 {
+		t.Errorf("hook not executable: %v", fi.Mode())
+	}
+
+	// Our own hook is replaced, with -check given only once
+	mustRunMain(t, dir, "-check", "install-hook", "pre-push")
+	if bs, err := ioutil.ReadFile(hook); err != nil {
+		t.Fatal(err)
+	} else if !strings.HasSuffix(string(bs), "' '-check'\n") || strings.Contains(string(bs), "-read-authors") {
+		t.Errorf("unexpected hook\n%s", bs)
+	}
+
+	// Others are not
+	other := filepath.Join(dir, ".git", "hooks", "post-merge")
+	if err := ioutil.WriteFile(other, []byte("#!/bin/sh\necho mine\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, code := runMain(t, dir, "install-hook", "post-merge"); code == 0 || !strings.Contains(stderr, "exists and was not installed by us") {
+		t.Errorf("exit code %d replacing another hook\n%s", code, stderr)
+	}
+	if _, stderr, code := runMain(t, dir, "install-hook", "pre-commit"); code == 0 || !strings.Contains(stderr, `unsupported hook "pre-commit"`) {
+		t.Errorf("exit code %d for an unsupported hook\n%s", code, stderr)
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// An importCommit is a commit whose changed lines aren't attributed to its
+// author, such as a drop of vendored or generated code. The commit itself
+// still counts.
+type importCommit struct {
+	hash    string
+	email   string
+	subject string
+	lines   int
+	reason  string // "listed" or "threshold"
+}
+
+// lineCounts returns a map from commit hash to the number of lines added
+// and removed in that commit, for the given revisions. Binary files count
+// as nothing.
+func lineCounts(revs []string) map[string]int {
+	args := append([]string{"log", "--numstat", "--format=%x00%H"}, revs...)
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = os.Stderr
+	bs, err := cmd.Output()
+	if err != nil {
+		fatal("git:", err)
+	}
+
+	lines := make(map[string]int)
+	var hash string
+	for _, line := range strings.Split(string(bs), "\n") {
+		if strings.HasPrefix(line, "\x00") {
+			hash = line[1:]
+			continue
+		}
+		// <added> TAB <removed> TAB <path>, with "-" for binary files
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 || hash == "" {
+			continue
+		}
+		added, _ := strconv.Atoi(fields[0])
+		removed, _ := strconv.Atoi(fields[1])
+		lines[hash] += added + removed
+	}
+	return lines
+}
+
+// getImports returns the commits that are imports, either because the
+// rules say so or because they change more than threshold lines, unless
+// that is zero.
+func getImports(commits []commit, lines map[string]int, rules excludes, threshold int) []importCommit {
+	var res []importCommit
+	for _, c := range commits {
+		reason := ""
+		switch {
+		case rules.matches(c):
+			reason = "listed"
+		case threshold > 0 && lines[c.hash] > threshold:
+			reason = "threshold"
+		default:
+			continue
+		}
+		res = append(res, importCommit{
+			hash:    c.hash,
+			email:   c.email,
+			subject: commitSubject(c.message),
+			lines:   lines[c.hash],
+			reason:  reason,
+		})
+	}
+	return res
+}
+
+// getLines attributes the changed lines of each commit to the commit
+// author, except for imports.
+func getLines(authors []author, idx *authorIndex, commits []commit, lines map[string]int, imports []importCommit) {
+	skip := make(stringSet)
+	for _, c := range imports {
+		skip.add(c.hash)
+	}
+	for _, c := range commits {
+		if skip.has(c.hash) {
+			continue
+		}
+		if i, ok := idx.email(c.email); ok {
+			authors[i].lines += lines[c.hash]
+		}
+	}
+}
+
+// writeLines writes the number of changed lines per author, most lines
+// first, skipping authors without any.
+func writeLines(w io.Writer, authors []author) error {
+	sorted := make([]author, len(authors))
+	copy(sorted, authors)
+	sortAuthors(sorted, func(a author) float64 { return float64(a.lines) })
+
+	bw := bufio.NewWriter(w)
+	for _, a := range sorted {
+		if a.lines == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "%8d %s\n", a.lines, a.displayName())
+	}
+	return bw.Flush()
+}
+
+// writeImports writes the commits treated as imports, for the user to
+// confirm. Lines are in the -imports file format, with the reason and the
+// size as a comment, so confirmed entries can be copied over as they are.
+func writeImports(w io.Writer, imports []importCommit) error {
+	bw := bufio.NewWriter(w)
+	for _, c := range imports {
+		fmt.Fprintf(bw, "# %s, %d lines by %s\n", c.reason, c.lines, c.email)
+		fmt.Fprintf(bw, "%s %s\n", c.hash, c.subject)
+	}
+	return bw.Flush()
+}
+
+// largestCommits is the number of commits per author kept for -outliers.
+const largestCommits = 3
+
+// A largeCommit is one of an author's largest commits.
+type largeCommit struct {
+	hash    string
+	subject string
+	lines   int
+	imports bool
+}
+
+// getLargest records the largest commits of each author, by changed
+// lines, marking those that are imports.
+func getLargest(authors []author, idx *authorIndex, commits []commit, lines map[string]int, imports []importCommit) {
+	isImport := make(stringSet)
+	for _, c := range imports {
+		isImport.add(c.hash)
+	}
+	for _, c := range commits {
+		i, ok := idx.email(c.email)
+		if !ok || lines[c.hash] == 0 {
+			continue
+		}
+		lc := largeCommit{hash: c.hash, subject: commitSubject(c.message), lines: lines[c.hash], imports: isImport.has(c.hash)}
+		largest := authors[i].largest
+		j := sort.Search(len(largest), func(j int) bool { return largest[j].lines < lc.lines })
+		if j >= largestCommits {
+			continue
+		}
+		largest = append(largest, largeCommit{})
+		copy(largest[j+1:], largest[j:])
+		largest[j] = lc
+		if len(largest) > largestCommits {
+			largest = largest[:largestCommits]
+		}
+		authors[i].largest = largest
+	}
+}
+
+// writeOutliers writes the largest commits per author, authors with the
+// largest commit first. Commits of at least threshold lines are flagged
+// with an exclamation mark, imports with an I.
+func writeOutliers(w io.Writer, authors []author, threshold int) error {
+	sorted := make([]author, len(authors))
+	copy(sorted, authors)
+	sortAuthors(sorted, func(a author) float64 {
+		if len(a.largest) == 0 {
+			return 0
+		}
+		return float64(a.largest[0].lines)
+	})
+
+	bw := bufio.NewWriter(w)
+	outliers := 0
+	for _, a := range sorted {
+		if len(a.largest) == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "%s\n", a.displayName())
+		for _, c := range a.largest {
+			flag := " "
+			switch {
+			case c.imports:
+				flag = "I"
+			case c.lines >= threshold:
+				flag = "!"
+				outliers++
+			}
+			fmt.Fprintf(bw, "  %s %8d %.12s %s\n", flag, c.lines, c.hash, c.subject)
+		}
+	}
+	if outliers > 0 {
+		fmt.Fprintf(bw, "\n%d commits of %d lines or more; consider listing them in -imports\n", outliers, threshold)
+	}
+	return bw.Flush()
+}
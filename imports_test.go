@@ -0,0 +1,96 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// importHistory has a vendored code drop by Bob between the commits by
+// Alice and Carol.
+var importHistory = []testCommit{
+	{author: "Alice A <alice@example.com>", date: "2020-01-01T12:00:00Z", message: "Add alice", files: map[string]string{"alice.txt": "one\ntwo\n", "logo.bin": "\x00\x01\x02"}},
+	{author: "Bob B <bob@example.com>", date: "2020-02-01T12:00:00Z", message: "Vendor lib", files: map[string]string{"vendor/lib.txt": strings.Repeat("line\n", 50)}},
+	{author: "Carol C <carol@example.com>", date: "2020-03-01T12:00:00Z", message: "Edit alice", files: map[string]string{"alice.txt": "one\nthree\n"}},
+}
+
+func TestLines(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, importHistory...)
+	defer cleanup()
+
+	if out := mustRunMain(t, dir, "-lines"); out != "      50 Bob B\n       2 Alice A\n       2 Carol C\n" {
+		t.Errorf("unexpected output\n%s", out)
+	}
+
+	imports := writeTestFile(t, dir, "imports", "# Vendored\n"+revParse(t, dir, "HEAD~1")[:10]+" Vendor lib\n")
+	if out := mustRunMain(t, dir, "-imports", imports, "-lines"); out != "       2 Alice A\n       2 Carol C\n" {
+		t.Errorf("unexpected output with imports\n%s", out)
+	}
+	// The import still counts as a commit
+	if out := mustRunMain(t, dir, "-imports", imports, "-stats"); !containsLine(out, "    1  0 Bob B") {
+		t.Errorf("unexpected stats with imports\n%s", out)
+	}
+}
+
+func TestImportsReport(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, importHistory...)
+	defer cleanup()
+
+	out := mustRunMain(t, dir, "-import-threshold", "10", "-imports-report")
+	expected := "# threshold, 50 lines by bob@example.com\n" + revParse(t, dir, "HEAD~1") + " Vendor lib\n"
+	if out != expected {
+		t.Errorf("output\n%s\nexpected\n%s", out, expected)
+	}
+	if out := mustRunMain(t, dir, "-import-threshold", "10", "-lines"); strings.Contains(out, "Bob") {
+		t.Errorf("import counted\n%s", out)
+	}
+}
+
+func TestGetLargest(t *testing.T) {
+	authors := []author{{name: "Alice A", emails: []string{"alice@example.com"}}}
+	lines := map[string]int{"a": 5, "b": 50, "c": 1, "d": 20, "e": 0}
+	var commits []commit
+	for _, hash := range []string{"a", "b", "c", "d", "e"} {
+		commits = append(commits, commit{hash: hash, email: "alice@example.com", message: "Commit " + hash})
+	}
+	getLargest(authors, newAuthorIndex(authors), commits, lines, []importCommit{{hash: "d"}})
+
+	expected := []largeCommit{
+		{hash: "b", subject: "Commit b", lines: 50},
+		{hash: "d", subject: "Commit d", lines: 20, imports: true},
+		{hash: "a", subject: "Commit a", lines: 5},
+	}
+	if !reflect.DeepEqual(authors[0].largest, expected) {
+		t.Errorf("largest %+v, expected %+v", authors[0].largest, expected)
+	}
+}
+
+func TestOutliers(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, importHistory...)
+	defer cleanup()
+	hash := func(rev string) string { return revParse(t, dir, rev)[:12] }
+
+	out := mustRunMain(t, dir, "-outliers", "-outlier-lines", "20")
+	expected := "Bob B\n" +
+		"  !       50 " + hash("HEAD~1") + " Vendor lib\n" +
+		"Alice A\n" +
+		"           2 " + hash("HEAD~2") + " Add alice\n" +
+		"Carol C\n" +
+		"           2 " + hash("HEAD") + " Edit alice\n" +
+		"\n1 commits of 20 lines or more; consider listing them in -imports\n"
+	if out != expected {
+		t.Errorf("output\n%s\nexpected\n%s", out, expected)
+	}
+
+	imports := writeTestFile(t, dir, "imports", hash("HEAD~1")+"\n")
+	out = mustRunMain(t, dir, "-imports", imports, "-outliers", "-outlier-lines", "20")
+	if !containsLine(out, "  I       50 "+hash("HEAD~1")+" Vendor lib") || strings.Contains(out, "consider listing") {
+		t.Errorf("unexpected output with imports\n%s", out)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import "strings"
+
+// An authorIndex maps email addresses and normalized names to positions in
+// an author list. It is built once and kept up to date as authors and
+// emails are added, so that matching is a map lookup instead of a scan.
+type authorIndex struct {
+	byEmail map[string]int
+	byName  map[string]int
+}
+
+func newAuthorIndex(authors []author) *authorIndex {
+	x := &authorIndex{
+		byEmail: make(map[string]int),
+		byName:  make(map[string]int),
+	}
+	for i := range authors {
+		x.add(authors, i)
+	}
+	return x
+}
+
+// add indexes the name and emails of authors[i]. Existing entries are
+// overwritten.
+func (x *authorIndex) add(authors []author, i int) {
+	if name := normalizeName(authors[i].name); name != "" {
+		x.byName[name] = i
+	}
+	for _, e := range authors[i].emails {
+		x.byEmail[e] = i
+	}
+}
+
+// addEmail indexes the email address as belonging to authors[i].
+func (x *authorIndex) addEmail(email string, i int) {
+	x.byEmail[email] = i
+}
+
+// email returns the index of the author with the given email address.
+func (x *authorIndex) email(email string) (int, bool) {
+	i, ok := x.byEmail[email]
+	return i, ok
+}
+
+// name returns the index of the author with the given name, compared after
+// normalization.
+func (x *authorIndex) name(name string) (int, bool) {
+	name = normalizeName(name)
+	if name == "" {
+		return 0, false
+	}
+	i, ok := x.byName[name]
+	return i, ok
+}
+
+// normalizeName returns the name in lower case with white space collapsed,
+// for comparison purposes.
+func normalizeName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
@@ -0,0 +1,45 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import "testing"
+
+func TestAuthorIndex(t *testing.T) {
+	authors := []author{
+		{name: "Alice A", emails: []string{"alice@example.com", "alice@example.net"}},
+		{name: "Bob  B", emails: []string{"bob@example.com"}},
+		{emails: []string{"nameless@example.com"}},
+	}
+	idx := newAuthorIndex(authors)
+
+	emails := map[string]int{"alice@example.com": 0, "alice@example.net": 0, "bob@example.com": 1, "nameless@example.com": 2}
+	for email, expected := range emails {
+		if i, ok := idx.email(email); !ok || i != expected {
+			t.Errorf("email(%q) = %d, %v, expected %d", email, i, ok, expected)
+		}
+	}
+
+	names := map[string]int{"Alice A": 0, "alice a": 0, " Bob B": 1, "bob\tb": 1}
+	for name, expected := range names {
+		if i, ok := idx.name(name); !ok || i != expected {
+			t.Errorf("name(%q) = %d, %v, expected %d", name, i, ok, expected)
+		}
+	}
+	if _, ok := idx.name(""); ok {
+		t.Error("empty name matched")
+	}
+
+	authors = append(authors, author{name: "Carol C"})
+	idx.add(authors, 3)
+	idx.addEmail("carol@example.com", 3)
+	if i, ok := idx.email("carol@example.com"); !ok || i != 3 {
+		t.Errorf("added email = %d, %v", i, ok)
+	}
+	if i, ok := idx.name("Carol C"); !ok || i != 3 {
+		t.Errorf("added name = %d, %v", i, ok)
+	}
+}
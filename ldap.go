@@ -0,0 +1,144 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ldapBatch is the number of emails looked up per LDAP query.
+const ldapBatch = 50
+
+// An ldapEntry is the directory information for a person.
+type ldapEntry struct {
+	displayName string
+	department  string
+}
+
+// ldapLookup looks up the emails in the directory given as "url,basedn",
+// returning the entries found by lower cased email. The lookup is done by
+// ldapsearch, binding as $LDAP_BIND_DN with $LDAP_BIND_PASSWORD if set and
+// anonymously otherwise.
+func ldapLookup(spec string, emails []string) (map[string]ldapEntry, error) {
+	comma := strings.Index(spec, ",")
+	if comma < 0 {
+		return nil, fmt.Errorf("%q is not url,basedn", spec)
+	}
+	url, base := spec[:comma], spec[comma+1:]
+
+	entries := make(map[string]ldapEntry)
+	for len(emails) > 0 {
+		n := ldapBatch
+		if n > len(emails) {
+			n = len(emails)
+		}
+		var filter strings.Builder
+		filter.WriteString("(|")
+		for _, e := range emails[:n] {
+			fmt.Fprintf(&filter, "(mail=%s)", ldapEscaper.Replace(e))
+		}
+		filter.WriteString(")")
+		emails = emails[n:]
+
+		args := []string{"-LLL", "-x", "-H", url, "-b", base}
+		cmd := exec.Command("ldapsearch")
+		if dn := os.Getenv("LDAP_BIND_DN"); dn != "" {
+			args = append(args, "-D", dn, "-y", "/dev/stdin")
+			cmd.Stdin = strings.NewReader(os.Getenv("LDAP_BIND_PASSWORD"))
+		}
+		args = append(args, filter.String(), "mail", "displayName", "department")
+		cmd.Args = append(cmd.Args, args...)
+		cmd.Stderr = os.Stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("ldapsearch: %v", err)
+		}
+		for _, rec := range parseLDIF(string(out)) {
+			e := ldapEntry{displayName: firstValue(rec["displayname"]), department: firstValue(rec["department"])}
+			for _, mail := range rec["mail"] {
+				entries[strings.ToLower(mail)] = e
+			}
+		}
+	}
+	return entries, nil
+}
+
+// ldapEscaper escapes the special characters in LDAP filter values.
+var ldapEscaper = strings.NewReplacer(`\`, `\5c`, "*", `\2a`, "(", `\28`, ")", `\29`, "\x00", `\00`)
+
+// parseLDIF parses the LDIF output of ldapsearch into records of attribute
+// values, keyed by lower cased attribute name.
+func parseLDIF(s string) []map[string][]string {
+	// Unfold continuation lines, which start with a single space
+	s = strings.Replace(s, "\r\n", "\n", -1)
+	s = strings.Replace(s, "\n ", "", -1)
+
+	var recs []map[string][]string
+	for _, block := range strings.Split(s, "\n\n") {
+		rec := make(map[string][]string)
+		for _, line := range strings.Split(block, "\n") {
+			if line == "" || line[0] == '#' {
+				continue
+			}
+			colon := strings.Index(line, ":")
+			if colon < 0 {
+				continue
+			}
+			attr, val := strings.ToLower(line[:colon]), line[colon+1:]
+			if strings.HasPrefix(val, ":") {
+				bs, err := base64.StdEncoding.DecodeString(strings.TrimSpace(val[1:]))
+				if err != nil {
+					continue
+				}
+				val = string(bs)
+			}
+			rec[attr] = append(rec[attr], strings.TrimSpace(val))
+		}
+		if len(rec) > 0 {
+			recs = append(recs, rec)
+		}
+	}
+	return recs
+}
+
+func firstValue(ss []string) string {
+	if len(ss) == 0 {
+		return ""
+	}
+	return ss[0]
+}
+
+// applyLDAP sets the name and department of the authors from the
+// directory, by the first of their emails found there.
+func applyLDAP(authors []author, spec string) error {
+	var emails []string
+	for _, a := range authors {
+		emails = append(emails, a.emails...)
+	}
+	entries, err := ldapLookup(spec, emails)
+	if err != nil {
+		return err
+	}
+	for i := range authors {
+		for _, email := range authors[i].emails {
+			e, ok := entries[strings.ToLower(email)]
+			if !ok {
+				continue
+			}
+			if e.displayName != "" {
+				authors[i].name = e.displayName
+			}
+			authors[i].department = e.department
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseLDIF(t *testing.T) {
+	ldif := "# extended LDIF\r\n" +
+		"dn: uid=jdoe,ou=people,dc=example,dc=com\r\n" +
+		"mail: jane@example.com\r\n" +
+		"mail: jdoe@example.com\r\n" +
+		"displayName: Jane\r\n" +
+		"  Doe\r\n" +
+		"\r\n" +
+		"dn: uid=jm,ou=people,dc=example,dc=com\r\n" +
+		"Mail: jm@example.com\r\n" +
+		"displayName:: SsO2cmcgTcO8bGxlcg==\r\n" +
+		"department: R&D\r\n"
+	expected := []map[string][]string{
+		{
+			"dn":          {"uid=jdoe,ou=people,dc=example,dc=com"},
+			"mail":        {"jane@example.com", "jdoe@example.com"},
+			"displayname": {"Jane Doe"},
+		},
+		{
+			"dn":          {"uid=jm,ou=people,dc=example,dc=com"},
+			"mail":        {"jm@example.com"},
+			"displayname": {"Jörg Müller"},
+			"department":  {"R&D"},
+		},
+	}
+	if recs := parseLDIF(ldif); !reflect.DeepEqual(recs, expected) {
+		t.Errorf("parsed %v, expected %v", recs, expected)
+	}
+}
+
+// fakeLDAPSearch is an ldapsearch that records its arguments and standard
+// input in the files args and stdin next to it, and prints an entry.
+const fakeLDAPSearch = `#!/bin/sh
+dir=$(dirname "$0")
+printf '%s\n' "$@" > "$dir/args"
+cat > "$dir/stdin"
+echo "dn: uid=jdoe,ou=people,dc=example,dc=com"
+echo "mail: JANE@example.com"
+echo "displayName: Jane Doe"
+echo "department: Engineering"
+`
+
+func TestApplyLDAP(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell script as ldapsearch")
+	}
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	if err := ioutil.WriteFile(filepath.Join(dir, "ldapsearch"), []byte(fakeLDAPSearch), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{
+		"PATH":               dir + string(os.PathListSeparator) + os.Getenv("PATH"),
+		"LDAP_BIND_DN":       "cn=reader,dc=example,dc=com",
+		"LDAP_BIND_PASSWORD": "secret",
+	} {
+		if old, ok := os.LookupEnv(k); ok {
+			defer os.Setenv(k, old)
+		} else {
+			defer os.Unsetenv(k)
+		}
+		os.Setenv(k, v)
+	}
+
+	authors := []author{
+		{name: "jdoe", emails: []string{"jdoe@laptop.local", "jane@example.com"}},
+		{name: "Someone (else)", emails: []string{"*@example.com"}},
+	}
+	if err := applyLDAP(authors, "ldaps://ldap.example.com,dc=example,dc=com"); err != nil {
+		t.Fatal(err)
+	}
+	if authors[0].name != "Jane Doe" || authors[0].department != "Engineering" {
+		t.Errorf("first author is %q in %q", authors[0].name, authors[0].department)
+	}
+	if authors[1].name != "Someone (else)" || authors[1].department != "" {
+		t.Errorf("second author is %q in %q", authors[1].name, authors[1].department)
+	}
+
+	bs, err := ioutil.ReadFile(filepath.Join(dir, "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Split(strings.TrimSuffix(string(bs), "\n"), "\n")
+	expected := []string{
+		"-LLL", "-x", "-H", "ldaps://ldap.example.com", "-b", "dc=example,dc=com",
+		"-D", "cn=reader,dc=example,dc=com", "-y", "/dev/stdin",
+		`(|(mail=jdoe@laptop.local)(mail=jane@example.com)(mail=\2a@example.com))`,
+		"mail", "displayName", "department",
+	}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("ldapsearch %q, expected %q", args, expected)
+	}
+	if bs, err := ioutil.ReadFile(filepath.Join(dir, "stdin")); err != nil {
+		t.Fatal(err)
+	} else if string(bs) != "secret" {
+		t.Errorf("password %q on standard input", bs)
+	}
+}
+
+func TestLDAPSpec(t *testing.T) {
+	if _, err := ldapLookup("ldaps://ldap.example.com", []string{"jane@example.com"}); err == nil {
+		t.Error("no error for a spec without a base DN")
+	}
+}
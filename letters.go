@@ -0,0 +1,89 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A letterGroup is the authors whose names start with the letter.
+type letterGroup struct {
+	letter  string
+	authors []author
+}
+
+// letterOf returns the upper cased first letter of the name, or "#" if
+// it doesn't start with a letter.
+func letterOf(name string) string {
+	r, _ := utf8.DecodeRuneInString(strings.TrimSpace(name))
+	if !unicode.IsLetter(r) {
+		return "#"
+	}
+	return string(unicode.ToUpper(r))
+}
+
+// groupByLetter returns the authors sorted by name and grouped by the
+// first letter of their display name, with "#" first.
+func groupByLetter(authors []author) []letterGroup {
+	sorted := make([]author, len(authors))
+	copy(sorted, authors)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return strings.ToLower(sorted[i].shownName()) < strings.ToLower(sorted[j].shownName())
+	})
+
+	var groups []letterGroup
+	byLetter := make(map[string]int)
+	for _, a := range sorted {
+		letter := letterOf(a.shownName())
+		i, ok := byLetter[letter]
+		if !ok {
+			i = len(groups)
+			byLetter[letter] = i
+			groups = append(groups, letterGroup{letter: letter})
+		}
+		groups[i].authors = append(groups[i].authors, a)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].letter == "#" || groups[j].letter == "#" {
+			return groups[i].letter == "#" && groups[j].letter != "#"
+		}
+		return false
+	})
+	return groups
+}
+
+// Letter headings for the outputs that can be grouped.
+var letterHeadings = map[string]string{
+	"names":    "%s\n",
+	"markdown": "## %s\n\n",
+	"html":     "<h2>%s</h2>\n",
+}
+
+// writeByLetter writes the authors grouped by letter, each group with the
+// heading for the output format followed by the group written by list.
+func writeByLetter(w io.Writer, format string, authors []author, list func(w io.Writer, authors []author) error) error {
+	for i, g := range groupByLetter(authors) {
+		if i > 0 && format != "html" {
+			if _, err := fmt.Fprintf(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, letterHeadings[format], html.EscapeString(g.letter)); err != nil {
+			return err
+		}
+		if err := list(w, g.authors); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import "testing"
+
+func TestLetterOf(t *testing.T) {
+	cases := map[string]string{
+		"Alice":    "A",
+		"adam":     "A",
+		" örjan":   "Ö",
+		"42 Team":  "#",
+		"(nobody)": "#",
+		"":         "#",
+		"Ωmega":    "Ω",
+	}
+	for name, expected := range cases {
+		if got := letterOf(name); got != expected {
+			t.Errorf("letterOf(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
+
+func TestGroupByLetter(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Bob B <bob@example.com>", message: "One"},
+		testCommit{author: "42 Team <team@example.com>", message: "Two"},
+		testCommit{author: "Alice A <alice@example.com>", message: "Three"},
+		testCommit{author: "adam a <adam@example.com>", message: "Four"},
+	)
+	defer cleanup()
+
+	out := mustRunMain(t, dir, "-names", "-group-by-letter")
+	if out != "#\n42 Team\n\nA\nadam a, Alice A\n\nB\nBob B\n" {
+		t.Errorf("unexpected names\n%s", out)
+	}
+	out = mustRunMain(t, dir, "-markdown", "-group-by-letter")
+	if out != "## #\n\n- 42 Team\n\n## A\n\n- adam a\n- Alice A\n\n## B\n\n- Bob B\n" {
+		t.Errorf("unexpected markdown\n%s", out)
+	}
+	out = mustRunMain(t, dir, "-html", "-group-by-letter")
+	if out != "<h2>#</h2>\n<ul>\n<li>42 Team</li>\n</ul>\n<h2>A</h2>\n<ul>\n<li>adam a</li>\n<li>Alice A</li>\n</ul>\n<h2>B</h2>\n<ul>\n<li>Bob B</li>\n</ul>\n" {
+		t.Errorf("unexpected html\n%s", out)
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// License headers are looked for in the first headerLines lines, of at
+// most headerBytes bytes, of a file.
+const (
+	headerLines = 30
+	headerBytes = 4096
+)
+
+// otherLicense is the license of headers we don't recognize, that still
+// claim copyright.
+const otherLicense = "other"
+
+var spdxRe = regexp.MustCompile(`SPDX-License-Identifier:\s*(.+)`)
+
+// licensePhrases identify the common licenses by the text of their
+// headers, when there is no SPDX identifier.
+var licensePhrases = []struct {
+	phrase  string
+	license string
+}{
+	{"mozilla public license, v. 2.0", "MPL-2.0"},
+	{"apache license, version 2.0", "Apache-2.0"},
+	{"gnu lesser general public license", "LGPL"},
+	{"gnu affero general public license", "AGPL"},
+	{"gnu general public license", "GPL"},
+	{"permission is hereby granted, free of charge", "MIT"},
+	{"redistribution and use in source and binary forms", "BSD"},
+	{"use of this source code is governed by a bsd-style license", "BSD"},
+}
+
+// licenseHeader returns the license named in the header of the file: the
+// SPDX identifier, the license recognized from the text, otherLicense if
+// there is only a copyright statement, or the empty string if there is no
+// license header.
+func licenseHeader(bs []byte) string {
+	lines := strings.SplitN(string(bs), "\n", headerLines+1)
+	if len(lines) > headerLines {
+		lines = lines[:headerLines]
+	}
+	for i, line := range lines {
+		if m := spdxRe.FindStringSubmatch(line); m != nil {
+			id := strings.TrimSpace(m[1])
+			id = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(id, "*/"), "-->"))
+			if id != "" {
+				return id
+			}
+		}
+		// Without comment markers, so that phrases span lines
+		lines[i] = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "/#*;-!<%"))
+	}
+	text := strings.ToLower(strings.Join(lines, " "))
+	for _, p := range licensePhrases {
+		if strings.Contains(text, p.phrase) {
+			return p.license
+		}
+	}
+	if strings.Contains(text, "copyright") {
+		return otherLicense
+	}
+	return ""
+}
+
+// fileHeads returns the first headerBytes bytes of each of the blobs,
+// given as "rev:path", read with a single git cat-file. Blobs that don't
+// exist, such as submodules, are left out.
+func fileHeads(specs []string) map[string][]byte {
+	cmd := exec.Command("git", "cat-file", "--batch")
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		fatal("git:", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		fatal("git:", err)
+	}
+	if err := cmd.Start(); err != nil {
+		fatal("git:", err)
+	}
+	go func() {
+		bw := bufio.NewWriter(stdin)
+		for _, spec := range specs {
+			fmt.Fprintf(bw, "%s\n", spec)
+		}
+		bw.Flush()
+		stdin.Close()
+	}()
+
+	heads := make(map[string][]byte)
+	br := bufio.NewReader(stdout)
+	for _, spec := range specs {
+		// "<oid> <type> <size>", or "<spec> missing"
+		line, err := br.ReadString('\n')
+		if err != nil {
+			fatal("git cat-file:", err)
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			fatal("git cat-file: unexpected output", line)
+		}
+		n := size
+		if n > headerBytes {
+			n = headerBytes
+		}
+		head := make([]byte, n)
+		if _, err := io.ReadFull(br, head); err != nil {
+			fatal("git cat-file:", err)
+		}
+		if _, err := io.CopyN(ioutil.Discard, br, size-n+1); err != nil {
+			fatal("git cat-file:", err)
+		}
+		if fields[1] == "blob" {
+			heads[spec] = head
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		fatal("git cat-file:", err)
+	}
+	return heads
+}
+
+// getLicenseHeaders attributes the license headers of the files added in
+// each commit, as given by addedFiles, to the commit author.
+func getLicenseHeaders(authors []author, idx *authorIndex, commits []commit, added map[string][]string) {
+	var specs []string
+	for _, c := range commits {
+		if _, ok := idx.email(c.email); !ok {
+			continue
+		}
+		for _, path := range added[c.hash] {
+			specs = append(specs, c.hash+":"+path)
+		}
+	}
+	heads := fileHeads(specs)
+
+	for _, c := range commits {
+		i, ok := idx.email(c.email)
+		if !ok {
+			continue
+		}
+		for _, path := range added[c.hash] {
+			license := licenseHeader(heads[c.hash+":"+path])
+			if license == "" {
+				continue
+			}
+			if authors[i].licenses == nil {
+				authors[i].licenses = make(map[string]int)
+			}
+			authors[i].licenses[license]++
+		}
+	}
+}
+
+// licensedFiles returns the number of files the author added with a
+// license header.
+func (a author) licensedFiles() int {
+	n := 0
+	for _, c := range a.licenses {
+		n += c
+	}
+	return n
+}
+
+// licenseSummary returns the licenses of the author's added files, most
+// common first, like "MPL-2.0 12, MIT 1".
+func licenseSummary(licenses map[string]int) string {
+	type count struct {
+		license string
+		n       int
+	}
+	var counts []count
+	for l, n := range licenses {
+		counts = append(counts, count{l, n})
+	}
+	sort.Slice(counts, func(a, b int) bool {
+		if counts[a].n != counts[b].n {
+			return counts[a].n > counts[b].n
+		}
+		return counts[a].license < counts[b].license
+	})
+	var buf bytes.Buffer
+	for i, c := range counts {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		fmt.Fprintf(&buf, "%s %d", c.license, c.n)
+	}
+	return buf.String()
+}
@@ -0,0 +1,163 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var validEmailRe = regexp.MustCompile(`^[^@\s<>()]+@[^@\s<>()]+$`)
+
+// A lintFinding is a problem found in the AUTHORS file. The line is zero
+// when not known, as for YAML files.
+type lintFinding struct {
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// lintEntry is an author together with where it was found.
+type lintEntry struct {
+	author author
+	line   int
+}
+
+// lintAuthors returns the problems found in the AUTHORS file.
+func lintAuthors(file string) []lintFinding {
+	bs := readAll(file)
+	var findings []lintFinding
+	report := func(line int, format string, args ...interface{}) {
+		findings = append(findings, lintFinding{file, line, fmt.Sprintf(format, args...)})
+	}
+
+	var entries []lintEntry
+	if isYAMLFile(file) {
+		authors, err := parseYAMLAuthors(bs)
+		if err != nil {
+			fatalf("%s: %v", file, err)
+		}
+		for _, a := range authors {
+			entries = append(entries, lintEntry{a, 0})
+		}
+	} else {
+		pinNext := false
+		var more []string
+		for i, line := range strings.Split(string(bs), "\n") {
+			if line != strings.TrimRight(line, " \t\r") {
+				report(i+1, "trailing white space")
+			}
+			if strings.TrimSpace(line) == pinDirective {
+				pinNext = true
+				continue
+			}
+			if emails, ok := moreEmails(line); ok {
+				more = append(more, emails...)
+				continue
+			}
+			if strings.TrimSpace(line) == "" || line[0] == '#' {
+				continue
+			}
+			a := parseAuthors([]byte(line))[0]
+			if pinNext {
+				a.pin = len(entries) + 1
+				pinNext = false
+			}
+			a.emails = append(a.emails, more...)
+			more = nil
+			entries = append(entries, lintEntry{a, i + 1})
+			entry, _ := splitNote(line)
+			lintLine(entry, func(format string, args ...interface{}) {
+				report(i+1, format, args...)
+			})
+		}
+	}
+
+	seen := make(map[string]string)
+	var prev *lintEntry
+	for i := range entries {
+		e := &entries[i]
+		a := e.author
+		if len(a.emails) == 0 {
+			report(e.line, "%s: no email address", a.name)
+		}
+		for _, email := range a.emails {
+			if !validEmailRe.MatchString(email) {
+				report(e.line, "%s: malformed email address <%s>", a.name, email)
+			}
+			key := strings.ToLower(email)
+			if other, ok := seen[key]; ok {
+				report(e.line, "%s: email <%s> is also listed for %s", a.name, email, other)
+				continue
+			}
+			seen[key] = a.name
+		}
+		if strings.ContainsAny(a.name, "()<>@") {
+			report(e.line, "%s: malformed name, nickname or email", a.name)
+		}
+		if a.pin > 0 {
+			// Pinned entries are exempt from sorting
+			continue
+		}
+		if prev != nil && strings.ToLower(a.name) < strings.ToLower(prev.author.name) {
+			report(e.line, "%s: not sorted, should come before %s", a.name, prev.author.name)
+		}
+		prev = e
+	}
+	sort.SliceStable(findings, func(a, b int) bool {
+		return findings[a].Line < findings[b].Line
+	})
+	return findings
+}
+
+// lintLine reports problems with the order of the fields on an AUTHORS
+// line, which should be the name, then the nickname, then the emails and
+// URLs.
+func lintLine(line string, report func(format string, args ...interface{})) {
+	seenEmail := false
+	for _, field := range strings.Fields(line) {
+		switch {
+		case nicknameRe.MatchString(field):
+			if seenEmail {
+				report("nickname %s should come before the emails", field)
+			}
+		case urlRe.MatchString(field):
+		case emailRe.MatchString(field):
+			seenEmail = true
+		default:
+			if seenEmail {
+				report("name part %q should come before the emails", field)
+			}
+		}
+	}
+}
+
+func writeLintText(w io.Writer, findings []lintFinding) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range findings {
+		if f.Line > 0 {
+			fmt.Fprintf(bw, "%s:%d: %s\n", f.File, f.Line, f.Message)
+		} else {
+			fmt.Fprintf(bw, "%s: %s\n", f.File, f.Message)
+		}
+	}
+	return bw.Flush()
+}
+
+func writeLintJSON(w io.Writer, findings []lintFinding) error {
+	if findings == nil {
+		findings = []lintFinding{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(findings)
+}
@@ -0,0 +1,71 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	writeTestFile(t, dir, "AUTHORS", "# Authors\n\n"+
+		"Bob B <bob@example.com>\t\n"+
+		"Alice A <alice@example.com>\n"+
+		"Carol C <carol@example.com> (carol)\n"+
+		"Dave D\n"+
+		"Eve E <BOB@example.com>\n"+
+		"Frank F <frank@@example.com>\n")
+
+	stdout, _, code := runMain(t, dir, "lint", "AUTHORS")
+	if code != 1 {
+		t.Errorf("exit code %d with findings", code)
+	}
+	expected := "AUTHORS:3: trailing white space\n" +
+		"AUTHORS:4: Alice A: not sorted, should come before Bob B\n" +
+		"AUTHORS:5: nickname (carol) should come before the emails\n" +
+		"AUTHORS:6: Dave D: no email address\n" +
+		"AUTHORS:7: Eve E: email <BOB@example.com> is also listed for Bob B\n" +
+		"AUTHORS:8: Frank F: malformed email address <frank@@example.com>\n"
+	if stdout != expected {
+		t.Errorf("unexpected output\n%s\nexpected\n%s", stdout, expected)
+	}
+
+	writeTestFile(t, dir, "AUTHORS", "# Authors\n\nAlice A (alice) <alice@example.com> https://alice.example.com\nBob B <bob@example.com>\n")
+	if stdout, _, code := runMain(t, dir, "-read-authors", "AUTHORS", "lint"); code != 0 || stdout != "" {
+		t.Errorf("exit code %d for a clean file\n%s", code, stdout)
+	}
+}
+
+func TestLintJSON(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	writeTestFile(t, dir, "AUTHORS.yaml", "- name: Bob B\n  emails: [bob@example.com]\n- name: Alice A\n  emails: [bob@example.com]\n")
+
+	stdout, _, code := runMain(t, dir, "-check-json", "lint", "AUTHORS.yaml")
+	if code != 1 {
+		t.Errorf("exit code %d with findings", code)
+	}
+	var findings []lintFinding
+	if err := json.Unmarshal([]byte(stdout), &findings); err != nil {
+		t.Fatalf("%v:\n%s", err, stdout)
+	}
+	expected := []lintFinding{
+		{File: "AUTHORS.yaml", Message: "Alice A: email <bob@example.com> is also listed for Bob B"},
+		{File: "AUTHORS.yaml", Message: "Alice A: not sorted, should come before Bob B"},
+	}
+	if !reflect.DeepEqual(findings, expected) {
+		t.Errorf("findings %+v, expected %+v", findings, expected)
+	}
+
+	writeTestFile(t, dir, "AUTHORS.yaml", "- name: Alice A\n  emails: [alice@example.com]\n")
+	if stdout, _, code := runMain(t, dir, "-check-json", "lint", "AUTHORS.yaml"); code != 0 || stdout != "[]\n" {
+		t.Errorf("exit code %d for a clean file\n%s", code, stdout)
+	}
+}
@@ -0,0 +1,774 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+)
+
+// options holds the command line flags.
+type options struct {
+	authorsFile      string // the first of authorsFiles, which is the one written to
+	authorsFiles     stringList
+	authorsRef       string
+	printAuthors     bool
+	printNames       bool
+	printStats       bool
+	nameStyle        string
+	deriveNicknames  bool
+	profilesDir      string
+	activeWindow     string
+	decay            string
+	authorsFormat    string
+	maxEmails        int
+	suppressFile     string
+	printSPDX        bool
+	check            bool
+	checkJSON        bool
+	claFile          string
+	claSince         string
+	policyFile       string
+	policyRange      string
+	printCategories  bool
+	categoryRules    string
+	printMarkdown    bool
+	printHTML        bool
+	avatars          bool
+	avatarSize       int
+	avatarDir        string
+	gravatar         bool
+	printMergeStats  bool
+	printAddedFiles  bool
+	licenseHeaders   bool
+	printLines       bool
+	printImports     bool
+	importsFile      string
+	importThreshold  int
+	printOutliers    bool
+	outlierLines     int
+	printBlame       bool
+	blameCache       string
+	noBlameCache     bool
+	apiRate          int
+	apiRetries       int
+	apiCache         string
+	noAPICache       bool
+	offline          bool
+	reproducible     bool
+	fixedNow         time.Time // the time with -reproducible, once known
+	scopesFile       string
+	modulesFile      string
+	moduleFormat     string
+	writeScoped      bool
+	printSummary     bool
+	summaryCount     int
+	summarySep       string
+	summaryConj      string
+	top              int
+	maxLength        int
+	namesSep         string
+	namesConj        string
+	namesOxford      bool
+	namesWidth       int
+	namesIndent      string
+	groupByLetter    bool
+	printCredits     bool
+	creditTrailers   string
+	printThanks      bool
+	printPairs       bool
+	thanksTemplate   string
+	teamFile         string
+	teamOrg          string
+	printTeam        bool
+	trend            string
+	trendJSON        bool
+	printVelocity    bool
+	releaseTags      string
+	printDiversity   bool
+	printMilestones  bool
+	milestoneWindow  string
+	badgeDir         string
+	badgeProject     string
+	badgeLogo        string
+	templateFile     string
+	printJSON        bool
+	minContributions int
+	geekrank         bool
+	rankDaysActive   bool
+	streaks          bool
+	excludeHashes    string
+	excludePattern   string
+	explainFilter    bool
+	strict           bool
+	noNameMatching   bool
+	rewriteFile      string
+	allBranches      bool
+	refs             stringList
+	outs             stringList
+	backup           bool
+	authorsChangelog string
+	metadata         bool
+	versionCheck     bool
+	excludeReverts   bool
+	foldFixups       bool
+	notesRef         string
+	parallel         int
+	maxCommits       int
+	signingKeys      bool
+	ssoFile          string
+	ldap             string
+	sqliteFile       string
+	parquetFile      string
+	githubBackfill   string
+	printDot         bool
+	wordCloud        int
+	tagCredit        bool
+	maintenance      string
+	maintenanceBase  string
+	sample           int
+	sampleRandom     bool
+	sampleSeed       int64
+	cpuProfile       string
+	memProfile       string
+	man              bool
+	listen           string
+	serveRefresh     time.Duration
+	quiet            bool
+	warningsJSON     string
+	noColor          bool
+	color            bool // decided from noColor and the terminal
+	noPager          bool
+	watch            bool
+	watchInterval    time.Duration
+	inputs           []*string // the input file flags, watched by -watch
+}
+
+// inputVar defines a flag naming an input file or directory, which -watch
+// watches for changes along with the AUTHORS files.
+func (opts *options) inputVar(p *string, name, usage string) {
+	flag.StringVar(p, name, "", usage)
+	opts.inputs = append(opts.inputs, p)
+}
+
+func parseFlags() *options {
+	var opts options
+	flag.Var(&opts.authorsFiles, "read-authors", "Name of canonical AUTHORS file, or glob (repeatable, merging the files)")
+	flag.StringVar(&opts.authorsRef, "read-authors-ref", "", "Read the canonical AUTHORS file from a revision instead, as rev:path, e.g. main:AUTHORS")
+	flag.BoolVar(&opts.printAuthors, "authors", false, "Print the AUTHORS list")
+	flag.BoolVar(&opts.printNames, "names", false, "Print the name list")
+	flag.StringVar(&opts.nameStyle, "name-style", "full", "Render names in full, short (\"J. Doe\") or as initials (\"JD\")")
+	flag.StringVar(&opts.activeWindow, "active-window", "", "Only list authors with commits within this period in -names, e.g. 12m, 2y or 90d")
+	flag.StringVar(&opts.decay, "decay", "", "Order -names by commits weighted to halve in value over this period, e.g. 6m")
+	opts.inputVar(&opts.profilesDir, "profiles", "Directory of per contributor YAML files, like contributors.d, with preferred name, pronouns, url and avatar")
+	flag.BoolVar(&opts.deriveNicknames, "derive-nicknames", false, "Give authors without a nickname one from their forge user name or a distinctive freemail address")
+	flag.BoolVar(&opts.printStats, "stats", false, "Print the statistics")
+	flag.StringVar(&opts.authorsFormat, "authors-format", "text", "Format of the AUTHORS list (text, yaml)")
+	flag.IntVar(&opts.maxEmails, "max-emails", 0, "Print at most this many emails per author on the AUTHORS list lines; the others are kept in a directive before the entry, which is read back (0 for all)")
+	opts.inputVar(&opts.suppressFile, "suppress-emails", "File of email addresses to use for matching but never print")
+	flag.BoolVar(&opts.printSPDX, "spdx", false, "Print SPDX copyright lines, as used by REUSE")
+	flag.BoolVar(&opts.check, "check", false, "Report differences between the AUTHORS file and the git history, exiting non-zero if there are any")
+	flag.BoolVar(&opts.checkJSON, "check-json", false, "Report -check differences and lint findings as JSON; implies -check")
+	opts.inputVar(&opts.claFile, "cla", "Report contributors whose emails or user names are not in this file")
+	flag.StringVar(&opts.claSince, "cla-since", "", "Only report missing CLAs for contributors with commits after this date (YYYY-MM-DD)")
+	opts.inputVar(&opts.policyFile, "policy", "Report commits by authors not allowed by this file of emails, @domains and GitHub user names, exiting non-zero if there are any")
+	flag.StringVar(&opts.policyRange, "policy-range", "HEAD", "Revision range to audit with -policy, e.g. \"v1.0..HEAD\"")
+	flag.BoolVar(&opts.printCategories, "categories", false, "Print commit counts per category, based on subject prefixes")
+	opts.inputVar(&opts.categoryRules, "category-rules", "File of \"prefix category\" lines to use instead of the conventional commit types")
+	flag.BoolVar(&opts.printMarkdown, "markdown", false, "Print the authors as a Markdown list")
+	flag.BoolVar(&opts.printHTML, "html", false, "Print the authors as an HTML list")
+	flag.BoolVar(&opts.avatars, "avatars", false, "Include avatar images in Markdown and HTML output")
+	flag.IntVar(&opts.avatarSize, "avatar-size", 40, "Avatar image size in pixels")
+	flag.StringVar(&opts.avatarDir, "avatar-dir", "", "Download avatar images to this directory and reference the local copies")
+	flag.BoolVar(&opts.gravatar, "gravatar", false, "Use Gravatar for avatars of authors without a known forge account")
+	flag.BoolVar(&opts.printMergeStats, "merge-stats", false, "Print the number of commits per author pushed directly to the mainline versus merged")
+	flag.BoolVar(&opts.printAddedFiles, "added-files", false, "Print the number of files added per author")
+	flag.BoolVar(&opts.licenseHeaders, "license-headers", false, "With -added-files, also print how many of the files had a license header, and which licenses")
+	flag.BoolVar(&opts.printLines, "lines", false, "Print the number of lines added and removed per author, not counting imports")
+	opts.inputVar(&opts.importsFile, "imports", "File of commits whose lines aren't attributed, such as vendored code drops, in the -exclude-commits format")
+	flag.IntVar(&opts.importThreshold, "import-threshold", 0, "Treat commits changing more than this many lines as imports (0 to disable)")
+	flag.BoolVar(&opts.printImports, "imports-report", false, "Print the commits treated as imports, in the -imports format")
+	flag.BoolVar(&opts.printOutliers, "outliers", false, "Print the largest commits per author, flagging those of -outlier-lines or more")
+	flag.IntVar(&opts.outlierLines, "outlier-lines", 10000, "Number of changed lines from which -outliers flags a commit")
+	flag.BoolVar(&opts.printBlame, "blame", false, "Print the number of lines per author surviving at HEAD, according to git blame")
+	flag.StringVar(&opts.blameCache, "blame-cache", "", "Cache blame results in this file (default in the git directory)")
+	flag.BoolVar(&opts.noBlameCache, "no-blame-cache", false, "Don't cache blame results")
+	flag.IntVar(&opts.apiRate, "api-rate", 0, "Maximum number of requests per minute to each forge API host, or 0 for no limit")
+	flag.IntVar(&opts.apiRetries, "api-retries", 3, "Number of times to retry failed or rate limited API requests, with jittered backoff")
+	flag.StringVar(&opts.apiCache, "api-cache", "", "Cache API responses in this directory for conditional requests (default in the user cache directory)")
+	flag.BoolVar(&opts.noAPICache, "no-api-cache", false, "Don't cache API responses")
+	flag.BoolVar(&opts.reproducible, "reproducible", false, "Make the outputs depend on the sources only, for reproducible builds: times are relative to $SOURCE_DATE_EPOCH or the HEAD commit, and there is no color")
+	flag.BoolVar(&opts.offline, "offline", false, "Never access the network, failing if a flag needs it")
+	opts.inputVar(&opts.modulesFile, "modules", "File of \"glob module\" lines; print the contributors per module of a monorepo")
+	flag.StringVar(&opts.moduleFormat, "module-format", "names", "Print each -modules block as names or stats")
+	opts.inputVar(&opts.scopesFile, "scopes", "File listing subtrees that maintain their own AUTHORS file, checked with -check")
+	flag.BoolVar(&opts.writeScoped, "write-scopes", false, "Write the AUTHORS file in each subtree listed in -scopes")
+	flag.BoolVar(&opts.printSummary, "summary", false, "Print a one line summary of the number of contributors and the most active ones")
+	flag.IntVar(&opts.summaryCount, "summary-count", 3, "Number of contributors to name in the summary")
+	flag.StringVar(&opts.namesSep, "names-sep", ", ", "Separator between the -names")
+	flag.StringVar(&opts.namesConj, "names-and", "", "Conjunction before the last of the -names, such as \"and\"")
+	flag.BoolVar(&opts.namesOxford, "oxford-comma", true, "Keep the -names-sep before the -names-and conjunction")
+	flag.IntVar(&opts.namesWidth, "names-width", 0, "Wrap the -names at this many characters, between names")
+	flag.StringVar(&opts.namesIndent, "names-indent", "", "Start every line of -names with this, such as spaces or a comment marker")
+	flag.BoolVar(&opts.groupByLetter, "group-by-letter", false, "Group the -names, -markdown and -html lists alphabetically under letter headings")
+	flag.IntVar(&opts.maxLength, "max-length", 0, "Truncate the -names line to this many characters, on a name boundary, ending with the number of names left out")
+	flag.IntVar(&opts.top, "top", 0, "Print the names of this many most active contributors, and how many others there are")
+	flag.StringVar(&opts.summarySep, "summary-sep", ", ", "Separator between names in the summary and -top")
+	flag.StringVar(&opts.summaryConj, "summary-and", "and", "Conjunction before the last name in the summary and -top")
+	flag.BoolVar(&opts.printCredits, "credits", false, "Print people credited in commit message trailers")
+	flag.StringVar(&opts.creditTrailers, "credit-trailers", strings.Join(defaultCreditTrailers, ","), "Comma separated commit message trailers to credit in -credits")
+	flag.BoolVar(&opts.printThanks, "thanks", false, "Print a THANKS file of the people credited in -credit-trailers who aren't authors")
+	flag.BoolVar(&opts.printPairs, "pairs", false, "Print who made commits together, per Co-authored-by trailers, as a CSV edge list")
+	opts.inputVar(&opts.thanksTemplate, "thanks-template", "Go text/template file for -thanks, instead of the built in one")
+	opts.inputVar(&opts.teamFile, "team", "File of core team emails, @domains and GitHub user names, for -team-stats")
+	flag.StringVar(&opts.teamOrg, "team-org", "", "GitHub organization whose members are the core team, for -team-stats (uses $GITHUB_TOKEN)")
+	flag.BoolVar(&opts.printTeam, "team-stats", false, "Print the number of authors and commits by the core team versus the community")
+	flag.StringVar(&opts.trend, "trend", "", "Print a comparison of this period before today with the one before it, e.g. 12m")
+	flag.BoolVar(&opts.trendJSON, "trend-json", false, "Print the -trend comparison as JSON")
+	flag.BoolVar(&opts.printVelocity, "release-velocity", false, "Print released commits per author by the number of weeks before the release they went into")
+	flag.StringVar(&opts.releaseTags, "release-tags", "v*", "Glob matching the release tags, for -release-velocity and -tag-credit")
+	flag.BoolVar(&opts.printDiversity, "diversity", false, "Print how concentrated the commits are among contributors: Gini coefficient and top 10% share")
+	flag.BoolVar(&opts.printMilestones, "milestones", false, "Print commit count milestones and first commit anniversaries, recent and upcoming")
+	flag.StringVar(&opts.milestoneWindow, "milestone-window", "1m", "Period before and after today to report -milestones for, e.g. 1m or 2w")
+	flag.StringVar(&opts.badgeDir, "badges", "", "Write an SVG contributor certificate per author to this directory")
+	flag.StringVar(&opts.badgeProject, "badge-project", "", "Project name on the -badges certificates (default the repository directory name)")
+	flag.StringVar(&opts.badgeLogo, "badge-logo", "", "URL or path of a logo image for the -badges certificates")
+	opts.inputVar(&opts.templateFile, "template", "Print the authors using this Go text/template file")
+	flag.BoolVar(&opts.printJSON, "json", false, "Print the authors and statistics as JSON")
+	flag.IntVar(&opts.minContributions, "min", 1, "Minimum number of contribution to show up in lists")
+	flag.BoolVar(&opts.geekrank, "geekrank", false, "Sort contributors by geekrank")
+	flag.BoolVar(&opts.rankDaysActive, "days-active", false, "Sort contributors by the number of distinct days with commits")
+	flag.BoolVar(&opts.streaks, "streaks", false, "Add the longest streaks of consecutive days and weeks with commits to the stats output")
+	opts.inputVar(&opts.excludeHashes, "exclude-commits", "File containing commit hashes and author date ranges (2019-03-01..2019-03-05) to ignore")
+	flag.StringVar(&opts.excludePattern, "exclude-pattern", "[bot]", "Skip names containing this string")
+	flag.BoolVar(&opts.explainFilter, "explain-filter", false, "Print the authors left out of the lists, and the rule that removed each")
+	flag.BoolVar(&opts.strict, "strict", false, "Fail on ambiguous author matches and invalid -exclude-commits entries instead of guessing")
+	opts.inputVar(&opts.rewriteFile, "rewrite-emails", "File of email rewrite rules like \"*@oldcorp.com -> *@newcorp.com\", applied before matching")
+	flag.BoolVar(&opts.noNameMatching, "no-name-matching", false, "Don't merge unknown emails into an existing author with the same name")
+	flag.BoolVar(&opts.allBranches, "all-branches", false, "Count commits reachable from any branch, not just HEAD")
+	flag.Var(&opts.refs, "refs", "Count commits reachable from refs matching this glob, e.g. \"tags/*\" (repeatable)")
+	flag.BoolVar(&opts.excludeReverts, "exclude-reverts", false, "Skip reverted commits and the commits reverting them")
+	flag.BoolVar(&opts.foldFixups, "fold-fixups", false, "Count fixup! and squash! commits toward the author of the commit they target")
+	flag.StringVar(&opts.notesRef, "notes-ref", "refs/notes/contributors", "Notes ref with \"credit: Name <email>\" overrides of commit authors (empty to disable)")
+	flag.StringVar(&opts.maintenance, "maintenance", "", "Glob matching maintenance branches, such as release-*, to print who made the commits on each that aren't on -maintenance-base")
+	flag.StringVar(&opts.maintenanceBase, "maintenance-base", "HEAD", "The mainline that -maintenance branches are compared against")
+	flag.BoolVar(&opts.tagCredit, "tag-credit", false, "Credit the creators of annotated -release-tags, counting toward -min and as the release category")
+	flag.BoolVar(&opts.printDot, "dot", false, "Print the graph of authors, their emails and the names used with them, in Graphviz format")
+	flag.IntVar(&opts.wordCloud, "word-cloud", 0, "Print the n most frequent words in each author's commit subjects, without stopwords, as JSON")
+	flag.StringVar(&opts.githubBackfill, "github-backfill", "", "GitHub owner/repo to look up the pull request authors of squash merged commits in, giving them the commits made under the merger's noreply address")
+	flag.StringVar(&opts.parquetFile, "parquet", "", "Write the analyzed commits and the author each is attributed to as a Parquet file")
+	flag.StringVar(&opts.sqliteFile, "sqlite", "", "Write the authors, emails and commits to a new SQLite database at this path, using sqlite3")
+	flag.StringVar(&opts.ldap, "ldap", "", "Look up the display names and departments of the authors in the LDAP directory given as url,basedn")
+	opts.inputVar(&opts.ssoFile, "sso-map", "CSV export mapping SSO identities to commit emails, with identity, name and email columns")
+	flag.BoolVar(&opts.signingKeys, "signing-keys", false, "Merge emails that signed commits with the same GPG or SSH key into one author; slow, as git verifies every signature")
+	flag.IntVar(&opts.maxCommits, "max-commits", 0, "Analyze only the most recent n commits, such as in a pre-commit hook")
+	flag.IntVar(&opts.sample, "sample", 0, "Analyze only every nth commit, for quick estimates on huge repositories; commit counts are scaled up")
+	flag.BoolVar(&opts.sampleRandom, "sample-random", false, "Pick a random one in -sample commits instead of every nth")
+	flag.Int64Var(&opts.sampleSeed, "sample-seed", 1, "Random seed for -sample-random, for repeatable estimates")
+	flag.IntVar(&opts.parallel, "parallel", runtime.NumCPU(), "Number of goroutines for parsing commits and running blame")
+	flag.StringVar(&opts.cpuProfile, "cpuprofile", "", "Write a CPU profile to this file")
+	flag.StringVar(&opts.memProfile, "memprofile", "", "Write a memory profile to this file on exit")
+	flag.BoolVar(&opts.backup, "backup", false, "Keep the previous version of written files as file.bak")
+	flag.BoolVar(&opts.noColor, "no-color", false, "Don't color terminal output (also with $NO_COLOR set)")
+	flag.BoolVar(&opts.noPager, "no-pager", false, "Don't send terminal output through $PAGER")
+	flag.BoolVar(&opts.watch, "watch", false, "Redo the analysis and outputs whenever HEAD, a ref or an input file changes")
+	flag.DurationVar(&opts.watchInterval, "watch-interval", 2*time.Second, "How often -watch checks for changes")
+	flag.StringVar(&opts.warningsJSON, "warnings-json", "", "Also write the warnings to this file as JSON, for automation")
+	flag.BoolVar(&opts.quiet, "quiet", false, "Don't print the summary of commits and authors processed to stderr")
+	flag.StringVar(&opts.listen, "listen", "127.0.0.1:8080", "Address to listen on in the serve command; only localhost by default, as the outputs include emails")
+	flag.DurationVar(&opts.serveRefresh, "refresh", 10*time.Minute, "How often the serve command redoes the analysis (0 for never)")
+	flag.BoolVar(&opts.man, "man", false, "Print a man page and exit")
+	flag.BoolVar(&opts.metadata, "metadata", false, "End AUTHORS, YAML, Markdown and HTML output with a comment giving the time, HEAD commit and program version")
+	flag.BoolVar(&opts.versionCheck, "version-check", false, "Warn if the -metadata of an AUTHORS file says it was generated by an older, incompatible version")
+	flag.StringVar(&opts.authorsChangelog, "authors-changelog", "", "Append the changes to AUTHORS files written by -out or apply to this file")
+	flag.Var(&opts.outs, "out", "Write an output to a file, as format=file (repeatable; formats: "+strings.Join(outputNames(), ", ")+")")
+	flag.Parse()
+	opts.authorsFiles = expandGlobs(opts.authorsFiles)
+	if len(opts.authorsFiles) > 0 {
+		opts.authorsFile = opts.authorsFiles[0]
+	}
+	if opts.parallel < 1 {
+		opts.parallel = 1
+	}
+	if opts.checkJSON {
+		opts.check = true
+	}
+	if opts.summaryCount < 0 {
+		fatalf("-summary-count %d: the number of contributors must not be negative", opts.summaryCount)
+	}
+	if opts.wordCloud < 0 {
+		fatalf("-word-cloud %d: the number of words must be positive", opts.wordCloud)
+	}
+	opts.color = terminalColors(opts.noColor || opts.reproducible)
+	warningsFile = opts.warningsJSON
+	return &opts
+}
+
+// expandGlobs replaces the patterns with the files matching them, leaving
+// patterns matching nothing as they are so that they fail visibly later.
+func expandGlobs(patterns []string) []string {
+	var res []string
+	for _, p := range patterns {
+		matches, err := filepath.Glob(p)
+		if err != nil || len(matches) == 0 {
+			res = append(res, p)
+			continue
+		}
+		res = append(res, matches...)
+	}
+	return res
+}
+
+// namesFormat returns the format of the names output.
+func (opts *options) namesFormat() namesFormat {
+	return namesFormat{
+		sep:       opts.namesSep,
+		conj:      opts.namesConj,
+		oxford:    opts.namesOxford,
+		maxLength: opts.maxLength,
+		width:     opts.namesWidth,
+		indent:    opts.namesIndent,
+	}
+}
+
+// creditKeys returns the trailer keys to give credit for.
+func (opts *options) creditKeys() []string {
+	var keys []string
+	for _, key := range strings.Split(opts.creditTrailers, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func main() {
+	opts := parseFlags()
+	apiCache := opts.apiCache
+	if apiCache == "" && !opts.noAPICache {
+		apiCache = defaultAPICache()
+	}
+	httpClient.Transport = newPoliteTransport(opts.apiRate, opts.apiRetries, apiCache)
+	if opts.offline {
+		// The doctor diagnoses this instead
+		if flags := opts.networkFlags(); len(flags) > 0 && flag.Arg(0) != "doctor" {
+			fatalf("%s: not possible with -offline", strings.Join(flags, ", "))
+		}
+		goOffline()
+	}
+	if opts.man {
+		if err := writeMan(os.Stdout); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	defer flushWarnings()
+
+	if opts.cpuProfile != "" {
+		fd, err := os.Create(opts.cpuProfile)
+		if err != nil {
+			fatal(err)
+		}
+		if err := pprof.StartCPUProfile(fd); err != nil {
+			fatal(err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+	if opts.memProfile != "" {
+		defer writeMemProfile(opts.memProfile)
+	}
+
+	var a *analysis
+	switch flag.Arg(0) {
+	case "":
+		if opts.policyFile != "" {
+			checkPolicy(opts)
+			return
+		}
+		if opts.watch && os.Getenv(watchRunEnv) == "" {
+			watch(opts, opts.watchInterval)
+			return
+		}
+		if opts.watch {
+			// A run of the watch, which shares the terminal
+			opts.noPager = true
+		}
+		a = analyze(opts)
+	case "convert":
+		if flag.NArg() != 3 {
+			fatal("usage: convert <from> <to>")
+		}
+		convertAuthors(flag.Arg(1), flag.Arg(2), opts.backup)
+		return
+	case "install-hook":
+		kind := "pre-push"
+		if flag.NArg() > 1 {
+			kind = flag.Arg(1)
+		}
+		// The flags given before the command are passed on to the hook
+		flags := os.Args[1 : len(os.Args)-flag.NArg()]
+		if err := installHook(kind, flags, opts.backup); err != nil {
+			fatal(err)
+		}
+		return
+	case "version":
+		if err := writeVersion(os.Stdout); err != nil {
+			fatal(err)
+		}
+		return
+	case "doctor":
+		diags := doctor(opts)
+		if err := writeDiagnoses(os.Stdout, diags); err != nil {
+			fatal(err)
+		}
+		for _, d := range diags {
+			if d.level == diagError {
+				exit(1)
+			}
+		}
+		return
+	case "help":
+		if err := writeHelp(os.Stdout, flag.Arg(1)); err != nil {
+			fatal(err)
+		}
+		return
+	case "completion":
+		if flag.NArg() != 2 {
+			fatal("usage: completion <bash|zsh|fish>")
+		}
+		if err := writeCompletion(os.Stdout, flag.Arg(1)); err != nil {
+			fatal(err)
+		}
+		return
+	case "lint":
+		file := opts.authorsFile
+		if flag.NArg() > 1 {
+			file = flag.Arg(1)
+		}
+		if file == "" {
+			fatal("usage: lint <file>, or -read-authors")
+		}
+		findings := lintAuthors(file)
+		var err error
+		if opts.checkJSON {
+			err = writeLintJSON(os.Stdout, findings)
+		} else {
+			err = writeLintText(os.Stdout, findings)
+		}
+		if err != nil {
+			fatal(err)
+		}
+		if len(findings) > 0 {
+			exit(1)
+		}
+		return
+	case "name-conflicts":
+		files := []string(opts.authorsFiles)
+		if flag.NArg() > 1 {
+			files = expandGlobs(flag.Args()[1:])
+		}
+		if len(files) < 2 {
+			fatal("usage: name-conflicts <file> <file>..., or -read-authors")
+		}
+		conflicts := nameConflicts(files)
+		var err error
+		if opts.checkJSON {
+			err = writeConflictsJSON(os.Stdout, conflicts)
+		} else {
+			err = writeConflictsText(os.Stdout, conflicts)
+		}
+		if err != nil {
+			fatal(err)
+		}
+		if len(conflicts) > 0 {
+			exit(1)
+		}
+		return
+	case "merge-authors":
+		if flag.NArg() != 4 {
+			fatal("usage: merge-authors <base> <ours> <theirs>")
+		}
+		conflicts, err := mergeAuthorFilesInPlace(flag.Arg(1), flag.Arg(2), flag.Arg(3))
+		if err != nil {
+			fatal(err)
+		}
+		for _, c := range conflicts {
+			log.Println("Conflict:", c)
+		}
+		if len(conflicts) > 0 {
+			exit(1)
+		}
+		return
+	case "serve":
+		if err := serve(opts); err != nil {
+			fatal(err)
+		}
+		return
+	case "review":
+		if flag.NArg() != 2 || opts.authorsFile == "" {
+			fatal("usage: -read-authors <file> review <file>")
+		}
+		a := analyze(opts)
+		err := writeFileAtomic(flag.Arg(1), opts.backup, func(w io.Writer) error {
+			return writeReview(w, a, opts.authorsFile)
+		})
+		if err != nil {
+			fatal(err)
+		}
+		return
+	case "apply":
+		if flag.NArg() != 2 || opts.authorsFile == "" {
+			fatal("usage: -read-authors <file> apply <file>")
+		}
+		if err := applyReview(flag.Arg(1), opts.authorsFile, opts.authorsChangelog, opts.backup, opts.now()); err != nil {
+			fatal(err)
+		}
+		return
+	case "export":
+		if flag.NArg() != 2 {
+			fatal("usage: export <file>")
+		}
+		if err := saveState(flag.Arg(1), analyze(opts), opts.now()); err != nil {
+			fatal(err)
+		}
+		return
+	case "import":
+		if flag.NArg() != 2 {
+			fatal("usage: import <file>")
+		}
+		var err error
+		a, err = loadState(flag.Arg(1))
+		if err != nil {
+			fatal(err)
+		}
+	default:
+		fatalf("unknown command %q", flag.Arg(0))
+	}
+
+	render(a, opts)
+}
+
+// checkPolicy audits the commits in the policy range against the
+// allowlist, exiting non-zero if there are any by outside authors.
+func checkPolicy(opts *options) {
+	mustRepository()
+	var exclude excludes
+	if opts.excludeHashes != "" {
+		exclude = readExcludes(opts.excludeHashes, opts.strict)
+	}
+	commits := exclude.filter(readCommits(strings.Fields(opts.policyRange), opts.parallel))
+	outside := policyViolations(commits, readPolicy(opts.policyFile))
+	if err := writePolicyViolations(os.Stdout, outside); err != nil {
+		fatal(err)
+	}
+	if len(outside) > 0 {
+		exit(1)
+	}
+}
+
+// prepare applies the presentation options to the analysis, returning it
+// and the authors in the order they are listed.
+func prepare(a *analysis, opts *options) (*analysis, []author) {
+	if opts.suppressFile != "" {
+		a = a.suppressEmails(readSuppressed(opts.suppressFile))
+	}
+
+	// Sort by name and, optionally, rank
+	authors := a.authors
+	if err := applyNameStyle(authors, opts.nameStyle); err != nil {
+		fatal(err)
+	}
+	disambiguate(authors)
+	sortByName(authors)
+	if opts.geekrank {
+		sortByGeekrank(authors)
+	} else if opts.rankDaysActive {
+		sortByDaysActive(authors)
+	} else {
+		placePinned(authors)
+	}
+	return a, authors
+}
+
+// render prints the outputs selected by the options.
+func render(a *analysis, opts *options) {
+	a, authors := prepare(a, opts)
+
+	if opts.writeScoped {
+		writeScopes(a.scopes, opts.maxEmails, opts.backup)
+	}
+
+	if opts.check {
+		res := checkAuthors(authors, a.stale, stringSetFromStrings(a.listedEmails))
+		if len(a.scopes) > 0 {
+			// Compare with the same revision as the top level file
+			var rev string
+			if opts.authorsRef != "" {
+				rev = revision(opts.authorsRef)
+			}
+			res.Scopes = checkScopes(a.scopes, rev)
+		}
+		if opts.maxCommits > 0 {
+			res = res.withoutStale()
+		}
+		var err error
+		if opts.checkJSON {
+			err = res.writeJSON(os.Stdout)
+		} else {
+			err = res.writeText(os.Stdout)
+		}
+		if err != nil {
+			fatal(err)
+		}
+		if !res.ok() {
+			exit(1)
+		}
+	}
+
+	if opts.claFile != "" {
+		var since time.Time
+		if opts.claSince != "" {
+			var err error
+			since, err = time.Parse("2006-01-02", opts.claSince)
+			if err != nil {
+				fatal("cla-since:", err)
+			}
+		}
+		signed := readCLA(opts.claFile)
+		if missing := missingCLA(authors, signed, since); len(missing) > 0 {
+			if err := writeMissingCLA(os.Stdout, missing); err != nil {
+				fatal(err)
+			}
+			exit(1)
+		}
+	}
+
+	if opts.avatars {
+		if err := resolveAvatars(authors, opts.avatarSize, opts.avatarDir, opts.gravatar); err != nil {
+			fatal("avatars:", err)
+		}
+	}
+
+	if names := opts.stdoutOutputs(); len(names) > 0 {
+		stopPager := func() {}
+		if !opts.noPager {
+			stopPager = startPager()
+		}
+		for _, name := range names {
+			if err := writeOutput(os.Stdout, name, a, authors, opts); err != nil {
+				fatal(err)
+			}
+		}
+		stopPager()
+	}
+
+	if len(opts.outs) > 0 {
+		if err := writeOutputFiles(opts.outs, a, authors, opts); err != nil {
+			fatal(err)
+		}
+	}
+
+	if opts.badgeDir != "" {
+		project := opts.badgeProject
+		if project == "" {
+			project = projectName()
+		}
+		if err := writeBadges(opts.badgeDir, authors, project, opts.badgeLogo, opts.backup); err != nil {
+			fatal("badges:", err)
+		}
+	}
+
+	if opts.sqliteFile != "" {
+		if err := writeSQLite(opts.sqliteFile, a, authors); err != nil {
+			fatal("sqlite:", err)
+		}
+	}
+
+	if opts.parquetFile != "" {
+		err := writeFileAtomic(opts.parquetFile, opts.backup, func(w io.Writer) error {
+			return writeCommitsParquet(w, a, authors)
+		})
+		if err != nil {
+			fatal("parquet:", err)
+		}
+	}
+}
+
+// stdoutOutputs returns the names of the outputs selected by flags, in
+// the order they are printed.
+func (opts *options) stdoutOutputs() []string {
+	authorsOutput := "authors"
+	if opts.authorsFormat == "yaml" {
+		authorsOutput = "yaml"
+	} else if opts.authorsFormat != "text" {
+		fatalf("unknown authors format %q", opts.authorsFormat)
+	}
+
+	selected := []struct {
+		enabled bool
+		name    string
+	}{
+		{opts.printNames, "names"},
+		{opts.printStats, "stats"},
+		{opts.printAuthors, authorsOutput},
+		{opts.printSPDX, "spdx"},
+		{opts.printCategories, "categories"},
+		{opts.printMarkdown, "markdown"},
+		{opts.printHTML, "html"},
+		{opts.printMergeStats, "merge-stats"},
+		{opts.printAddedFiles || opts.licenseHeaders, "added-files"},
+		{opts.printLines, "lines"},
+		{opts.printImports, "imports"},
+		{opts.printOutliers, "outliers"},
+		{opts.printBlame, "blame"},
+		{opts.printSummary, "summary"},
+		{opts.top > 0, "top"},
+		{opts.printCredits, "credits"},
+		{opts.printThanks, "thanks"},
+		{opts.printPairs, "pairs"},
+		{opts.printTeam, "team"},
+		{opts.trend != "" && !opts.trendJSON, "trend"},
+		{opts.trend != "" && opts.trendJSON, "trend-json"},
+		{opts.printVelocity, "release-velocity"},
+		{opts.printDiversity, "diversity"},
+		{opts.printMilestones, "milestones"},
+		{opts.modulesFile != "", "modules"},
+		{opts.explainFilter, "explain-filter"},
+		{opts.printDot, "dot"},
+		{opts.wordCloud > 0, "word-cloud"},
+		{opts.maintenance != "", "maintenance"},
+		{opts.templateFile != "", "template"},
+		{opts.printJSON, "json"},
+	}
+	var names []string
+	for _, s := range selected {
+		if s.enabled {
+			names = append(names, s.name)
+		}
+	}
+	return names
+}
+
+func writeMemProfile(file string) {
+	fd, err := os.Create(file)
+	if err != nil {
+		fatal(err)
+	}
+	defer fd.Close()
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(fd); err != nil {
+		fatal(err)
+	}
+}
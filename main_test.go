@@ -0,0 +1,148 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runMainEnv makes the test binary run the program instead of the tests,
+// for runMain.
+const runMainEnv = "GIT_CONTRIBUTORS_RUN_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(runMainEnv) != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runMain runs the program with the arguments in the directory, and
+// returns its standard output and error and exit code.
+func runMain(t *testing.T, dir string, args ...string) (string, string, int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	for _, e := range os.Environ() {
+		if !hasEnvPrefix(e, "GIT_DIR=", "GIT_WORK_TREE=", "XDG_CACHE_HOME=", "GITHUB_TOKEN=") {
+			cmd.Env = append(cmd.Env, e)
+		}
+	}
+	// Keeps the API caches out of the user's cache directory
+	cmd.Env = append(cmd.Env, runMainEnv+"=1", "XDG_CACHE_HOME="+filepath.Join(dir, ".git", "test-cache"))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var ee *exec.ExitError
+	if err != nil && !errors.As(err, &ee) {
+		t.Fatal(err)
+	}
+	return stdout.String(), stderr.String(), cmd.ProcessState.ExitCode()
+}
+
+// mustRunMain is runMain for runs expected to succeed, returning the
+// standard output.
+func mustRunMain(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	stdout, stderr, code := runMain(t, dir, args...)
+	if code != 0 {
+		t.Fatalf("%v: exit code %d\n%s", args, code, stderr)
+	}
+	return stdout
+}
+
+// A testCommit is a commit made by commitFiles.
+type testCommit struct {
+	author  string // "Name <email>"
+	date    string // RFC 3339, or empty for the current time
+	message string
+	files   map[string]string // path to contents
+}
+
+// commitFiles writes the files of the commit in the repository and commits
+// them as the author.
+func commitFiles(t *testing.T, dir string, c testCommit) {
+	t.Helper()
+	for file, contents := range c.files {
+		path := filepath.Join(dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, dir, "add", "-A")
+	args := []string{"commit", "-q", "--allow-empty", "-m", c.message, "--author", c.author}
+	if c.date != "" {
+		args = append(args, "--date", c.date)
+	}
+	runGit(t, dir, args...)
+}
+
+// newHistoryRepo creates a repository in a new temporary directory with
+// the commits, and returns the directory and a function to remove it.
+func newHistoryRepo(t *testing.T, commits ...testCommit) (string, func()) {
+	t.Helper()
+	dir, cleanup := tempDir(t)
+	runGit(t, dir, "init", "-q")
+	for _, c := range commits {
+		commitFiles(t, dir, c)
+	}
+	return dir, cleanup
+}
+
+// writeTestFile writes the file in the directory, returning its path.
+func writeTestFile(t *testing.T, dir, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// threeAuthors is a history of one commit by Bob, then two by Alice, and
+// one by Carol, on separate files.
+var threeAuthors = []testCommit{
+	{author: "Bob B <bob@example.com>", date: "2020-01-01T12:00:00Z", message: "Add bob", files: map[string]string{"bob.txt": "bob\n"}},
+	{author: "Alice A <alice@example.com>", date: "2020-02-01T12:00:00Z", message: "Add alice", files: map[string]string{"alice.txt": "alice\n"}},
+	{author: "Alice A <alice@example.com>", date: "2020-03-01T12:00:00Z", message: "Improve alice", files: map[string]string{"alice.txt": "alice\nmore\n"}},
+	{author: "Carol C <carol@example.com>", date: "2020-04-01T12:00:00Z", message: "Add carol", files: map[string]string{"carol.txt": "carol\n"}},
+}
+
+// containsLine returns whether the output has the line.
+func containsLine(out, line string) bool {
+	for _, l := range strings.Split(out, "\n") {
+		if l == line {
+			return true
+		}
+	}
+	return false
+}
+
+func TestProfiling(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	cpu, mem := filepath.Join(dir, "cpu.prof"), filepath.Join(dir, "mem.prof")
+
+	mustRunMain(t, dir, "-cpuprofile", cpu, "-memprofile", mem, "-stats")
+	for _, file := range []string{cpu, mem} {
+		if fi, err := os.Stat(file); err != nil || fi.Size() == 0 {
+			t.Errorf("no profile in %s: %v", file, err)
+		}
+	}
+}
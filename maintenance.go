@@ -0,0 +1,92 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// A maintainedBranch is a maintenance branch with the authors of the
+// commits on it that aren't on the mainline, such as backports.
+type maintainedBranch struct {
+	name    string
+	commits int
+	authors []author
+}
+
+// maintenanceBranches returns the local branches matching the pattern, or
+// the refs if the pattern starts with "refs/".
+func maintenanceBranches(pattern string) []string {
+	if !strings.HasPrefix(pattern, "refs/") {
+		pattern = "refs/heads/" + pattern
+	}
+	cmd := exec.Command("git", "for-each-ref", "--format=%(refname:short)", pattern)
+	cmd.Stderr = os.Stderr
+	bs, err := cmd.Output()
+	if err != nil {
+		fatal("git:", err)
+	}
+	return strings.Fields(string(bs))
+}
+
+// getMaintenance counts the commits on each branch that aren't on the
+// base. Their authors need not be authors on the mainline; those who
+// aren't are added for the branch only.
+func getMaintenance(branches []string, base string, authors []author, exclude excludes, rewrites []rewriteRule, parallel int, keep func(author) bool) []maintainedBranch {
+	res := make([]maintainedBranch, len(branches))
+	for i, branch := range branches {
+		commits := readCommits([]string{branch, "--not", base}, parallel)
+		rewriteCommits(commits, rewrites)
+		commits = exclude.filter(commits)
+
+		counted := make([]author, len(authors))
+		for j, a := range authors {
+			a.commits, a.first, a.last = 0, time.Time{}, time.Time{}
+			counted[j] = a
+		}
+		idx := newAuthorIndex(counted)
+		for _, id := range allAuthors(commits) {
+			if _, ok := idx.email(id.email); !ok {
+				counted = append(counted, author{name: id.name, emails: []string{id.email}})
+				idx.add(counted, len(counted)-1)
+			}
+		}
+		getContributions(counted, idx, commits)
+
+		var kept []author
+		for _, a := range counted {
+			if a.commits > 0 && keep(a) {
+				kept = append(kept, a)
+			}
+		}
+		sortAuthors(kept, func(a author) float64 { return float64(a.commits) })
+		res[i] = maintainedBranch{name: branch, commits: len(commits), authors: kept}
+	}
+	return res
+}
+
+// writeMaintenance writes the commits per author on each maintenance
+// branch, most commits first.
+func writeMaintenance(w io.Writer, branches []maintainedBranch) error {
+	bw := bufio.NewWriter(w)
+	for i, b := range branches {
+		if i > 0 {
+			fmt.Fprintf(bw, "\n")
+		}
+		fmt.Fprintf(bw, "%s (%d commits not on the mainline)\n", b.name, b.commits)
+		for _, a := range b.authors {
+			fmt.Fprintf(bw, "%5d %s\n", a.commits, a.displayName())
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,31 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import "testing"
+
+func TestMaintenance(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	runGit(t, dir, "checkout", "-q", "-b", "release-1", "HEAD~2")
+	commitFiles(t, dir, testCommit{author: "Bob B <bob@example.com>", date: "2020-05-01T12:00:00Z", message: "Backport fix"})
+	commitFiles(t, dir, testCommit{author: "Eve E <eve@example.com>", date: "2020-05-02T12:00:00Z", message: "Fix for release only"})
+	commitFiles(t, dir, testCommit{author: "Bob B <bob@example.com>", date: "2020-05-03T12:00:00Z", message: "Another backport"})
+	runGit(t, dir, "checkout", "-q", "-")
+	// On the mainline, so with nothing of its own
+	runGit(t, dir, "branch", "release-2", "HEAD~1")
+
+	out := mustRunMain(t, dir, "-maintenance", "release-*")
+	expected := "release-1 (3 commits not on the mainline)\n" +
+		"    2 Bob B\n" +
+		"    1 Eve E\n" +
+		"\n" +
+		"release-2 (0 commits not on the mainline)\n"
+	if out != expected {
+		t.Errorf("output\n%s\nexpected\n%s", out, expected)
+	}
+}
@@ -0,0 +1,195 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// readAuthorsAnyFormat reads an AUTHORS file, deciding between the plain
+// text and YAML formats by the contents rather than the name, since git
+// gives merge drivers temporary files.
+func readAuthorsAnyFormat(file string) ([]author, bool) {
+	bs := readAll(file)
+	if isYAMLFile(file) || looksLikeYAML(bs) {
+		authors, err := parseYAMLAuthors(bs)
+		if err != nil {
+			fatalf("%s: %v", file, err)
+		}
+		return authors, true
+	}
+	return parseAuthors(bs), false
+}
+
+// looksLikeYAML returns true if the first entry is a YAML list item.
+func looksLikeYAML(bs []byte) bool {
+	for _, line := range strings.Split(string(bs), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		return strings.HasPrefix(line, "- ") || line == "-" || line == "[]"
+	}
+	return false
+}
+
+// mergeAuthors does a three-way merge of the author lists, where entries
+// are the same author if they share an email address. Changes made on
+// only one side are taken; conflicting changes are resolved in favour of
+// ours and described in the returned conflicts.
+func mergeAuthors(base, ours, theirs []author) ([]author, []string) {
+	baseIdx := newAuthorIndex(base)
+	match := func(a author) (int, bool) {
+		for _, e := range a.emails {
+			if i, ok := baseIdx.email(e); ok {
+				return i, true
+			}
+		}
+		return -1, false
+	}
+
+	oursByBase := make(map[int]author)
+	theirsByBase := make(map[int]author)
+	var oursNew, theirsNew []author
+	for _, a := range ours {
+		if i, ok := match(a); ok {
+			oursByBase[i] = a
+		} else {
+			oursNew = append(oursNew, a)
+		}
+	}
+	for _, a := range theirs {
+		if i, ok := match(a); ok {
+			theirsByBase[i] = a
+		} else {
+			theirsNew = append(theirsNew, a)
+		}
+	}
+
+	var res []author
+	var conflicts []string
+	for i, b := range base {
+		o, inOurs := oursByBase[i]
+		t, inTheirs := theirsByBase[i]
+		switch {
+		case inOurs && inTheirs:
+			m, c := mergeAuthor(b, o, t)
+			res = append(res, m)
+			conflicts = append(conflicts, c...)
+		case inOurs:
+			if !sameAuthor(b, o) {
+				conflicts = append(conflicts, fmt.Sprintf("%s: changed in ours, removed in theirs", o.name))
+				res = append(res, o)
+			}
+		case inTheirs:
+			if !sameAuthor(b, t) {
+				conflicts = append(conflicts, fmt.Sprintf("%s: removed in ours, changed in theirs", t.name))
+				res = append(res, t)
+			}
+		}
+	}
+
+	// Additions on both sides, merged when they are the same person
+	newIdx := newAuthorIndex(nil)
+	for _, a := range oursNew {
+		res = append(res, a)
+		newIdx.add(res, len(res)-1)
+	}
+	for _, a := range theirsNew {
+		j, ok := -1, false
+		for _, e := range a.emails {
+			if j, ok = newIdx.email(e); ok {
+				break
+			}
+		}
+		if !ok {
+			res = append(res, a)
+			newIdx.add(res, len(res)-1)
+			continue
+		}
+		if res[j].fullName() != a.fullName() {
+			conflicts = append(conflicts, fmt.Sprintf("%s: added as %q in ours and %q in theirs", a.emails[0], res[j].fullName(), a.fullName()))
+		}
+		res[j].emails = appendMissing(res[j].emails, a.emails...)
+		res[j].urls = appendMissing(res[j].urls, a.urls...)
+		res[j].tags = appendMissing(res[j].tags, a.tags...)
+	}
+
+	sortByName(res)
+	placePinned(res)
+	return res, conflicts
+}
+
+// mergeAuthor merges the changes to an entry made on both sides.
+func mergeAuthor(base, ours, theirs author) (author, []string) {
+	var conflicts []string
+	m := ours
+	pick := func(field, b, o, t string) string {
+		switch {
+		case o == t || t == b:
+			return o
+		case o == b:
+			return t
+		default:
+			conflicts = append(conflicts, fmt.Sprintf("%s: %s changed to %q in ours and %q in theirs", ours.name, field, o, t))
+			return o
+		}
+	}
+	m.name = pick("name", base.name, ours.name, theirs.name)
+	m.nickname = pick("nickname", base.nickname, ours.nickname, theirs.nickname)
+	m.note = pick("note", base.note, ours.note, theirs.note)
+	m.emails = mergeStrings(base.emails, ours.emails, theirs.emails)
+	m.urls = mergeStrings(base.urls, ours.urls, theirs.urls)
+	m.tags = mergeStrings(base.tags, ours.tags, theirs.tags)
+	m.nicknames = mergeStrings(base.nicknames, ours.nicknames, theirs.nicknames)
+	return m, conflicts
+}
+
+// mergeStrings merges two edits of a list: ours, minus what theirs
+// removed, plus what theirs added.
+func mergeStrings(base, ours, theirs []string) []string {
+	inBase := stringSetFromStrings(base)
+	inTheirs := stringSetFromStrings(theirs)
+	var res []string
+	for _, s := range ours {
+		if inBase.has(s) && !inTheirs.has(s) {
+			continue // removed by them
+		}
+		res = append(res, s)
+	}
+	for _, s := range theirs {
+		if !inBase.has(s) {
+			res = appendMissing(res, s)
+		}
+	}
+	return res
+}
+
+func sameAuthor(a, b author) bool {
+	return a.name == b.name && a.nickname == b.nickname && a.note == b.note &&
+		strings.Join(a.emails, " ") == strings.Join(b.emails, " ") &&
+		strings.Join(a.urls, " ") == strings.Join(b.urls, " ") &&
+		strings.Join(a.tags, " ") == strings.Join(b.tags, " ")
+}
+
+// mergeAuthorFilesInPlace is the merge driver: it merges the three
+// versions and writes the result to the ours file, in its format and with
+// its comments. It returns the conflicts, if any.
+func mergeAuthorFilesInPlace(baseFile, oursFile, theirsFile string) ([]string, error) {
+	base, _ := readAuthorsAnyFormat(baseFile)
+	ours, yamlFormat := readAuthorsAnyFormat(oursFile)
+	theirs, _ := readAuthorsAnyFormat(theirsFile)
+	comments := readAuthorComments(readAll(oursFile), yamlFormat, ours)
+
+	merged, conflicts := mergeAuthors(base, ours, theirs)
+	err := writeFileAtomic(oursFile, false, func(w io.Writer) error {
+		return writeAuthorsWithComments(w, merged, yamlFormat, comments)
+	})
+	return conflicts, err
+}
@@ -0,0 +1,111 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeAuthors(t *testing.T) {
+	base := parseAuthors([]byte("Alice A <alice@example.com>\nBob B <bob@example.com>\nCarol C <carol@example.com>\n"))
+	// Ours gives Alice a nickname and adds Dave, theirs gives Alice
+	// another email, removes Bob and adds Dave with a URL
+	ours := parseAuthors([]byte("Alice A (alice) <alice@example.com>\nBob B <bob@example.com>\nCarol C <carol@example.com>\nDave D <dave@example.com>\n"))
+	theirs := parseAuthors([]byte("Alice A <alice@example.com> <alice@example.net>\nCarol C <carol@example.com>\nDave D <dave@example.com> https://dave.example.com\n"))
+
+	merged, conflicts := mergeAuthors(base, ours, theirs)
+	if len(conflicts) != 0 {
+		t.Errorf("unexpected conflicts %q", conflicts)
+	}
+	var buf bytes.Buffer
+	if err := writeAuthors(&buf, merged, 0); err != nil {
+		t.Fatal(err)
+	}
+	expected := "Alice A (alice) <alice@example.com> <alice@example.net>\n" +
+		"Carol C <carol@example.com>\n" +
+		"Dave D <dave@example.com> https://dave.example.com\n"
+	if buf.String() != expected {
+		t.Errorf("merged\n%s\nexpected\n%s", buf.String(), expected)
+	}
+}
+
+func TestMergeAuthorsConflicts(t *testing.T) {
+	base := parseAuthors([]byte("Alice A <alice@example.com>\nBob B <bob@example.com>\n"))
+	ours := parseAuthors([]byte("Alice Ours <alice@example.com>\nBob B <bob@example.com> # maintainer\nEve E <eve@example.com>\n"))
+	theirs := parseAuthors([]byte("Alice Theirs <alice@example.com>\nEve Else <eve@example.com>\n"))
+
+	merged, conflicts := mergeAuthors(base, ours, theirs)
+	expected := []string{
+		`Alice Ours: name changed to "Alice Ours" in ours and "Alice Theirs" in theirs`,
+		"Bob B: changed in ours, removed in theirs",
+		`eve@example.com: added as "Eve E" in ours and "Eve Else" in theirs`,
+	}
+	if !reflect.DeepEqual(conflicts, expected) {
+		t.Errorf("conflicts %q, expected %q", conflicts, expected)
+	}
+	// Ours wins the conflicts
+	var names []string
+	for _, a := range merged {
+		names = append(names, a.name)
+	}
+	if expected := []string{"Alice Ours", "Bob B", "Eve E"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("merged %q, expected %q", names, expected)
+	}
+}
+
+func TestMergeAuthorsCommand(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	base := writeTestFile(t, dir, "base", "# The authors\n\nAlice A <alice@example.com>\n")
+	ours := writeTestFile(t, dir, "ours", "# The authors\n\nAlice A <alice@example.com>\nBob B <bob@example.com>\n")
+	theirs := writeTestFile(t, dir, "theirs", "# The authors\n\nAlice A <alice@example.com>\nCarol C <carol@example.com>\n")
+
+	mustRunMain(t, dir, "merge-authors", base, ours, theirs)
+	bs, err := ioutil.ReadFile(ours)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# The authors\n\nAlice A <alice@example.com>\nBob B <bob@example.com>\nCarol C <carol@example.com>\n"
+	if string(bs) != expected {
+		t.Errorf("merged\n%s\nexpected\n%s", bs, expected)
+	}
+
+	// A conflict is reported and fails the merge, keeping ours
+	writeTestFile(t, dir, "ours", "Alice Ours <alice@example.com>\n")
+	writeTestFile(t, dir, "theirs", "Alice Theirs <alice@example.com>\n")
+	_, stderr, code := runMain(t, dir, "merge-authors", base, ours, theirs)
+	if code != 1 {
+		t.Errorf("exit code %d for a conflict", code)
+	}
+	if !strings.Contains(stderr, `Conflict: Alice Ours: name changed to "Alice Ours" in ours and "Alice Theirs" in theirs`) {
+		t.Errorf("conflict not reported:\n%s", stderr)
+	}
+	if bs, err := ioutil.ReadFile(ours); err != nil {
+		t.Fatal(err)
+	} else if string(bs) != "Alice Ours <alice@example.com>\n" {
+		t.Errorf("merged\n%s", bs)
+	}
+}
+
+func TestLooksLikeYAML(t *testing.T) {
+	cases := map[string]bool{
+		"# Authors\n\n- name: Alice A\n": true,
+		"[]\n":                           true,
+		"# Authors\nAlice A <a@b.c>\n":   false,
+		"Alice - the first <a@b.c>\n":    false,
+		"":                               false,
+	}
+	for in, expected := range cases {
+		if got := looksLikeYAML([]byte(in)); got != expected {
+			t.Errorf("looksLikeYAML(%q) = %v, expected %v", in, got, expected)
+		}
+	}
+}
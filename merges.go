@@ -0,0 +1,44 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// getMergeStats counts, per author, the non-merge commits that are on the
+// mainline (pushed directly) and those that entered it through a merge.
+// Merge commits themselves are not counted.
+func getMergeStats(authors []author, idx *authorIndex, commits []commit, mainline stringSet) {
+	for _, c := range commits {
+		if len(c.parents) > 1 {
+			continue
+		}
+		i, ok := idx.email(c.email)
+		if !ok {
+			continue
+		}
+		if mainline.has(c.hash) {
+			authors[i].direct++
+		} else {
+			authors[i].merged++
+		}
+	}
+}
+
+// writeMergeStats writes the number of directly pushed and merged commits
+// per author.
+func writeMergeStats(w io.Writer, authors []author) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%6s %6s %s\n", "direct", "merged", "author")
+	for _, a := range authors {
+		fmt.Fprintf(bw, "%6d %6d %s\n", a.direct, a.merged, a.displayName())
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,177 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// buildDate may be set at build time, with -ldflags "-X
+// main.buildDate=...". Otherwise the commit time recorded by the Go
+// toolchain is used, if any.
+var buildDate string
+
+// toolVersion returns the version of this program, as recorded by the Go
+// toolchain when installed with go install, or "devel" otherwise.
+func toolVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "devel"
+}
+
+// writeVersion writes the version, VCS revision, build date and Go
+// version, as far as they are known.
+func writeVersion(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "git-contributors %s\n", toolVersion())
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return bw.Flush()
+	}
+	settings := make(map[string]string)
+	for _, s := range info.Settings {
+		settings[s.Key] = s.Value
+	}
+	if rev := settings["vcs.revision"]; rev != "" {
+		if settings["vcs.modified"] == "true" {
+			rev += " (modified)"
+		}
+		fmt.Fprintf(bw, "revision: %s\n", rev)
+	}
+	date := buildDate
+	if date == "" {
+		date = settings["vcs.time"]
+	}
+	if date != "" {
+		fmt.Fprintf(bw, "built:    %s\n", date)
+	}
+	fmt.Fprintf(bw, "go:       %s\n", info.GoVersion)
+	return bw.Flush()
+}
+
+// readMetadata returns the metadata in a file's metadata block, if any, by
+// key without the git-contributors- prefix.
+func readMetadata(bs []byte) map[string]string {
+	res := make(map[string]string)
+	for _, line := range strings.Split(string(bs), "\n") {
+		line = strings.TrimSpace(line)
+		for _, comment := range metadataComments {
+			line = strings.TrimSuffix(strings.TrimPrefix(line, strings.TrimSpace(comment[0])), strings.TrimSpace(comment[1]))
+		}
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "git-contributors-") {
+			continue
+		}
+		kv := strings.SplitN(strings.TrimPrefix(line, "git-contributors-"), ":", 2)
+		if len(kv) == 2 {
+			res[kv[0]] = strings.TrimSpace(kv[1])
+		}
+	}
+	return res
+}
+
+// compatLevel returns the compatibility level of a version like
+// "v1.2.3": the major version, times a thousand, or for v0 the minor
+// version, as any v0 minor version may break compatibility.
+func compatLevel(v string) (int, bool) {
+	if !strings.HasPrefix(v, "v") {
+		return 0, false
+	}
+	parts := strings.SplitN(v[1:], ".", 3)
+	if len(parts) < 2 {
+		return 0, false
+	}
+	major, err1 := strconv.Atoi(parts[0])
+	minor, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		return 0, false
+	}
+	if major == 0 {
+		return minor, true
+	}
+	return major * 1000, true
+}
+
+// checkMetadataVersion warns if the file's metadata block says it was
+// generated by an older, incompatible version of this program, whose
+// output may not be what this one produces. Development builds aren't
+// compared.
+func checkMetadataVersion(file string, bs []byte) {
+	generated := readMetadata(bs)["version"]
+	theirs, ok1 := compatLevel(generated)
+	ours, ok2 := compatLevel(toolVersion())
+	if ok1 && ok2 && theirs < ours {
+		warn(warning{Kind: warnVersion, File: file, Message: fmt.Sprintf("generated by git-contributors %s, which is incompatible with %s; regenerate it", generated, toolVersion())})
+	}
+}
+
+// metadataComments are the comment syntaxes of the outputs that can carry
+// a metadata block, as line prefix and suffix.
+var metadataComments = map[string][2]string{
+	"authors":  {"# ", ""},
+	"yaml":     {"# ", ""},
+	"markdown": {"<!-- ", " -->"},
+	"html":     {"<!-- ", " -->"},
+	"dot":      {"// ", ""},
+}
+
+// metadata returns the "key: value" lines describing how fresh a
+// generated file is: when it was generated, from which commit, and by
+// which version of this program.
+func metadata(now time.Time) []string {
+	head := "unknown"
+	if bs, err := exec.Command("git", "rev-parse", "HEAD").Output(); err == nil {
+		head = strings.TrimSpace(string(bs))
+	}
+	return []string{
+		"git-contributors-generated: " + now.UTC().Format(time.RFC3339),
+		"git-contributors-head: " + head,
+		"git-contributors-version: " + toolVersion(),
+	}
+}
+
+// writeMetadata writes the metadata as a trailing comment block, if the
+// output format has comments.
+func writeMetadata(w io.Writer, format string, lines []string) error {
+	comment, ok := metadataComments[format]
+	if !ok {
+		return nil
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "\n")
+	for _, line := range lines {
+		fmt.Fprintf(bw, "%s%s%s\n", comment[0], line, comment[1])
+	}
+	return bw.Flush()
+}
+
+// writeOutput writes the named output, followed by the metadata block if
+// asked for, and a note if the analysis is an estimate from a sample.
+func writeOutput(w io.Writer, name string, a *analysis, authors []author, opts *options) error {
+	if err := outputFuncs[name](w, a, authors, opts); err != nil {
+		return err
+	}
+	var lines []string
+	if a.sample > 1 {
+		lines = append(lines, "git-contributors-estimate: "+estimateNote(a.sample))
+	}
+	if opts.metadata {
+		lines = append(lines, metadata(opts.now())...)
+	}
+	if len(lines) > 0 {
+		return writeMetadata(w, name, lines)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteMetadata(t *testing.T) {
+	lines := []string{"git-contributors-head: abc123"}
+	cases := map[string]string{
+		"authors":  "\n# git-contributors-head: abc123\n",
+		"markdown": "\n<!-- git-contributors-head: abc123 -->\n",
+		"json":     "",
+	}
+	for format, expected := range cases {
+		var buf bytes.Buffer
+		if err := writeMetadata(&buf, format, lines); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != expected {
+			t.Errorf("%s: wrote %q, expected %q", format, buf.String(), expected)
+		}
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	head := revParse(t, dir, "HEAD")
+
+	out := mustRunMain(t, dir, "-metadata", "-authors")
+	if !strings.HasPrefix(out, "Alice A <alice@example.com>\n") {
+		t.Errorf("unexpected output\n%s", out)
+	}
+	for _, line := range []string{"# git-contributors-head: " + head, "# git-contributors-version: devel"} {
+		if !containsLine(out, line) {
+			t.Errorf("no %q in\n%s", line, out)
+		}
+	}
+	if !strings.Contains(out, "\n# git-contributors-generated: 20") {
+		t.Errorf("no generation time in\n%s", out)
+	}
+
+	out = mustRunMain(t, dir, "-metadata", "-markdown")
+	if !containsLine(out, "<!-- git-contributors-head: "+head+" -->") {
+		t.Errorf("no metadata in\n%s", out)
+	}
+	if out := mustRunMain(t, dir, "-metadata", "-json"); strings.Contains(out, "git-contributors-") {
+		t.Errorf("metadata in JSON\n%s", out)
+	}
+}
+
+func TestReadMetadata(t *testing.T) {
+	bs := []byte("Alice A <alice@example.com>\n\n# git-contributors-version: v1.2.3\n<!-- git-contributors-head: abc123 -->\n// git-contributors-generated: 2020-01-01T00:00:00Z\n# not-git-contributors-key: x\n")
+	md := readMetadata(bs)
+	expected := map[string]string{
+		"version":   "v1.2.3",
+		"head":      "abc123",
+		"generated": "2020-01-01T00:00:00Z",
+	}
+	if !reflect.DeepEqual(md, expected) {
+		t.Errorf("metadata %v, expected %v", md, expected)
+	}
+}
+
+func TestCompatLevel(t *testing.T) {
+	cases := []struct {
+		version  string
+		expected int
+		ok       bool
+	}{
+		{"v1.2.3", 1000, true},
+		{"v2.0.0-rc.1", 2000, true},
+		{"v0.4.1", 4, true},
+		{"v0.10.0", 10, true},
+		{"devel", 0, false},
+		{"v1", 0, false},
+	}
+	for _, c := range cases {
+		if level, ok := compatLevel(c.version); level != c.expected || ok != c.ok {
+			t.Errorf("compatLevel(%q) = %d, %v, expected %d, %v", c.version, level, ok, c.expected, c.ok)
+		}
+	}
+}
+
+func TestVersion(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	out := mustRunMain(t, dir, "version")
+	if !strings.HasPrefix(out, "git-contributors devel\n") || !strings.Contains(out, "\ngo:       go") {
+		t.Errorf("unexpected output\n%s", out)
+	}
+
+	// A development build doesn't compare versions
+	authors := writeTestFile(t, dir, "AUTHORS", "Alice A <alice@example.com>\n\n# git-contributors-version: v0.1.0\n")
+	runGit(t, dir, "init", "-q")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "Initial")
+	if _, stderr, code := runMain(t, dir, "-read-authors", authors, "-version-check", "-stats"); code != 0 || strings.Contains(stderr, "incompatible") {
+		t.Errorf("exit code %d\n%s", code, stderr)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// commitMilestones are the commit counts worth celebrating, beyond which
+// every thousandth commit is one.
+var commitMilestones = []int{1, 10, 50, 100, 250, 500}
+
+// anniversaryYears are the anniversaries of the first commit worth
+// celebrating, beyond which every fifth year is one.
+var anniversaryYears = []int{1, 5}
+
+// A milestone is a commit count or anniversary reached by an author.
+type milestone struct {
+	date time.Time
+	name string // author display name
+	what string // "100th commit", "5 year anniversary"
+}
+
+func isCommitMilestone(n int) bool {
+	for _, m := range commitMilestones {
+		if n == m {
+			return true
+		}
+	}
+	return n%1000 == 0
+}
+
+func isAnniversary(years int) bool {
+	for _, y := range anniversaryYears {
+		if years == y {
+			return true
+		}
+	}
+	return years%5 == 0
+}
+
+// getMilestones returns the milestones reached within the window before
+// now, and the anniversaries coming up within the window after now,
+// oldest first.
+func getMilestones(authors []author, idx *authorIndex, commits []commit, window string, now time.Time) ([]milestone, error) {
+	since, err := spanBefore(now, window)
+	if err != nil {
+		return nil, err
+	}
+	until := now.Add(now.Sub(since))
+
+	var res []milestone
+
+	// Commits are newest first, so we count from the end
+	counts := make([]int, len(authors))
+	for i := len(commits) - 1; i >= 0; i-- {
+		c := commits[i]
+		ai, ok := idx.email(c.email)
+		if !ok {
+			continue
+		}
+		counts[ai]++
+		if isCommitMilestone(counts[ai]) && !c.date.Before(since) && !c.date.After(now) {
+			res = append(res, milestone{c.date, authors[ai].displayName(), ordinal(counts[ai]) + " commit"})
+		}
+	}
+
+	for _, a := range authors {
+		if a.first.IsZero() {
+			continue
+		}
+		for years := 1; ; years++ {
+			date := a.first.AddDate(years, 0, 0)
+			if date.After(until) {
+				break
+			}
+			if isAnniversary(years) && !date.Before(since) {
+				res = append(res, milestone{date, a.displayName(), fmt.Sprintf("%d year anniversary", years)})
+			}
+		}
+	}
+
+	sort.SliceStable(res, func(a, b int) bool {
+		if !res[a].date.Equal(res[b].date) {
+			return res[a].date.Before(res[b].date)
+		}
+		if res[a].name != res[b].name {
+			return res[a].name < res[b].name
+		}
+		return res[a].what < res[b].what
+	})
+	return res, nil
+}
+
+// ordinal returns the number with its English ordinal suffix, like "2nd".
+func ordinal(n int) string {
+	suffix := "th"
+	switch n % 10 {
+	case 1:
+		suffix = "st"
+	case 2:
+		suffix = "nd"
+	case 3:
+		suffix = "rd"
+	}
+	if n%100 >= 11 && n%100 <= 13 {
+		suffix = "th"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// writeMilestones writes the milestones, marking those in the future as
+// upcoming.
+func writeMilestones(w io.Writer, milestones []milestone, now time.Time) error {
+	bw := bufio.NewWriter(w)
+	for _, m := range milestones {
+		fmt.Fprintf(bw, "%s %s: %s", m.date.Format("2006-01-02"), m.name, m.what)
+		if m.date.After(now) {
+			fmt.Fprintf(bw, " (upcoming)")
+		}
+		fmt.Fprintf(bw, "\n")
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,58 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestOrdinal(t *testing.T) {
+	cases := map[int]string{1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 13: "13th", 21: "21st", 101: "101st", 111: "111th", 1000: "1000th"}
+	for n, expected := range cases {
+		if got := ordinal(n); got != expected {
+			t.Errorf("ordinal(%d) = %q, expected %q", n, got, expected)
+		}
+	}
+}
+
+func TestMilestones(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	authors := []author{
+		{name: "Alice A", emails: []string{"alice@example.com"}, first: day("2015-06-10")},
+		{name: "Bob B", emails: []string{"bob@example.com"}, first: day("2020-05-20")},
+	}
+	// Alice reaches 10 commits in the window, and Bob makes a first one
+	var commits []commit
+	commits = append(commits, commit{email: "bob@example.com", date: day("2020-05-20")})
+	for i := 0; i < 10; i++ {
+		commits = append(commits, commit{email: "alice@example.com", date: day("2020-05-15").AddDate(0, 0, -i)})
+	}
+	commits = append(commits, commit{email: "alice@example.com", date: day("2015-06-10")})
+
+	ms, err := getMilestones(authors, newAuthorIndex(authors), commits, "1m", day("2020-06-01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := writeMilestones(&buf, ms, day("2020-06-01")); err != nil {
+		t.Fatal(err)
+	}
+	expected := "2020-05-14 Alice A: 10th commit\n" +
+		"2020-05-20 Bob B: 1st commit\n" +
+		"2020-06-10 Alice A: 5 year anniversary (upcoming)\n"
+	if buf.String() != expected {
+		t.Errorf("milestones\n%s\nexpected\n%s", buf.String(), expected)
+	}
+}
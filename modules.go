@@ -0,0 +1,168 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// A module is a named part of a monorepo, made up of the files matching
+// some globs, with the authors of the commits touching them.
+type module struct {
+	name    string
+	authors []author
+}
+
+// A moduleGlob maps the files matching the glob to the module.
+type moduleGlob struct {
+	glob   string
+	module string
+}
+
+// readModules reads the module mapping, lines of a glob and a module
+// name, like "lib/** core". Globs are relative to the top of the
+// repository, and ** matches any number of directories.
+func readModules(file string) []moduleGlob {
+	var globs []moduleGlob
+	for _, line := range readLines(file) {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			fatalf("%s: %s: not of the form \"glob module\"", file, line)
+		}
+		if _, err := path.Match(strings.Replace(fields[0], "**", "*", -1), ""); err != nil {
+			fatalf("%s: %s: %v", file, line, err)
+		}
+		globs = append(globs, moduleGlob{glob: fields[0], module: fields[1]})
+	}
+	return globs
+}
+
+// moduleOf returns the module of the first glob the file matches, if any.
+func moduleOf(globs []moduleGlob, file string) (string, bool) {
+	for _, g := range globs {
+		if globMatch(g.glob, file) {
+			return g.module, true
+		}
+	}
+	return "", false
+}
+
+// globMatch matches the slash separated name against the pattern, where
+// a ** segment matches any number of path segments.
+func globMatch(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(ps, ns []string) bool {
+	for len(ps) > 0 {
+		if ps[0] == "**" {
+			for i := len(ns); i >= 0; i-- {
+				if matchSegments(ps[1:], ns[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(ns) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(ps[0], ns[0]); !ok {
+			return false
+		}
+		ps, ns = ps[1:], ns[1:]
+	}
+	return len(ns) == 0
+}
+
+// changedFiles returns a map from commit hash to the files changed in
+// that commit, relative to the top of the repository, for the given
+// revisions.
+func changedFiles(revs []string) map[string][]string {
+	return logPaths(revs)
+}
+
+// getModules returns the modules, in the order first mentioned in the
+// mapping, with the authors of the commits touching each. As for scopes,
+// the authors are the fully merged set with commits counted anew per
+// module, and the keep function decides which make it into the lists.
+func getModules(globs []moduleGlob, revs []string, authors []author, idx *authorIndex, commits []commit, keep func(author) bool) []module {
+	var names []string
+	byModule := make(map[string][]commit)
+	for _, g := range globs {
+		if _, ok := byModule[g.module]; !ok {
+			names = append(names, g.module)
+			byModule[g.module] = nil
+		}
+	}
+
+	files := changedFiles(revs)
+	for _, c := range commits {
+		touched := make(stringSet)
+		for _, f := range files[c.hash] {
+			if m, ok := moduleOf(globs, f); ok && !touched.has(m) {
+				touched.add(m)
+				byModule[m] = append(byModule[m], c)
+			}
+		}
+	}
+
+	modules := make([]module, len(names))
+	for i, name := range names {
+		counted := make([]author, len(authors))
+		for j, a := range authors {
+			a.commits, a.first, a.last = 0, time.Time{}, time.Time{}
+			counted[j] = a
+		}
+		getContributions(counted, idx, byModule[name])
+
+		var kept []author
+		for _, a := range counted {
+			if a.commits > 0 && keep(a) {
+				kept = append(kept, a)
+			}
+		}
+		sortByName(kept)
+		modules[i] = module{name: name, authors: kept}
+	}
+	return modules
+}
+
+// writeModules writes a block per module: the name and number of
+// contributors, then either their names or, if stats is set, their
+// commit counts in the module.
+func writeModules(w io.Writer, modules []module, stats bool) error {
+	bw := bufio.NewWriter(w)
+	for i, m := range modules {
+		if i > 0 {
+			fmt.Fprintf(bw, "\n")
+		}
+		fmt.Fprintf(bw, "%s (%d contributors)\n", m.name, len(m.authors))
+		if len(m.authors) == 0 {
+			continue
+		}
+		if !stats {
+			var names []string
+			for _, a := range m.authors {
+				names = append(names, a.displayName())
+			}
+			fmt.Fprintf(bw, "%s\n", strings.Join(names, ", "))
+			continue
+		}
+		sorted := make([]author, len(m.authors))
+		copy(sorted, m.authors)
+		sortAuthors(sorted, func(a author) float64 { return float64(a.commits) })
+		for _, a := range sorted {
+			fmt.Fprintf(bw, "%5d %s\n", a.commits, a.displayName())
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGlobMatch(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		expected      bool
+	}{
+		{"lib/**", "lib/a.go", true},
+		{"lib/**", "lib/x/y/a.go", true},
+		{"lib/**", "lib", true},
+		{"lib/**", "cmd/lib/a.go", false},
+		{"**/*.md", "README.md", true},
+		{"**/*.md", "docs/x/guide.md", true},
+		{"**/*.md", "docs/guide.txt", false},
+		{"cmd/*/main.go", "cmd/tool/main.go", true},
+		{"cmd/*/main.go", "cmd/tool/sub/main.go", false},
+		{"lib/**/test/*", "lib/test/a", true},
+		{"lib/**/test/*", "lib/x/y/test/a", true},
+	}
+	for _, c := range cases {
+		if got := globMatch(c.pattern, c.name); got != c.expected {
+			t.Errorf("globMatch(%q, %q) = %v, expected %v", c.pattern, c.name, got, c.expected)
+		}
+	}
+}
+
+func TestModules(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Alice A <alice@example.com>", message: "Core", files: map[string]string{"lib/core/a.go": "a"}},
+		testCommit{author: "Alice A <alice@example.com>", message: "More core", files: map[string]string{"lib/core/a.go": "b", "lib/README.md": "core"}},
+		testCommit{author: "Bob B <bob@example.com>", message: "Tool", files: map[string]string{"cmd/tool/main.go": "main"}},
+		testCommit{author: "Carol C <carol@example.com>", message: "Docs", files: map[string]string{"docs/guide.md": "guide", "my file.md": "quoted"}},
+	)
+	defer cleanup()
+	// The first matching glob decides, so lib/README.md is core
+	modules := writeTestFile(t, dir, "modules", "# Modules\nlib/** core\ncmd/* cli\ncmd/** cli\n**/*.md docs\nweb/** web\n")
+
+	out := mustRunMain(t, dir, "-modules", modules)
+	expected := "core (1 contributors)\nAlice A\n\ncli (1 contributors)\nBob B\n\ndocs (1 contributors)\nCarol C\n\nweb (0 contributors)\n"
+	if out != expected {
+		t.Errorf("unexpected output\n%s\nexpected\n%s", out, expected)
+	}
+	out = mustRunMain(t, dir, "-modules", modules, "-module-format", "stats")
+	if !strings.HasPrefix(out, "core (1 contributors)\n    2 Alice A\n\n") {
+		t.Errorf("unexpected stats output\n%s", out)
+	}
+
+	bad := writeTestFile(t, dir, "bad", "lib/[ core\n")
+	if _, stderr, code := runMain(t, dir, "-modules", bad); code == 0 || !strings.Contains(stderr, "syntax error in pattern") {
+		t.Errorf("exit code %d for a bad glob\n%s", code, stderr)
+	}
+}
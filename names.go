@@ -0,0 +1,100 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// surnameParticles are the lower case words that belong to the surname
+// following them, as in "Ludwig van Beethoven".
+var surnameParticles = stringSetFromStrings([]string{
+	"al", "bin", "da", "das", "de", "del", "della", "den", "der", "di",
+	"do", "dos", "du", "la", "le", "st.", "ten", "ter", "van", "von",
+})
+
+// shortName abbreviates the given names, keeping the surname, so that
+// "Jane Quinn Doe" becomes "J. Q. Doe", "Jean-Luc Picard" becomes
+// "J.-L. Picard" and "Ludwig van Beethoven" becomes "L. van Beethoven".
+// Mononyms are returned as is.
+func shortName(name string) string {
+	words := strings.Fields(name)
+	if len(words) < 2 {
+		return name
+	}
+
+	surname := len(words) - 1
+	for surname > 1 && surnameParticles.has(words[surname-1]) {
+		surname--
+	}
+
+	parts := make([]string, 0, len(words))
+	for _, word := range words[:surname] {
+		parts = append(parts, abbreviate(word))
+	}
+	parts = append(parts, words[surname:]...)
+	return strings.Join(parts, " ")
+}
+
+// abbreviate returns the initial of each hyphenated part of the word, as
+// in "J.-L." for "Jean-Luc".
+func abbreviate(word string) string {
+	pieces := strings.Split(word, "-")
+	for i, p := range pieces {
+		r, _ := utf8.DecodeRuneInString(p)
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		pieces[i] = string(unicode.ToUpper(r)) + "."
+	}
+	return strings.Join(pieces, "-")
+}
+
+// initials returns the upper cased first letter of each word, or
+// hyphenated part of a word, in the name.
+func initials(name string) string {
+	var b strings.Builder
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '-' || unicode.IsSpace(r)
+	})
+	for _, word := range words {
+		r, _ := utf8.DecodeRuneInString(word)
+		if unicode.IsLetter(r) {
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
+
+// applyNameStyle sets the names shown in the lists of names to the given
+// style: "full", leaving them as is, "short" or "initials". Nicknames are
+// dropped from the shortened forms. The names themselves are kept, for the
+// AUTHORS file and the exports.
+func applyNameStyle(authors []author, style string) error {
+	var fn func(string) string
+	switch style {
+	case "full":
+		return nil
+	case "short":
+		fn = shortName
+	case "initials":
+		fn = initials
+	default:
+		return fmt.Errorf("unknown name style %q", style)
+	}
+	for i := range authors {
+		short := fn(authors[i].name)
+		if short == "" {
+			short = authors[i].name
+		}
+		authors[i].styledName = short
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import "testing"
+
+func TestShortName(t *testing.T) {
+	cases := map[string]string{
+		"Jane Quinn Doe":       "J. Q. Doe",
+		"Jean-Luc Picard":      "J.-L. Picard",
+		"Ludwig van Beethoven": "L. van Beethoven",
+		"Juan de la Cruz":      "J. de la Cruz",
+		"van Gogh":             "V. Gogh",
+		"Madonna":              "Madonna",
+		"  Jane   Doe ":        "J. Doe",
+		"jane doe":             "J. doe",
+		"Åsa Öberg":            "Å. Öberg",
+		"3rd Party":            "3rd Party",
+	}
+	for name, expected := range cases {
+		if got := shortName(name); got != expected {
+			t.Errorf("shortName(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
+
+func TestNameStyle(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Jane Quinn Doe <jane@example.com>", message: "One"},
+		testCommit{author: "Ludwig van Beethoven <ludwig@example.com>", message: "Two"},
+		testCommit{author: "42 <answer@example.com>", message: "Three"},
+	)
+	defer cleanup()
+
+	if out := mustRunMain(t, dir, "-names", "-name-style", "short"); out != "42, J. Q. Doe, L. van Beethoven\n" {
+		t.Errorf("unexpected short names\n%s", out)
+	}
+	// Without any initials the name is kept
+	if out := mustRunMain(t, dir, "-names", "-name-style", "initials"); out != "42, JQD, LVB\n" {
+		t.Errorf("unexpected initials\n%s", out)
+	}
+	// The AUTHORS list keeps the full names
+	if out := mustRunMain(t, dir, "-authors", "-name-style", "short"); !containsLine(out, "Jane Quinn Doe <jane@example.com>") {
+		t.Errorf("unexpected authors\n%s", out)
+	}
+	if _, _, code := runMain(t, dir, "-names", "-name-style", "nicknames"); code == 0 {
+		t.Error("no failure for an unknown name style")
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// nicknameCandidateRe matches what we accept as a derived nickname: a
+// handle, not an address fragment.
+var nicknameCandidateRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]{2,19}$`)
+
+// genericLocalParts are email local parts that say nothing about the
+// person.
+var genericLocalParts = stringSetFromStrings([]string{
+	"admin", "contact", "dev", "developer", "git", "github", "hello", "info",
+	"mail", "me", "noreply", "no-reply", "root", "user", "webmaster",
+})
+
+// forgeHosts are the sites whose profile URLs name the user.
+var forgeHosts = stringSetFromStrings([]string{
+	"github.com", "gitlab.com", "codeberg.org", "bitbucket.org",
+})
+
+// deriveNickname returns a nickname for the author, and where it came
+// from, when there is one we're confident about. Forge user names, from
+// GitHub noreply addresses and profile URLs, are trusted unless they are
+// the name itself. The local part of a freemail address is used only if
+// it isn't generic or like the author's name, and all such addresses
+// agree.
+func deriveNickname(a author) (string, string) {
+	isName := func(user string) bool {
+		return strings.EqualFold(user, strings.Join(strings.Fields(a.name), ""))
+	}
+	for _, e := range a.emails {
+		if user := githubUsername(e); nicknameCandidateRe.MatchString(user) && !isName(user) {
+			return user, e
+		}
+	}
+	for _, u := range a.urls {
+		if user := forgeUsername(u); nicknameCandidateRe.MatchString(user) && !isName(user) {
+			return user, u
+		}
+	}
+
+	var nick, from string
+	for _, e := range a.emails {
+		if !freemailDomains.has(emailDomain(e)) {
+			continue
+		}
+		local := strings.ToLower(e[:strings.LastIndexByte(e, '@')])
+		if !nicknameCandidateRe.MatchString(local) || genericLocalParts.has(local) || isNameLike(local, a.name) {
+			continue
+		}
+		if nick != "" && nick != local {
+			return "", "" // ambiguous
+		}
+		nick, from = local, e
+	}
+	return nick, from
+}
+
+// forgeUsername returns the user name from a forge profile URL like
+// https://github.com/jdoe, or the empty string if the URL isn't one.
+func forgeUsername(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || !forgeHosts.has(strings.ToLower(strings.TrimPrefix(u.Host, "www."))) {
+		return ""
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 1 {
+		return ""
+	}
+	return parts[0]
+}
+
+// isNameLike returns true if the local part is made up of the name, like
+// "janedoe", "jane_doe" or "jdoe" for Jane Doe, which makes it a poor
+// nickname.
+func isNameLike(local, name string) bool {
+	words := strings.Fields(strings.ToLower(name))
+	if len(words) == 0 {
+		return false
+	}
+	squashed := strings.NewReplacer("-", "", "_", "", ".", "").Replace(local)
+	if squashed == strings.Join(words, "") {
+		return true
+	}
+	for _, w := range words {
+		if squashed == w {
+			return true
+		}
+	}
+	last := words[len(words)-1]
+	return squashed == words[0][:1]+last || squashed == words[0]+last[:1]
+}
+
+// deriveNicknames sets derived nicknames on the authors without one.
+func deriveNicknames(authors []author) {
+	for i, a := range authors {
+		if a.nickname != "" {
+			continue
+		}
+		if nick, from := deriveNickname(a); nick != "" {
+			log.Printf("Nickname %s for %s, from %s", nick, a.name, from)
+			authors[i].nickname = nick
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDeriveNickname(t *testing.T) {
+	cases := []struct {
+		author   author
+		expected string
+	}{
+		{author{name: "Jane Doe", emails: []string{"123+jd-hacks@users.noreply.github.com"}}, "jd-hacks"},
+		{author{name: "Jane Doe", urls: []string{"https://www.GitHub.com/jdh"}}, "jdh"},
+		{author{name: "Jane Doe", urls: []string{"https://gitlab.com/group/jdh"}}, ""},
+		{author{name: "Jane Doe", emails: []string{"janedoe@users.noreply.github.com"}}, ""},
+		{author{name: "Jane Doe", emails: []string{"stardust@gmail.com"}}, "stardust"},
+		{author{name: "Jane Doe", emails: []string{"stardust@gmail.com", "stardust@outlook.com"}}, "stardust"},
+		{author{name: "Jane Doe", emails: []string{"stardust@gmail.com", "moonbeam@outlook.com"}}, ""},
+		{author{name: "Jane Doe", emails: []string{"stardust@example.com"}}, ""},
+		{author{name: "Jane Doe", emails: []string{"info@gmail.com"}}, ""},
+		{author{name: "Jane Doe", emails: []string{"jane.doe@gmail.com", "jdoe@gmail.com", "janed@gmail.com", "doe@gmail.com"}}, ""},
+		{author{name: "Jane Doe", emails: []string{"sd@gmail.com"}}, ""},
+	}
+	for _, c := range cases {
+		if got, _ := deriveNickname(c.author); got != c.expected {
+			t.Errorf("deriveNickname(%+v) = %q, expected %q", c.author, got, c.expected)
+		}
+	}
+}
+
+func TestDeriveNicknames(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Alice A <stardust@gmail.com>", date: "2020-01-01T12:00:00Z", message: "Alice"},
+		testCommit{author: "Bob B <bob@example.com>", date: "2020-02-01T12:00:00Z", message: "Bob"},
+	)
+	defer cleanup()
+	authors := writeTestFile(t, dir, "AUTHORS", "Alice A <stardust@gmail.com>\nBob B (bobby) <bob@example.com>\n")
+
+	out := mustRunMain(t, dir, "-read-authors", authors, "-derive-nicknames", "-json")
+	var res []struct {
+		Name     string `json:"name"`
+		Nickname string `json:"nickname"`
+	}
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatal(err)
+	}
+	nicks := make(map[string]string)
+	for _, r := range res {
+		nicks[r.Name] = r.Nickname
+	}
+	if nicks["Alice A"] != "stardust" || nicks["Bob B"] != "bobby" {
+		t.Errorf("nicknames %v", nicks)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// creditNoteRe matches a "credit: Name <email>" line in a note.
+var creditNoteRe = regexp.MustCompile(`(?im)^\s*credit:\s*(.*?)\s*<([^>\s]+)>\s*$`)
+
+// creditNotes returns the identities credited in the notes under the ref,
+// by commit hash. A note like "credit: Jane Doe <jane@example.com>"
+// attributes the commit to Jane instead of its author.
+func creditNotes(ref string) map[string]identity {
+	cmd := exec.Command("git", "notes", "--ref="+ref, "list")
+	cmd.Stderr = os.Stderr
+	bs, err := cmd.Output()
+	if err != nil {
+		fatal("git:", err)
+	}
+
+	// Each line is "<note blob> <annotated commit>"
+	var blobs, hashes []string
+	for _, line := range strings.Split(string(bs), "\n") {
+		if f := strings.Fields(line); len(f) == 2 {
+			blobs = append(blobs, f[0])
+			hashes = append(hashes, f[1])
+		}
+	}
+	res := make(map[string]identity)
+	if len(blobs) == 0 {
+		return res
+	}
+
+	cmd = exec.Command("git", "cat-file", "--batch")
+	cmd.Stdin = strings.NewReader(strings.Join(blobs, "\n") + "\n")
+	cmd.Stderr = os.Stderr
+	bs, err = cmd.Output()
+	if err != nil {
+		fatal("git:", err)
+	}
+	br := bufio.NewReader(bytes.NewReader(bs))
+	for _, hash := range hashes {
+		// "<blob> blob <size>\n<contents>\n"
+		header, err := br.ReadString('\n')
+		if err != nil {
+			fatal("git: reading notes:", err)
+		}
+		f := strings.Fields(header)
+		size, err := strconv.Atoi(f[len(f)-1])
+		if err != nil {
+			fatal("git: reading notes:", header)
+		}
+		note := make([]byte, size+1)
+		if _, err := io.ReadFull(br, note); err != nil {
+			fatal("git: reading notes:", err)
+		}
+		if m := creditNoteRe.FindStringSubmatch(string(note)); m != nil {
+			res[hash] = identity{email: m[2], name: m[1]}
+		}
+	}
+	return res
+}
+
+// applyCreditNotes returns the commits with the authors replaced by those
+// credited in the notes.
+func applyCreditNotes(commits []commit, credits map[string]identity) []commit {
+	if len(credits) == 0 {
+		return commits
+	}
+	res := make([]commit, len(commits))
+	copy(res, commits)
+	for i, c := range res {
+		if id, ok := credits[c.hash]; ok {
+			res[i].email, res[i].name = id.email, id.name
+		}
+	}
+	return res
+}
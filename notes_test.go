@@ -0,0 +1,48 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyCreditNotes(t *testing.T) {
+	commits := []commit{
+		{hash: "aaa", email: "bot@example.com", name: "Bot"},
+		{hash: "bbb", email: "bob@example.com", name: "Bob B"},
+	}
+	res := applyCreditNotes(commits, map[string]identity{"aaa": {email: "alice@example.com", name: "Alice A"}})
+	expected := []commit{
+		{hash: "aaa", email: "alice@example.com", name: "Alice A"},
+		{hash: "bbb", email: "bob@example.com", name: "Bob B"},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("applied %+v, expected %+v", res, expected)
+	}
+	if commits[0].email != "bot@example.com" {
+		t.Error("the commits were modified in place")
+	}
+}
+
+func TestCreditNotes(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	// Carol's commit was really Alice's, and Bob's note is not a credit
+	runGit(t, dir, "notes", "--ref=refs/notes/contributors", "add", "-m", "Paired on this.\n\n  Credit: Alice A <alice@example.com>", "HEAD")
+	runGit(t, dir, "notes", "--ref=refs/notes/contributors", "add", "-m", "Reviewed by Carol", "HEAD~3")
+
+	out := mustRunMain(t, dir, "-stats")
+	if out != "    3  1 Alice A\n    1  0 Bob B\n" {
+		t.Errorf("unexpected output\n%s", out)
+	}
+
+	out = mustRunMain(t, dir, "-notes-ref", "", "-stats")
+	if !containsLine(out, "    1  0 Carol C") {
+		t.Errorf("unexpected output without notes\n%s", out)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"os"
+)
+
+var errOffline = errors.New("network access is disabled by -offline")
+
+// offlineTransport fails every request, as a guarantee that nothing
+// reaches the network.
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, errOffline
+}
+
+// networkFlags returns the flags set that can't work without network
+// access.
+func (opts *options) networkFlags() []string {
+	var res []string
+	for _, f := range []struct {
+		set  bool
+		name string
+	}{
+		{opts.teamOrg != "", "-team-org"},
+		{opts.githubBackfill != "", "-github-backfill"},
+		{opts.ldap != "", "-ldap"},
+	} {
+		if f.set {
+			res = append(res, f.name)
+		}
+	}
+	return res
+}
+
+// goOffline makes sure that neither we nor git access the network. Avatars
+// already in the -avatar-dir still work; the others are linked remotely.
+func goOffline() {
+	httpClient.Transport = offlineTransport{}
+	// Partial clones would otherwise fetch missing objects on demand
+	os.Setenv("GIT_NO_LAZY_FETCH", "1")
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNetworkFlags(t *testing.T) {
+	if flags := (&options{}).networkFlags(); len(flags) != 0 {
+		t.Errorf("network flags %q by default", flags)
+	}
+	opts := &options{teamOrg: "example", ldap: "ldap.example.com"}
+	if flags := opts.networkFlags(); !reflect.DeepEqual(flags, []string{"-team-org", "-ldap"}) {
+		t.Errorf("network flags %q", flags)
+	}
+}
+
+func TestOffline(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "J Doe <1234+jdoe@users.noreply.github.com>", date: "2020-01-01T12:00:00Z", message: "One"})
+	defer cleanup()
+	avatars := filepath.Join(dir, ".git", "avatars")
+
+	if _, stderr, code := runMain(t, dir, "-offline", "-team-org", "example", "-stats"); code == 0 || !strings.Contains(stderr, "-team-org: not possible with -offline") {
+		t.Errorf("exit code %d for -team-org\n%s", code, stderr)
+	}
+	stdout, _, code := runMain(t, dir, "-offline", "-team-org", "example", "doctor")
+	if code != 1 || !containsLine(stdout, "error    -offline: -team-org not possible without network access") {
+		t.Errorf("exit code %d from the doctor\n%s", code, stdout)
+	}
+
+	// Avatars not yet downloaded are linked remotely
+	out := mustRunMain(t, dir, "-offline", "-markdown", "-avatars", "-avatar-dir", avatars)
+	if !strings.Contains(out, `<img src="https://github.com/jdoe.png?size=40"`) {
+		t.Errorf("unexpected output\n%s", out)
+	}
+	id := (author{emails: []string{"1234+jdoe@users.noreply.github.com"}}).id()
+	if err := os.MkdirAll(avatars, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, avatars, id+"-40.png", "image")
+	out = mustRunMain(t, dir, "-offline", "-markdown", "-avatars", "-avatar-dir", avatars)
+	if !strings.Contains(out, `<img src="`+filepath.ToSlash(filepath.Join(avatars, id+"-40.png"))+`"`) {
+		t.Errorf("cached avatar not used\n%s", out)
+	}
+}
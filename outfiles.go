@@ -0,0 +1,172 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// An outputFile is an output format to be written to a file.
+type outputFile struct {
+	format string
+	file   string
+	tmp    string
+}
+
+// parseOutputFiles parses "format=file" specifications.
+func parseOutputFiles(specs []string) ([]outputFile, error) {
+	outs := make([]outputFile, len(specs))
+	for i, spec := range specs {
+		eq := strings.IndexByte(spec, '=')
+		if eq <= 0 || eq == len(spec)-1 {
+			return nil, fmt.Errorf("malformed output %q, expected format=file", spec)
+		}
+		format, file := spec[:eq], spec[eq+1:]
+		if _, ok := outputFuncs[format]; !ok {
+			return nil, fmt.Errorf("unknown output format %q", format)
+		}
+		outs[i] = outputFile{format: format, file: file}
+	}
+	return outs, nil
+}
+
+// writeOutputFiles renders all the given outputs to temporary files, and
+// only when all of them have succeeded, renames them into place. Thus
+// either all the files are updated from the same analysis, or none.
+func writeOutputFiles(specs []string, a *analysis, authors []author, opts *options) error {
+	outs, err := parseOutputFiles(specs)
+	if err != nil {
+		return err
+	}
+
+	cleanup := func() {
+		for _, out := range outs {
+			if out.tmp != "" {
+				os.Remove(out.tmp)
+			}
+		}
+	}
+
+	for i := range outs {
+		out := &outs[i]
+		fd, err := createTemp(out.file)
+		if err != nil {
+			cleanup()
+			return err
+		}
+		out.tmp = fd.Name()
+		err = writeOutput(fd, out.format, a, authors, opts)
+		if cerr := syncClose(fd); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			cleanup()
+			return fmt.Errorf("%s: %w", out.file, err)
+		}
+	}
+
+	// The previous AUTHORS lists, for the changelog
+	old := make(map[string][]author)
+	if opts.authorsChangelog != "" {
+		for _, out := range outs {
+			if out.format == "authors" || out.format == "yaml" {
+				old[out.file] = readAuthorsIfExists(out.file)
+			}
+		}
+	}
+
+	for i := range outs {
+		if err := replaceFile(outs[i].tmp, outs[i].file, opts.backup); err != nil {
+			cleanup()
+			return err
+		}
+		outs[i].tmp = ""
+	}
+
+	for _, out := range outs {
+		if prev, ok := old[out.file]; ok {
+			if err := appendChangelog(opts.authorsChangelog, out.file, prev, getAuthors(out.file), opts.now()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic writes the file by way of a temporary file that is
+// renamed into place, so that the file is never seen half written. If
+// backup is set, the previous contents are kept in file.bak.
+func writeFileAtomic(file string, backup bool, write func(w io.Writer) error) error {
+	fd, err := createTemp(file)
+	if err != nil {
+		return err
+	}
+	err = write(fd)
+	if cerr := syncClose(fd); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = replaceFile(fd.Name(), file, backup)
+	}
+	if err != nil {
+		os.Remove(fd.Name())
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
+
+// syncClose flushes the file to disk and closes it, so that it is never
+// renamed into place before its contents are on disk.
+func syncClose(fd *os.File) error {
+	err := fd.Sync()
+	if cerr := fd.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// createTemp creates a temporary file in the same directory as the given
+// file, so that it can be renamed over it.
+func createTemp(file string) (*os.File, error) {
+	fd, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file)+".tmp")
+	if err != nil {
+		return nil, err
+	}
+	if err := fd.Chmod(0644); err != nil {
+		fd.Close()
+		os.Remove(fd.Name())
+		return nil, err
+	}
+	return fd, nil
+}
+
+// replaceFile renames tmp to file, first keeping the current file as
+// file.bak if backup is set and there is a current file.
+func replaceFile(tmp, file string, backup bool) error {
+	if backup {
+		if _, err := os.Stat(file); err == nil {
+			bak := file + ".bak"
+			os.Remove(bak)
+			if err := os.Link(file, bak); err != nil {
+				// Hard links aren't supported everywhere
+				bs, err := ioutil.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				if err := ioutil.WriteFile(bak, bs, 0644); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return os.Rename(tmp, file)
+}
@@ -0,0 +1,104 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseOutputFiles(t *testing.T) {
+	outs, err := parseOutputFiles([]string{"authors=AUTHORS", "json=out/a=b.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outs) != 2 || outs[0] != (outputFile{format: "authors", file: "AUTHORS"}) || outs[1] != (outputFile{format: "json", file: "out/a=b.json"}) {
+		t.Errorf("unexpected outputs %+v", outs)
+	}
+	for spec, msg := range map[string]string{
+		"AUTHORS":      "malformed output",
+		"=AUTHORS":     "malformed output",
+		"authors=":     "malformed output",
+		"nope=AUTHORS": `unknown output format "nope"`,
+	} {
+		if _, err := parseOutputFiles([]string{spec}); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("%q: error %v, expected %q", spec, err, msg)
+		}
+	}
+}
+
+func TestOutFiles(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	authors := filepath.Join(dir, "AUTHORS")
+	jsonFile := filepath.Join(dir, "authors.json")
+
+	if out := mustRunMain(t, dir, "-out", "authors="+authors, "-out", "json="+jsonFile); out != "" {
+		t.Errorf("unexpected output\n%s", out)
+	}
+	bs, err := ioutil.ReadFile(authors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "Alice A <alice@example.com>\nBob B <bob@example.com>\nCarol C <carol@example.com>\n"; string(bs) != expected {
+		t.Errorf("AUTHORS\n%s\nexpected\n%s", bs, expected)
+	}
+	bs, err = ioutil.ReadFile(jsonFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(bs), `"name": "Carol C"`) {
+		t.Errorf("unexpected JSON\n%s", bs)
+	}
+}
+
+func TestOutFilesAllOrNothing(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	authors := writeTestFile(t, dir, "AUTHORS", "Previous\n")
+
+	_, _, code := runMain(t, dir, "-out", "authors="+authors, "-out", "json="+filepath.Join(dir, "missing", "authors.json"))
+	if code == 0 {
+		t.Error("exit code 0 for an unwritable output")
+	}
+	if bs, err := ioutil.ReadFile(authors); err != nil {
+		t.Fatal(err)
+	} else if string(bs) != "Previous\n" {
+		t.Errorf("AUTHORS written despite the failure\n%s", bs)
+	}
+	if tmps, _ := filepath.Glob(filepath.Join(dir, ".AUTHORS.tmp*")); len(tmps) != 0 {
+		t.Errorf("temporary files left behind: %v", tmps)
+	}
+}
+
+func TestBackup(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	authors := writeTestFile(t, dir, "AUTHORS", "Previous\n")
+
+	mustRunMain(t, dir, "-out", "authors="+authors, "-backup")
+	if bs, err := ioutil.ReadFile(authors + ".bak"); err != nil {
+		t.Fatal(err)
+	} else if string(bs) != "Previous\n" {
+		t.Errorf("backup\n%s", bs)
+	}
+	if bs, err := ioutil.ReadFile(authors); err != nil {
+		t.Fatal(err)
+	} else if !strings.HasPrefix(string(bs), "Alice A <alice@example.com>\n") {
+		t.Errorf("AUTHORS\n%s", bs)
+	}
+
+	// Writing the same again replaces the backup
+	mustRunMain(t, dir, "-out", "authors="+authors, "-backup")
+	if bs, err := ioutil.ReadFile(authors + ".bak"); err != nil {
+		t.Fatal(err)
+	} else if !strings.HasPrefix(string(bs), "Alice A <alice@example.com>\n") {
+		t.Errorf("backup not replaced\n%s", bs)
+	}
+}
@@ -0,0 +1,559 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// An outputFunc writes one of the output formats, given the analysis and
+// the sorted and filtered authors.
+type outputFunc func(w io.Writer, a *analysis, authors []author, opts *options) error
+
+// outputFuncs are the output formats, by the name used with -out.
+var outputFuncs = map[string]outputFunc{
+	"names": func(w io.Writer, a *analysis, authors []author, opts *options) error {
+		authors, err := activeAuthors(authors, opts.activeWindow, opts.decay != "", opts.now())
+		if err != nil {
+			return err
+		}
+		f := opts.namesFormat()
+		if opts.groupByLetter {
+			err = writeByLetter(w, "names", authors, func(w io.Writer, authors []author) error {
+				return writeNames(w, authors, f)
+			})
+		} else {
+			err = writeNames(w, authors, f)
+		}
+		if err != nil {
+			return err
+		}
+		return writeEstimateNote(w, a)
+	},
+	"stats": func(w io.Writer, a *analysis, authors []author, opts *options) error {
+		if err := writeStats(w, authors, opts.streaks, colorsFor(w, opts)); err != nil {
+			return err
+		}
+		return writeEstimateNote(w, a)
+	},
+	"authors": func(w io.Writer, _ *analysis, authors []author, opts *options) error {
+		return writeAuthors(w, authors, opts.maxEmails)
+	},
+	"yaml": func(w io.Writer, _ *analysis, authors []author, opts *options) error {
+		return writeYAMLAuthors(w, authors, opts.maxEmails)
+	},
+	"spdx": func(w io.Writer, _ *analysis, authors []author, _ *options) error {
+		return writeSPDX(w, authors)
+	},
+	"categories": func(w io.Writer, _ *analysis, authors []author, _ *options) error {
+		return writeCategories(w, authors)
+	},
+	"markdown": func(w io.Writer, _ *analysis, authors []author, opts *options) error {
+		if opts.groupByLetter {
+			return writeByLetter(w, "markdown", authors, func(w io.Writer, authors []author) error {
+				return writeMarkdown(w, authors, opts.avatarSize)
+			})
+		}
+		return writeMarkdown(w, authors, opts.avatarSize)
+	},
+	"html": func(w io.Writer, _ *analysis, authors []author, opts *options) error {
+		if opts.groupByLetter {
+			return writeByLetter(w, "html", authors, func(w io.Writer, authors []author) error {
+				return writeHTML(w, authors, opts.avatarSize)
+			})
+		}
+		return writeHTML(w, authors, opts.avatarSize)
+	},
+	"merge-stats": func(w io.Writer, _ *analysis, authors []author, _ *options) error {
+		return writeMergeStats(w, authors)
+	},
+	"added-files": func(w io.Writer, _ *analysis, authors []author, opts *options) error {
+		return writeAddedFiles(w, authors, opts.licenseHeaders)
+	},
+	"lines": func(w io.Writer, _ *analysis, authors []author, _ *options) error {
+		return writeLines(w, authors)
+	},
+	"maintenance": func(w io.Writer, a *analysis, _ []author, _ *options) error {
+		return writeMaintenance(w, a.maintenance)
+	},
+	"dot": func(w io.Writer, a *analysis, authors []author, _ *options) error {
+		return writeDot(w, a, authors)
+	},
+	"word-cloud": func(w io.Writer, a *analysis, authors []author, opts *options) error {
+		n := opts.wordCloud
+		if n == 0 {
+			// Asked for with -out only
+			n = defaultWordCloudWords
+		}
+		return writeWordCloud(w, a, authors, n)
+	},
+	"imports": func(w io.Writer, a *analysis, _ []author, _ *options) error {
+		return writeImports(w, a.imports)
+	},
+	"outliers": func(w io.Writer, _ *analysis, authors []author, opts *options) error {
+		return writeOutliers(w, authors, opts.outlierLines)
+	},
+	"blame": func(w io.Writer, _ *analysis, authors []author, _ *options) error {
+		return writeBlameLines(w, authors)
+	},
+	"summary": func(w io.Writer, _ *analysis, authors []author, opts *options) error {
+		return writeSummary(w, authors, opts.summaryCount, opts.summarySep, opts.summaryConj)
+	},
+	"top": func(w io.Writer, _ *analysis, authors []author, opts *options) error {
+		return writeTop(w, authors, opts.top, opts.summarySep, opts.summaryConj)
+	},
+	"credits": func(w io.Writer, a *analysis, _ []author, opts *options) error {
+		return writeCredits(w, a.credits, opts.creditKeys())
+	},
+	"modules": func(w io.Writer, a *analysis, _ []author, opts *options) error {
+		switch opts.moduleFormat {
+		case "names":
+			return writeModules(w, a.modules, false)
+		case "stats":
+			return writeModules(w, a.modules, true)
+		default:
+			return fmt.Errorf("unknown module format %q", opts.moduleFormat)
+		}
+	},
+	"thanks": func(w io.Writer, a *analysis, _ []author, opts *options) error {
+		return writeThanks(w, opts.thanksTemplate, a.credits, opts.creditKeys())
+	},
+	"pairs": func(w io.Writer, a *analysis, authors []author, _ *options) error {
+		return writePairs(w, getPairs(a.commits, authors))
+	},
+	"explain-filter": func(w io.Writer, a *analysis, _ []author, _ *options) error {
+		return writeDropped(w, a.dropped)
+	},
+	"milestones": func(w io.Writer, a *analysis, _ []author, opts *options) error {
+		return writeMilestones(w, a.milestones, opts.now())
+	},
+	"team": func(w io.Writer, _ *analysis, authors []author, _ *options) error {
+		return writeTeamStats(w, authors)
+	},
+	"trend": func(w io.Writer, a *analysis, _ []author, _ *options) error {
+		return writeTrend(w, a.trend)
+	},
+	"trend-json": func(w io.Writer, a *analysis, _ []author, _ *options) error {
+		return writeTrendJSON(w, a.trend)
+	},
+	"release-velocity": func(w io.Writer, _ *analysis, authors []author, _ *options) error {
+		return writeReleaseVelocity(w, authors)
+	},
+	"diversity": func(w io.Writer, _ *analysis, authors []author, _ *options) error {
+		return writeDiversity(w, authors)
+	},
+	"template": func(w io.Writer, _ *analysis, authors []author, opts *options) error {
+		if opts.templateFile == "" {
+			return errors.New("template output requires -template")
+		}
+		return writeTemplate(w, opts.templateFile, authors)
+	},
+	"json": func(w io.Writer, a *analysis, authors []author, _ *options) error {
+		return writeJSON(w, authors, a.sample)
+	},
+}
+
+// outputNames returns the sorted names of the output formats.
+func outputNames() []string {
+	names := make([]string, 0, len(outputFuncs))
+	for name := range outputFuncs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// A namesFormat is how the names output is joined and wrapped. Lengths
+// are counted in characters.
+type namesFormat struct {
+	sep       string // between names
+	conj      string // before the last name, if set
+	oxford    bool   // keep the separator before the conjunction
+	maxLength int    // truncate the names to this length, if set
+	width     int    // wrap lines at this width, if set
+	indent    string // at the start of every line
+}
+
+// writeNames writes the display names, separated and wrapped as given by
+// the format. If the names are longer than the maximum length, as many as
+// fit are kept, followed by the number of the others.
+func writeNames(w io.Writer, authors []author, f namesFormat) error {
+	names := make([]string, len(authors))
+	for i, author := range authors {
+		names[i] = author.shownName()
+	}
+	bw := bufio.NewWriter(w)
+	for _, line := range f.wrap(f.truncate(names)) {
+		fmt.Fprintln(bw, line)
+	}
+	return bw.Flush()
+}
+
+// pieces returns the names with the separators and conjunction attached,
+// such that the pieces together are the joined names. Lines are wrapped
+// between pieces.
+func (f namesFormat) pieces(names []string) []string {
+	res := make([]string, len(names))
+	last := len(names) - 1
+	for i, name := range names {
+		switch {
+		case i == last:
+			res[i] = name
+		case i == last-1 && f.conj != "" && (!f.oxford || len(names) == 2):
+			res[i] = name + " "
+		default:
+			res[i] = name + f.sep
+		}
+	}
+	if f.conj != "" && last > 0 {
+		res[last] = f.conj + " " + res[last]
+	}
+	return res
+}
+
+// truncate returns the pieces of the names, truncated at a name boundary
+// to the maximum length. When not even "N others" fits, that is what it
+// returns anyway.
+func (f namesFormat) truncate(names []string) []string {
+	pieces := f.pieces(names)
+	if f.maxLength <= 0 || utf8.RuneCountInString(strings.Join(pieces, "")) <= f.maxLength {
+		return pieces
+	}
+	if f.conj == "" {
+		f.conj = "and"
+	}
+	for keep := len(names) - 1; keep > 0; keep-- {
+		pieces = f.pieces(append(names[:keep:keep], othersText(len(names)-keep)))
+		if utf8.RuneCountInString(strings.Join(pieces, "")) <= f.maxLength {
+			return pieces
+		}
+	}
+	return []string{othersText(len(names))}
+}
+
+// wrap joins the pieces into indented lines no wider than the width, if
+// set, except where a single piece is wider.
+func (f namesFormat) wrap(pieces []string) []string {
+	if f.width <= 0 {
+		return []string{f.indent + strings.Join(pieces, "")}
+	}
+	var lines []string
+	line := f.indent
+	for _, p := range pieces {
+		if line != f.indent && utf8.RuneCountInString(line+strings.TrimRight(p, " ")) > f.width {
+			lines = append(lines, strings.TrimRight(line, " "))
+			line = f.indent
+		}
+		line += p
+	}
+	return append(lines, strings.TrimRight(line, " "))
+}
+
+// writeStats writes the commit count, geekrank and name of each author,
+// in columns wide enough for the largest numbers, optionally with the
+// longest day and week streaks after the geekrank. With color, the
+// geekrank is colored by how high it is and bots are dimmed.
+func writeStats(w io.Writer, authors []author, streaks, color bool) error {
+	commitsWidth, rankWidth, maxRank := 5, 2, 0
+	dayWidth, weekWidth := 3, 3
+	for _, a := range authors {
+		if n := len(strconv.Itoa(a.dayStreak)); n > dayWidth {
+			dayWidth = n
+		}
+		if n := len(strconv.Itoa(a.weekStreak)); n > weekWidth {
+			weekWidth = n
+		}
+		if n := len(strconv.Itoa(a.commits)); n > commitsWidth {
+			commitsWidth = n
+		}
+		if n := len(strconv.Itoa(a.geekrank)); n > rankWidth {
+			rankWidth = n
+		}
+		if a.geekrank > maxRank {
+			maxRank = a.geekrank
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, author := range authors {
+		name := author.shownName()
+		if streaks {
+			name = fmt.Sprintf("%*d %*d %s", dayWidth, author.dayStreak, weekWidth, author.weekStreak, name)
+		}
+		if !color {
+			fmt.Fprintf(bw, "%*d %*d %s\n", commitsWidth, author.commits, rankWidth, author.geekrank, name)
+			continue
+		}
+		if isBot(author.name) {
+			fmt.Fprintf(bw, "%s%*d %*d %s%s\n", ansiDim, commitsWidth, author.commits, rankWidth, author.geekrank, name, ansiReset)
+			continue
+		}
+		fmt.Fprintf(bw, "%*d %s%*d%s %s\n", commitsWidth, author.commits, heatColor(author.geekrank, maxRank), rankWidth, author.geekrank, ansiReset, name)
+	}
+	return bw.Flush()
+}
+
+// authorView is the exported representation of an author, as used in JSON
+// and template output.
+type authorView struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Nickname    string         `json:"nickname,omitempty"`
+	DisplayName string         `json:"displayName"`
+	Emails      []string       `json:"emails"`
+	Commits     int            `json:"commits"`
+	Geekrank    int            `json:"geekrank"`
+	DaysActive  int            `json:"daysActive"`
+	DayStreak   int            `json:"dayStreak"`
+	WeekStreak  int            `json:"weekStreak"`
+	FirstYear   int            `json:"firstYear,omitempty"`
+	LastYear    int            `json:"lastYear,omitempty"`
+	Years       string         `json:"years,omitempty"`
+	URL         string         `json:"url,omitempty"`
+	Avatar      string         `json:"avatar,omitempty"`
+	Categories  map[string]int `json:"categories,omitempty"`
+	Team        bool           `json:"team,omitempty"`
+	Note        string         `json:"note,omitempty"`
+	Pronouns    string         `json:"pronouns,omitempty"`
+	Department  string         `json:"department,omitempty"`
+	Tagged      int            `json:"tagged,omitempty"`
+	Sample      int            `json:"sample,omitempty"` // the commits are estimated from a sample of 1 in this many
+}
+
+func newAuthorView(a author) authorView {
+	v := authorView{
+		ID:          a.id(),
+		Name:        a.name,
+		Nickname:    a.nickname,
+		DisplayName: a.displayName(),
+		Emails:      a.emails,
+		Commits:     a.commits,
+		Geekrank:    a.geekrank,
+		DaysActive:  a.daysActive,
+		DayStreak:   a.dayStreak,
+		WeekStreak:  a.weekStreak,
+		Years:       a.years(),
+		URL:         a.url(),
+		Avatar:      a.avatar,
+		Categories:  a.categories,
+		Team:        a.team,
+		Note:        a.note,
+		Pronouns:    a.pronouns,
+		Department:  a.department,
+		Tagged:      a.tagged,
+	}
+	if !a.first.IsZero() {
+		v.FirstYear = a.first.Year()
+		v.LastYear = a.last.Year()
+	}
+	return v
+}
+
+func newAuthorViews(authors []author) []authorView {
+	res := make([]authorView, len(authors))
+	for i, a := range authors {
+		res[i] = newAuthorView(a)
+	}
+	return res
+}
+
+// writeJSON writes the authors as an indented JSON array, marked as
+// estimates if from a one in sample analysis.
+func writeJSON(w io.Writer, authors []author, sample int) error {
+	res := newAuthorViews(authors)
+	if sample > 1 {
+		for i := range res {
+			res[i].Sample = sample
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+// writeSPDX writes one SPDX-FileCopyrightText line per author, with the
+// years they have been active, in the form expected by the REUSE
+// specification.
+func writeSPDX(w io.Writer, authors []author) error {
+	bw := bufio.NewWriter(w)
+	for _, a := range authors {
+		fmt.Fprintf(bw, "SPDX-FileCopyrightText: ")
+		if years := a.years(); years != "" {
+			fmt.Fprintf(bw, "%s ", years)
+		}
+		fmt.Fprintf(bw, "%s", a.name)
+		if len(a.emails) > 0 {
+			fmt.Fprintf(bw, " <%s>", a.emails[0])
+		}
+		fmt.Fprintf(bw, "\n")
+	}
+	return bw.Flush()
+}
+
+// markdownEscaper escapes the characters that have meaning in Markdown
+// inline text.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`,
+	"[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`,
+)
+
+// markdownURLEscaper percent encodes the characters that would end a
+// Markdown link destination early. The encoded URL is equivalent.
+var markdownURLEscaper = strings.NewReplacer(
+	" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E",
+)
+
+// writeMarkdown writes the authors as a Markdown list, with names linked
+// to the author URL when there is one.
+func writeMarkdown(w io.Writer, authors []author, avatarSize int) error {
+	bw := bufio.NewWriter(w)
+	for _, a := range authors {
+		fmt.Fprintf(bw, "- ")
+		if a.avatar != "" {
+			fmt.Fprintf(bw, `<img src="%s" width="%d" height="%d" alt=""> `, html.EscapeString(a.avatar), avatarSize, avatarSize)
+		}
+		name := markdownEscaper.Replace(a.shownName())
+		if url := a.url(); url != "" {
+			fmt.Fprintf(bw, "[%s](%s)\n", name, markdownURLEscaper.Replace(url))
+		} else {
+			fmt.Fprintf(bw, "%s\n", name)
+		}
+	}
+	return bw.Flush()
+}
+
+// writeHTML writes the authors as an HTML list, with names linked to the
+// author URL when there is one.
+func writeHTML(w io.Writer, authors []author, avatarSize int) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<ul>\n")
+	for _, a := range authors {
+		name := html.EscapeString(a.shownName())
+		if a.avatar != "" {
+			name = fmt.Sprintf(`<img src="%s" srcset="%s 2x" width="%d" height="%d" alt=""> %s`, html.EscapeString(a.avatar), html.EscapeString(a.avatar2x), avatarSize, avatarSize, name)
+		}
+		if url := a.url(); url != "" {
+			fmt.Fprintf(bw, "<li><a href=\"%s\">%s</a></li>\n", html.EscapeString(url), name)
+		} else {
+			fmt.Fprintf(bw, "<li>%s</li>\n", name)
+		}
+	}
+	fmt.Fprintf(bw, "</ul>\n")
+	return bw.Flush()
+}
+
+// writeSummary writes a one line summary like "142 contributors, most
+// active: A, B, C, and 139 others", naming the count most active authors.
+// The names are separated by sep, with conj before the last one.
+func writeSummary(w io.Writer, authors []author, count int, sep, conj string) error {
+	names, others := mostActive(authors, count)
+	contributors := "contributors"
+	if len(authors) == 1 {
+		contributors = "contributor"
+	}
+
+	var err error
+	switch {
+	case len(names) == 0:
+		_, err = fmt.Fprintf(w, "%d %s\n", len(authors), contributors)
+	case others == 0:
+		_, err = fmt.Fprintf(w, "%d %s: %s\n", len(authors), contributors, joinList(names, sep, conj))
+	default:
+		names = append(names, othersText(others))
+		_, err = fmt.Fprintf(w, "%d %s, most active: %s\n", len(authors), contributors, joinList(names, sep, conj))
+	}
+	return err
+}
+
+// writeTop writes the names of the count most active authors followed by
+// "and N others" for the rest, if any, as for an About dialog where there
+// is no room for everyone.
+func writeTop(w io.Writer, authors []author, count int, sep, conj string) error {
+	names, others := mostActive(authors, count)
+	if others > 0 {
+		names = append(names, othersText(others))
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "%s\n", joinList(names, sep, conj))
+	return err
+}
+
+// mostActive returns the display names of the count authors with the most
+// commits, and the number of authors left.
+func mostActive(authors []author, count int) ([]string, int) {
+	sorted := make([]author, len(authors))
+	copy(sorted, authors)
+	sortAuthors(sorted, func(a author) float64 { return float64(a.commits) })
+	if count < 0 {
+		count = 0
+	}
+	if count > len(sorted) {
+		count = len(sorted)
+	}
+	names := make([]string, count)
+	for i, a := range sorted[:count] {
+		names[i] = a.shownName()
+	}
+	return names, len(sorted) - count
+}
+
+func othersText(others int) string {
+	if others == 1 {
+		return "1 other"
+	}
+	return fmt.Sprintf("%d others", others)
+}
+
+// joinList joins the items with sep, and the conjunction before the last
+// one, as in "A, B, and C". Two items are joined as "A and B". Without a
+// conjunction the items are only separated, as in "A, B, C".
+func joinList(items []string, sep, conj string) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	}
+	if conj == "" {
+		return strings.Join(items, sep)
+	}
+	switch len(items) {
+	case 2:
+		return items[0] + " " + conj + " " + items[1]
+	default:
+		last := len(items) - 1
+		return strings.Join(items[:last], sep) + sep + conj + " " + items[last]
+	}
+}
+
+// templateData is the data passed to output templates.
+type templateData struct {
+	Authors []authorView
+}
+
+// writeTemplate executes the template in the given file with the authors
+// as data.
+func writeTemplate(w io.Writer, file string, authors []author) error {
+	tpl, err := template.New(filepath.Base(file)).Funcs(templateFuncs).ParseFiles(file)
+	if err != nil {
+		return err
+	}
+	return tpl.Execute(w, templateData{Authors: newAuthorViews(authors)})
+}
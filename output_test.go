@@ -0,0 +1,259 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJoinList(t *testing.T) {
+	cases := []struct {
+		items     []string
+		sep, conj string
+		expected  string
+	}{
+		{nil, ", ", "and", ""},
+		{[]string{"A"}, ", ", "and", "A"},
+		{[]string{"A", "B"}, ", ", "and", "A and B"},
+		{[]string{"A", "B", "C"}, ", ", "and", "A, B, and C"},
+		{[]string{"A", "B", "C"}, " · ", "&", "A · B · & C"},
+		{[]string{"A", "B"}, ", ", "", "A, B"},
+		{[]string{"A", "B", "C"}, ", ", "", "A, B, C"},
+	}
+	for _, c := range cases {
+		if got := joinList(c.items, c.sep, c.conj); got != c.expected {
+			t.Errorf("joinList(%q, %q, %q) = %q, expected %q", c.items, c.sep, c.conj, got, c.expected)
+		}
+	}
+}
+
+func TestSummary(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	if out := mustRunMain(t, dir, "-summary", "-summary-count", "2"); out != "3 contributors, most active: Alice A, Bob B, and 1 other\n" {
+		t.Errorf("unexpected summary %q", out)
+	}
+	if out := mustRunMain(t, dir, "-summary", "-summary-count", "2", "-summary-and", ""); out != "3 contributors, most active: Alice A, Bob B, 1 other\n" {
+		t.Errorf("unexpected summary without conjunction %q", out)
+	}
+}
+
+func TestMaxLength(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	cases := map[string]string{
+		"0":  "Alice A, Bob B, Carol C\n",
+		"23": "Alice A, Bob B, Carol C\n",
+		"22": "Alice A and 2 others\n",
+		"20": "Alice A and 2 others\n",
+		"19": "3 others\n",
+		"1":  "3 others\n",
+	}
+	for max, expected := range cases {
+		if out := mustRunMain(t, dir, "-names", "-max-length", max); out != expected {
+			t.Errorf("-max-length %s: %q, expected %q", max, out, expected)
+		}
+	}
+}
+
+func TestNamesFormat(t *testing.T) {
+	names := []string{"Alice A", "Bob B", "Carol C", "Dave D"}
+	cases := []struct {
+		f        namesFormat
+		expected []string
+	}{
+		{namesFormat{sep: ", "}, []string{"Alice A, Bob B, Carol C, Dave D"}},
+		{namesFormat{sep: ", ", conj: "and", oxford: true}, []string{"Alice A, Bob B, Carol C, and Dave D"}},
+		{namesFormat{sep: ", ", conj: "and"}, []string{"Alice A, Bob B, Carol C and Dave D"}},
+		{namesFormat{sep: " · ", indent: "# "}, []string{"# Alice A · Bob B · Carol C · Dave D"}},
+		{namesFormat{sep: ", ", conj: "and", oxford: true, width: 20, indent: "  "}, []string{"  Alice A, Bob B,", "  Carol C,", "  and Dave D"}},
+		// A name wider than the width gets a line of its own
+		{namesFormat{sep: ", ", width: 6}, []string{"Alice A,", "Bob B,", "Carol C,", "Dave D"}},
+		{namesFormat{sep: ", ", conj: "and", maxLength: 25, width: 12}, []string{"Alice A", "and 3 others"}},
+	}
+	for _, c := range cases {
+		if got := c.f.wrap(c.f.truncate(names)); !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("%+v: %q, expected %q", c.f, got, c.expected)
+		}
+	}
+	f := namesFormat{sep: ", ", conj: "and", oxford: true}
+	if got := f.wrap(f.truncate(names[:2])); !reflect.DeepEqual(got, []string{"Alice A and Bob B"}) {
+		t.Errorf("two names joined as %q", got)
+	}
+}
+
+func TestNamesOptions(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	out := mustRunMain(t, dir, "-names", "-names-and", "and", "-oxford-comma=false", "-names-width", "16", "-names-indent", "// ")
+	if out != "// Alice A,\n// Bob B\n// and Carol C\n" {
+		t.Errorf("unexpected names\n%s", out)
+	}
+	out = mustRunMain(t, dir, "-names", "-names-sep", " | ", "-names-and", "&", "-max-length", "20")
+	if out != "Alice A & 2 others\n" {
+		t.Errorf("unexpected truncated names\n%s", out)
+	}
+}
+
+func TestSPDX(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Alice A <alice@example.com>", date: "2018-06-01T12:00:00Z", message: "Early"},
+		testCommit{author: "Alice A <alice@example.com>", date: "2020-02-01T12:00:00Z", message: "Late"},
+		testCommit{author: "Bob B <bob@example.com>", date: "2020-03-01T12:00:00Z", message: "Bob"},
+	)
+	defer cleanup()
+	authors := writeTestFile(t, dir, "AUTHORS", "Alice A <alice@example.com>\nBob B <bob@example.com>\nCarol C <carol@example.com>\n")
+
+	out := mustRunMain(t, dir, "-read-authors", authors, "-spdx")
+	expected := "SPDX-FileCopyrightText: 2018-2020 Alice A <alice@example.com>\n" +
+		"SPDX-FileCopyrightText: 2020 Bob B <bob@example.com>\n"
+	if out != expected {
+		t.Errorf("output\n%s\nexpected\n%s", out, expected)
+	}
+}
+
+func TestYears(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	cases := []struct {
+		a        author
+		expected string
+	}{
+		{author{}, ""},
+		{author{first: day("2019-01-01"), last: day("2019-12-31")}, "2019"},
+		{author{first: day("2015-06-01"), last: day("2019-01-01")}, "2015-2019"},
+	}
+	for _, c := range cases {
+		if got := c.a.years(); got != c.expected {
+			t.Errorf("years() for %v to %v = %q, expected %q", c.a.first, c.a.last, got, c.expected)
+		}
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Alice A <alice@example.com>", date: "2018-06-01T12:00:00Z", message: "Early"},
+		testCommit{author: "Alice A <alice@example.com>", date: "2020-02-01T12:00:00Z", message: "Late"},
+		testCommit{author: "Bob B <bob@example.com>", date: "2020-03-01T12:00:00Z", message: "Bob"},
+	)
+	defer cleanup()
+	tpl := writeTestFile(t, dir, "authors.tpl", "{{range .Authors}}{{.Name}} ({{.Years}}, {{.FirstYear}}){{\"\\n\"}}{{end}}")
+
+	if out := mustRunMain(t, dir, "-template", tpl); out != "Alice A (2018-2020, 2018)\nBob B (2020, 2020)\n" {
+		t.Errorf("unexpected output\n%s", out)
+	}
+
+	out := mustRunMain(t, dir, "-json")
+	if !strings.Contains(out, `"years": "2018-2020"`) || !strings.Contains(out, `"lastYear": 2020`) {
+		t.Errorf("no years in JSON\n%s", out)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+
+	ids := func(args ...string) map[string]string {
+		var res []authorView
+		if err := json.Unmarshal([]byte(mustRunMain(t, dir, append(args, "-json")...)), &res); err != nil {
+			t.Fatal(err)
+		}
+		m := make(map[string]string)
+		for _, a := range res {
+			m[a.Emails[0]] = a.ID
+		}
+		return m
+	}
+	before := ids()
+	if len(before) != 3 || before["alice@example.com"] != (author{emails: []string{"alice@example.com"}}).id() {
+		t.Errorf("ids %v", before)
+	}
+
+	// Renamed and with another email, Alice is still the same
+	authors := writeTestFile(t, dir, "AUTHORS", "Alice Anderson <alice@example.com> <alice@example.net>\n")
+	if after := ids("-read-authors", authors); !reflect.DeepEqual(after, before) {
+		t.Errorf("ids %v, expected %v", after, before)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	authors := []author{
+		{name: "Alice *A*", urls: []string{"https://example.com/alice (home)"}},
+		{name: "Bob B"},
+	}
+	buf := new(bytes.Buffer)
+	if err := writeMarkdown(buf, authors, 32); err != nil {
+		t.Fatal(err)
+	}
+	expected := "- [Alice \\*A\\*](https://example.com/alice%20%28home%29)\n- Bob B\n"
+	if buf.String() != expected {
+		t.Errorf("output\n%s\nexpected\n%s", buf.String(), expected)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	authors := []author{
+		{name: "Alice <A>", urls: []string{"https://example.com/?a=1&b=2"}},
+		{name: "Bob B"},
+	}
+	buf := new(bytes.Buffer)
+	if err := writeHTML(buf, authors, 32); err != nil {
+		t.Fatal(err)
+	}
+	expected := "<ul>\n<li><a href=\"https://example.com/?a=1&amp;b=2\">Alice &lt;A&gt;</a></li>\n<li>Bob B</li>\n</ul>\n"
+	if buf.String() != expected {
+		t.Errorf("output\n%s\nexpected\n%s", buf.String(), expected)
+	}
+}
+
+func TestWriteTop(t *testing.T) {
+	authors := []author{
+		{name: "Bob B", commits: 5},
+		{name: "Alice A", commits: 10},
+		{name: "Carol C", commits: 1},
+		{name: "Dave D", commits: 2},
+	}
+	cases := []struct {
+		count    int
+		expected string
+	}{
+		{0, "4 others\n"},
+		{1, "Alice A and 3 others\n"},
+		{3, "Alice A, Bob B, Dave D, and 1 other\n"},
+		{4, "Alice A, Bob B, Dave D, and Carol C\n"},
+		{10, "Alice A, Bob B, Dave D, and Carol C\n"},
+	}
+	for _, c := range cases {
+		buf := new(bytes.Buffer)
+		if err := writeTop(buf, authors, c.count, ", ", "and"); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != c.expected {
+			t.Errorf("top %d: %q, expected %q", c.count, buf.String(), c.expected)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := writeTop(buf, nil, 3, ", ", "and"); err != nil || buf.Len() != 0 {
+		t.Errorf("output %q, %v without authors", buf.String(), err)
+	}
+}
+
+func TestTopFlag(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	if out := mustRunMain(t, dir, "-top", "1", "-summary-and", "&"); out != "Alice A & 2 others\n" {
+		t.Errorf("unexpected output\n%s", out)
+	}
+}
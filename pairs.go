@@ -0,0 +1,99 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// coAuthorTrailer is the trailer naming the other authors of a commit made
+// in a pair or mob.
+const coAuthorTrailer = "Co-authored-by"
+
+// A pair is two people who made commits together, with the number of
+// commits. The names are in order.
+type pair struct {
+	a, b    string
+	commits int
+}
+
+// getPairs returns the pairs among the author and co-authors of each
+// commit, most commits first. A commit by three people counts toward each
+// of the three pairs.
+func getPairs(commits []commit, authors []author) []pair {
+	idx := newAuthorIndex(authors)
+	found := make(map[[2]string]*pair)
+	for _, c := range commits {
+		// person -> name, for the people on the commit
+		people := make(map[string]string)
+		if i, ok := idx.email(c.email); ok {
+			people[authors[i].id()] = authors[i].displayName()
+		} else {
+			people[strings.ToLower(c.email)] = c.name
+		}
+		for _, t := range parseTrailers(c.message) {
+			if !strings.EqualFold(t.key, coAuthorTrailer) {
+				continue
+			}
+			name, person, _ := trailerPerson(t, authors, idx)
+			people[person] = name
+		}
+		if len(people) < 2 {
+			continue
+		}
+
+		keys := make([]string, 0, len(people))
+		for p := range people {
+			keys = append(keys, p)
+		}
+		sort.Strings(keys)
+		for i := range keys {
+			for j := i + 1; j < len(keys); j++ {
+				k := [2]string{keys[i], keys[j]}
+				if found[k] == nil {
+					a, b := people[keys[i]], people[keys[j]]
+					if strings.ToLower(b) < strings.ToLower(a) {
+						a, b = b, a
+					}
+					found[k] = &pair{a: a, b: b}
+				}
+				found[k].commits++
+			}
+		}
+	}
+
+	res := make([]pair, 0, len(found))
+	for _, p := range found {
+		res = append(res, *p)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].commits != res[j].commits {
+			return res[i].commits > res[j].commits
+		}
+		if ai, aj := strings.ToLower(res[i].a), strings.ToLower(res[j].a); ai != aj {
+			return ai < aj
+		}
+		return strings.ToLower(res[i].b) < strings.ToLower(res[j].b)
+	})
+	return res
+}
+
+// writePairs writes the pairs as a CSV edge list, with source, target and
+// weight columns as graph tools expect.
+func writePairs(w io.Writer, pairs []pair) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"source", "target", "weight"})
+	for _, p := range pairs {
+		cw.Write([]string{p.a, p.b, strconv.Itoa(p.commits)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
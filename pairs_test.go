@@ -0,0 +1,25 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import "testing"
+
+func TestPairs(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Bob B <bob@example.com>", message: "Pair on the lexer\n\nCo-authored-by: Alice A <alice@example.com>\nCo-authored-by: Carol C <carol@example.com>"},
+		testCommit{author: "Alice A <alice@example.com>", message: "Pair on the parser\n\nCo-authored-by: Bob B <BOB@example.com>"},
+		// Crediting oneself is no pair
+		testCommit{author: "Carol C <carol@example.com>", message: "Solo\n\nCo-authored-by: Carol C <carol@example.com>"},
+	)
+	defer cleanup()
+
+	out := exportImport(t, dir, "-pairs")
+	expected := "source,target,weight\nAlice A,Bob B,2\nAlice A,Carol C,1\nBob B,Carol C,1\n"
+	if out != expected {
+		t.Errorf("got\n%s\nexpected\n%s", out, expected)
+	}
+}
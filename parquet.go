@@ -0,0 +1,308 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// This is a minimal Parquet writer: one row group of uncompressed, PLAIN
+// encoded columns with a single data page each, which every reader
+// supports. The metadata is in the Thrift compact protocol.
+
+// Parquet physical types, repetitions and converted types.
+const (
+	parquetInt64     = 2
+	parquetByteArray = 6
+
+	parquetRequired = 0
+	parquetOptional = 1
+
+	parquetUTF8            = 0
+	parquetTimestampMillis = 9
+)
+
+// A parquetColumn is a column of strings or of int64s. Null marks the
+// missing values of optional columns.
+type parquetColumn struct {
+	name      string
+	typ       int32
+	converted int32
+	optional  bool
+	strs      []string
+	ints      []int64
+	null      []bool
+}
+
+func (c *parquetColumn) rows() int {
+	if c.typ == parquetInt64 {
+		return len(c.ints)
+	}
+	return len(c.strs)
+}
+
+// page returns the data page for the column: the definition levels, if the
+// column is optional, followed by the values.
+func (c *parquetColumn) page() []byte {
+	var buf bytes.Buffer
+	if c.optional {
+		levels := rleLevels(c.null)
+		binary.Write(&buf, binary.LittleEndian, uint32(len(levels)))
+		buf.Write(levels)
+	}
+	for i := 0; i < c.rows(); i++ {
+		if c.optional && c.null[i] {
+			continue
+		}
+		if c.typ == parquetInt64 {
+			binary.Write(&buf, binary.LittleEndian, c.ints[i])
+			continue
+		}
+		binary.Write(&buf, binary.LittleEndian, uint32(len(c.strs[i])))
+		buf.WriteString(c.strs[i])
+	}
+	return buf.Bytes()
+}
+
+// rleLevels encodes the definition levels, one for present values and
+// zero for nulls, as runs in the RLE/bit packing hybrid of bit width one.
+func rleLevels(null []bool) []byte {
+	var buf bytes.Buffer
+	for i := 0; i < len(null); {
+		j := i
+		for j < len(null) && null[j] == null[i] {
+			j++
+		}
+		writeUvarint(&buf, uint64(j-i)<<1)
+		if null[i] {
+			buf.WriteByte(0)
+		} else {
+			buf.WriteByte(1)
+		}
+		i = j
+	}
+	return buf.Bytes()
+}
+
+// writeParquet writes the columns, which must have the same number of
+// rows, as a Parquet file.
+func writeParquet(w io.Writer, cols []*parquetColumn) error {
+	rows := 0
+	if len(cols) > 0 {
+		rows = cols[0].rows()
+	}
+
+	var out bytes.Buffer
+	out.WriteString("PAR1")
+	type chunk struct {
+		offset, size int64
+		values       int
+	}
+	chunks := make([]chunk, len(cols))
+	for i, c := range cols {
+		data := c.page()
+		var hdr bytes.Buffer
+		t := &thriftStruct{w: &hdr}
+		t.i32(1, 0) // DATA_PAGE
+		t.i32(2, int32(len(data)))
+		t.i32(3, int32(len(data)))
+		t.structField(5, func(t *thriftStruct) {
+			t.i32(1, int32(rows))
+			t.i32(2, 0) // PLAIN
+			t.i32(3, 3) // RLE
+			t.i32(4, 3) // RLE
+		})
+		t.stop()
+		chunks[i] = chunk{int64(out.Len()), int64(hdr.Len() + len(data)), rows}
+		out.Write(hdr.Bytes())
+		out.Write(data)
+	}
+
+	var meta bytes.Buffer
+	t := &thriftStruct{w: &meta}
+	t.i32(1, 1)
+	t.structList(2, len(cols)+1, func(i int, t *thriftStruct) {
+		if i == 0 {
+			t.binary(4, "schema")
+			t.i32(5, int32(len(cols)))
+			return
+		}
+		c := cols[i-1]
+		t.i32(1, c.typ)
+		if c.optional {
+			t.i32(3, parquetOptional)
+		} else {
+			t.i32(3, parquetRequired)
+		}
+		t.binary(4, c.name)
+		if c.converted >= 0 {
+			t.i32(6, c.converted)
+		}
+	})
+	t.i64(3, int64(rows))
+	var total int64
+	for _, ch := range chunks {
+		total += ch.size
+	}
+	t.structList(4, 1, func(_ int, t *thriftStruct) {
+		t.structList(1, len(cols), func(i int, t *thriftStruct) {
+			c, ch := cols[i], chunks[i]
+			t.i64(2, ch.offset)
+			t.structField(3, func(t *thriftStruct) {
+				t.i32(1, c.typ)
+				t.i32List(2, []int32{0, 3}) // PLAIN, RLE
+				t.binaryList(3, []string{c.name})
+				t.i32(4, 0) // UNCOMPRESSED
+				t.i64(5, int64(ch.values))
+				t.i64(6, ch.size)
+				t.i64(7, ch.size)
+				t.i64(9, ch.offset)
+			})
+		})
+		t.i64(2, total)
+		t.i64(3, int64(rows))
+	})
+	t.binary(6, "git-contributors "+toolVersion())
+	t.stop()
+
+	out.Write(meta.Bytes())
+	binary.Write(&out, binary.LittleEndian, uint32(meta.Len()))
+	out.WriteString("PAR1")
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// Thrift compact protocol types.
+const (
+	thriftTypeI32    = 5
+	thriftTypeI64    = 6
+	thriftTypeBinary = 8
+	thriftTypeList   = 9
+	thriftTypeStruct = 12
+)
+
+// A thriftStruct writes the fields of a struct in the Thrift compact
+// protocol, in increasing field id order.
+type thriftStruct struct {
+	w    *bytes.Buffer
+	last int
+}
+
+func (t *thriftStruct) field(id int, typ byte) {
+	if d := id - t.last; d > 0 && d <= 15 {
+		t.w.WriteByte(byte(d<<4) | typ)
+	} else {
+		t.w.WriteByte(typ)
+		writeUvarint(t.w, zigzag(int64(id)))
+	}
+	t.last = id
+}
+
+func (t *thriftStruct) i32(id int, v int32) {
+	t.field(id, thriftTypeI32)
+	writeUvarint(t.w, zigzag(int64(v)))
+}
+
+func (t *thriftStruct) i64(id int, v int64) {
+	t.field(id, thriftTypeI64)
+	writeUvarint(t.w, zigzag(v))
+}
+
+func (t *thriftStruct) binary(id int, s string) {
+	t.field(id, thriftTypeBinary)
+	writeUvarint(t.w, uint64(len(s)))
+	t.w.WriteString(s)
+}
+
+func (t *thriftStruct) structField(id int, fields func(t *thriftStruct)) {
+	t.field(id, thriftTypeStruct)
+	s := &thriftStruct{w: t.w}
+	fields(s)
+	s.stop()
+}
+
+func (t *thriftStruct) listHeader(id int, n int, typ byte) {
+	t.field(id, thriftTypeList)
+	if n < 15 {
+		t.w.WriteByte(byte(n<<4) | typ)
+		return
+	}
+	t.w.WriteByte(0xf0 | typ)
+	writeUvarint(t.w, uint64(n))
+}
+
+func (t *thriftStruct) i32List(id int, vs []int32) {
+	t.listHeader(id, len(vs), thriftTypeI32)
+	for _, v := range vs {
+		writeUvarint(t.w, zigzag(int64(v)))
+	}
+}
+
+func (t *thriftStruct) binaryList(id int, ss []string) {
+	t.listHeader(id, len(ss), thriftTypeBinary)
+	for _, s := range ss {
+		writeUvarint(t.w, uint64(len(s)))
+		t.w.WriteString(s)
+	}
+}
+
+func (t *thriftStruct) structList(id int, n int, elem func(i int, t *thriftStruct)) {
+	t.listHeader(id, n, thriftTypeStruct)
+	for i := 0; i < n; i++ {
+		s := &thriftStruct{w: t.w}
+		elem(i, s)
+		s.stop()
+	}
+}
+
+func (t *thriftStruct) stop() {
+	t.w.WriteByte(0)
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func writeUvarint(w *bytes.Buffer, v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	w.Write(buf[:binary.PutUvarint(buf[:], v)])
+}
+
+// writeCommitsParquet writes the analyzed commits with the author each is
+// attributed to, one row per commit, as a Parquet file. Commits by authors
+// that were filtered out have null author columns.
+func writeCommitsParquet(w io.Writer, a *analysis, authors []author) error {
+	str := func(name string, optional bool) *parquetColumn {
+		return &parquetColumn{name: name, typ: parquetByteArray, converted: parquetUTF8, optional: optional}
+	}
+	hash, authorID, authorName := str("hash", false), str("author_id", true), str("author", true)
+	email, name, subject := str("email", false), str("name", false), str("subject", false)
+	date := &parquetColumn{name: "date", typ: parquetInt64, converted: parquetTimestampMillis}
+
+	idx := newAuthorIndex(authors)
+	for _, c := range a.commits {
+		hash.strs = append(hash.strs, c.hash)
+		i, ok := idx.email(c.email)
+		for _, col := range []*parquetColumn{authorID, authorName} {
+			col.null = append(col.null, !ok)
+		}
+		if ok {
+			authorID.strs = append(authorID.strs, authors[i].id())
+			authorName.strs = append(authorName.strs, authors[i].displayName())
+		} else {
+			authorID.strs = append(authorID.strs, "")
+			authorName.strs = append(authorName.strs, "")
+		}
+		email.strs = append(email.strs, c.email)
+		name.strs = append(name.strs, c.name)
+		date.ints = append(date.ints, c.date.Unix()*1000)
+		subject.strs = append(subject.strs, commitSubject(c.message))
+	}
+	return writeParquet(w, []*parquetColumn{hash, authorID, authorName, email, name, date, subject})
+}
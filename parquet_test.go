@@ -0,0 +1,267 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// thriftReader decodes the Thrift compact protocol into maps from field
+// id to value, independently of thriftStruct, to check what it writes.
+type thriftReader struct {
+	bs  []byte
+	pos int
+	err error
+}
+
+func (r *thriftReader) byte() byte {
+	if r.pos >= len(r.bs) {
+		r.err = errors.New("short thrift data")
+		return 0
+	}
+	b := r.bs[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.bs[r.pos:])
+	if n <= 0 {
+		r.err = errors.New("bad varint")
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case 1:
+		return true
+	case 2:
+		return false
+	case thriftTypeI32, thriftTypeI64:
+		return r.varint()
+	case thriftTypeBinary:
+		n := int(r.uvarint())
+		if r.pos+n > len(r.bs) {
+			r.err = errors.New("short binary")
+			return ""
+		}
+		s := string(r.bs[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case thriftTypeList:
+		h := r.byte()
+		n := int(h >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = r.value(h & 0x0f)
+		}
+		return list
+	case thriftTypeStruct:
+		return r.structure()
+	}
+	r.err = fmt.Errorf("unexpected thrift type %d", typ)
+	return nil
+}
+
+func (r *thriftReader) structure() map[int]interface{} {
+	fields := make(map[int]interface{})
+	id := 0
+	for r.err == nil {
+		h := r.byte()
+		if h == 0 {
+			break
+		}
+		if d := int(h >> 4); d != 0 {
+			id += d
+		} else {
+			id = int(r.varint())
+		}
+		fields[id] = r.value(h & 0x0f)
+	}
+	return fields
+}
+
+// readParquet reads the columns of the Parquet files we write, with nil
+// for nulls, and the number of rows.
+func readParquet(bs []byte) (map[string][]interface{}, int64, error) {
+	if len(bs) < 12 || string(bs[:4]) != "PAR1" || string(bs[len(bs)-4:]) != "PAR1" {
+		return nil, 0, errors.New("not a Parquet file")
+	}
+	n := int(binary.LittleEndian.Uint32(bs[len(bs)-8:]))
+	meta := &thriftReader{bs: bs[len(bs)-8-n : len(bs)-8]}
+	fm := meta.structure()
+	if meta.err != nil {
+		return nil, 0, meta.err
+	}
+	if meta.pos != n {
+		return nil, 0, fmt.Errorf("metadata is %d bytes, read %d", n, meta.pos)
+	}
+
+	schema := fm[2].([]interface{})
+	optional := make(map[string]bool)
+	for _, e := range schema[1:] {
+		e := e.(map[int]interface{})
+		optional[e[4].(string)] = e[3].(int64) == parquetOptional
+	}
+	rows := fm[3].(int64)
+
+	cols := make(map[string][]interface{})
+	for _, rg := range fm[4].([]interface{}) {
+		for _, cc := range rg.(map[int]interface{})[1].([]interface{}) {
+			md := cc.(map[int]interface{})[3].(map[int]interface{})
+			name := md[3].([]interface{})[0].(string)
+			off := int(md[9].(int64))
+			page := &thriftReader{bs: bs[off:]}
+			ph := page.structure()
+			if page.err != nil {
+				return nil, 0, page.err
+			}
+			size := int(ph[3].(int64))
+			if int64(page.pos+size) != md[7].(int64) {
+				return nil, 0, fmt.Errorf("%s: chunk size %d, page is %d", name, md[7], page.pos+size)
+			}
+			data := bs[off+page.pos : off+page.pos+size]
+			values := int(ph[5].(map[int]interface{})[1].(int64))
+
+			present := make([]bool, values)
+			for i := range present {
+				present[i] = true
+			}
+			if optional[name] {
+				l := int(binary.LittleEndian.Uint32(data))
+				levels := &thriftReader{bs: data[4 : 4+l]}
+				for i := 0; i < values; {
+					h := levels.uvarint()
+					if h&1 != 0 {
+						return nil, 0, errors.New("bit packed levels")
+					}
+					v := levels.byte()
+					for j := uint64(0); j < h>>1; j++ {
+						present[i] = v == 1
+						i++
+					}
+				}
+				data = data[4+l:]
+			}
+			for i := 0; i < values; i++ {
+				if !present[i] {
+					cols[name] = append(cols[name], nil)
+					continue
+				}
+				switch md[1].(int64) {
+				case parquetInt64:
+					cols[name] = append(cols[name], int64(binary.LittleEndian.Uint64(data)))
+					data = data[8:]
+				case parquetByteArray:
+					l := int(binary.LittleEndian.Uint32(data))
+					cols[name] = append(cols[name], string(data[4:4+l]))
+					data = data[4+l:]
+				}
+			}
+			if len(data) != 0 {
+				return nil, 0, fmt.Errorf("%s: %d bytes left in page", name, len(data))
+			}
+		}
+	}
+	return cols, rows, nil
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	a := &parquetColumn{name: "a", typ: parquetByteArray, converted: parquetUTF8, strs: []string{"x", "", "åäö"}}
+	b := &parquetColumn{name: "b", typ: parquetByteArray, converted: parquetUTF8, optional: true, strs: []string{"", "y", ""}, null: []bool{true, false, true}}
+	c := &parquetColumn{name: "c", typ: parquetInt64, converted: parquetTimestampMillis, ints: []int64{0, -1, 1 << 40}}
+	cols := []*parquetColumn{a, b, c}
+	// More columns than fit in the short form of a Thrift list header
+	for i := 0; i < 20; i++ {
+		cols = append(cols, &parquetColumn{name: fmt.Sprintf("pad%d", i), typ: parquetInt64, converted: -1, ints: []int64{1, 2, 3}})
+	}
+
+	var buf bytes.Buffer
+	if err := writeParquet(&buf, cols); err != nil {
+		t.Fatal(err)
+	}
+	got, rows, err := readParquet(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != 3 {
+		t.Errorf("%d rows, expected 3", rows)
+	}
+	expected := map[string][]interface{}{
+		"a": {"x", "", "åäö"},
+		"b": {nil, "y", nil},
+		"c": {int64(0), int64(-1), int64(1 << 40)},
+	}
+	for name, values := range expected {
+		if !reflect.DeepEqual(got[name], values) {
+			t.Errorf("column %s is %v, expected %v", name, got[name], values)
+		}
+	}
+	if len(got) != len(cols) {
+		t.Errorf("%d columns, expected %d", len(got), len(cols))
+	}
+}
+
+func TestCommitsParquet(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	state := filepath.Join(dir, ".git", "state.json")
+	mustRunMain(t, dir, "-exclude-pattern", "Bob", "export", state)
+	live, imported := filepath.Join(dir, "live.parquet"), filepath.Join(dir, "imported.parquet")
+	mustRunMain(t, dir, "-parquet", live, "-exclude-pattern", "Bob")
+	mustRunMain(t, dir, "-parquet", imported, "-exclude-pattern", "Bob", "import", state)
+
+	bs, err := ioutil.ReadFile(live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ibs, err := ioutil.ReadFile(imported); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(ibs, bs) {
+		t.Error("the imported Parquet file differs")
+	}
+
+	cols, rows, err := readParquet(bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != 4 {
+		t.Fatalf("%d rows, expected 4", rows)
+	}
+	// Newest first, with Bob's filtered out commit unattributed
+	if expected := []interface{}{"Carol C", "Alice A", "Alice A", nil}; !reflect.DeepEqual(cols["author"], expected) {
+		t.Errorf("authors %v, expected %v", cols["author"], expected)
+	}
+	if expected := []interface{}{"carol@example.com", "alice@example.com", "alice@example.com", "bob@example.com"}; !reflect.DeepEqual(cols["email"], expected) {
+		t.Errorf("emails %v, expected %v", cols["email"], expected)
+	}
+	if expected := []interface{}{"Add carol", "Improve alice", "Add alice", "Add bob"}; !reflect.DeepEqual(cols["subject"], expected) {
+		t.Errorf("subjects %v, expected %v", cols["subject"], expected)
+	}
+	if date := time.Date(2020, 4, 1, 12, 0, 0, 0, time.UTC).UnixNano() / 1e6; cols["date"][0] != date {
+		t.Errorf("date %v, expected %d", cols["date"][0], date)
+	}
+}
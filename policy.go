@@ -0,0 +1,78 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A policy is an allowlist of author identities. Entries are email
+// addresses, "@domain" for everyone at a domain, or GitHub user names.
+type policy struct {
+	emails  stringSet
+	domains stringSet
+	users   stringSet
+}
+
+func readPolicy(file string) policy {
+	return newPolicy(readLines(file))
+}
+
+func newPolicy(entries []string) policy {
+	p := policy{
+		emails:  make(stringSet),
+		domains: make(stringSet),
+		users:   make(stringSet),
+	}
+	for _, line := range entries {
+		line = strings.ToLower(line)
+		switch {
+		case strings.HasPrefix(line, "@"):
+			p.domains.add(line[1:])
+		case strings.Contains(line, "@"):
+			p.emails.add(line)
+		default:
+			p.users.add(line)
+		}
+	}
+	return p
+}
+
+// allows returns true if the email is covered by the policy.
+func (p policy) allows(email string) bool {
+	email = strings.ToLower(email)
+	if p.emails.has(email) || p.domains.has(emailDomain(email)) {
+		return true
+	}
+	if user := githubUsername(email); user != "" && p.users.has(user) {
+		return true
+	}
+	return false
+}
+
+// policyViolations returns the commits authored by identities outside
+// the policy, in the order given.
+func policyViolations(commits []commit, p policy) []commit {
+	var res []commit
+	for _, c := range commits {
+		if !p.allows(c.email) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+func writePolicyViolations(w io.Writer, commits []commit) error {
+	bw := bufio.NewWriter(w)
+	for _, c := range commits {
+		fmt.Fprintf(bw, "Outside author: %.12s %s <%s>\n", c.hash, c.name, c.email)
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,52 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestPolicyAllows(t *testing.T) {
+	p := newPolicy([]string{"Jane@Example.com", "@corp.example.com", "JDoe"})
+	cases := map[string]bool{
+		"jane@example.com":                       true,
+		"JANE@EXAMPLE.COM":                       true,
+		"joe@example.com":                        false,
+		"anyone@corp.example.com":                true,
+		"anyone@sub.corp.example.com":            false,
+		"jdoe@users.noreply.github.com":          true,
+		"12345+jdoe@users.noreply.github.com":    true,
+		"12345+someone@users.noreply.github.com": false,
+		"jdoe@example.com":                       false,
+	}
+	for email, expected := range cases {
+		if got := p.allows(email); got != expected {
+			t.Errorf("allows(%q) = %v, expected %v", email, got, expected)
+		}
+	}
+}
+
+func TestPolicyCommand(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	policy := writeTestFile(t, dir, "policy", "# Allowed authors\nAlice@Example.com\n")
+
+	stdout, _, code := runMain(t, dir, "-policy", policy)
+	if code != 1 {
+		t.Errorf("exit code %d with outside authors", code)
+	}
+	expected := regexp.MustCompile(`^Outside author: [0-9a-f]{12} Carol C <carol@example.com>\nOutside author: [0-9a-f]{12} Bob B <bob@example.com>\n$`)
+	if !expected.MatchString(stdout) {
+		t.Errorf("unexpected output\n%s", stdout)
+	}
+
+	// Only Alice committed in the range
+	if stdout, _, code := runMain(t, dir, "-policy", policy, "-policy-range", "HEAD~3..HEAD~1"); code != 0 || stdout != "" {
+		t.Errorf("exit code %d, output\n%s", code, stdout)
+	}
+}
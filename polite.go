@@ -0,0 +1,216 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxRateLimitWait is the longest we wait for an API rate limit to reset
+// before giving up.
+const maxRateLimitWait = 5 * time.Minute
+
+// A politeTransport keeps the API requests of scheduled runs from tripping
+// abuse detection: requests to each host are spaced out, failed requests
+// are retried with jittered backoff, and responses with an ETag are cached
+// so that repeated requests are conditional. GitHub doesn't count
+// conditional requests answered with 304 Not Modified against the rate
+// limit.
+type politeTransport struct {
+	base     http.RoundTripper
+	interval time.Duration // between requests to the same host
+	retries  int
+	cacheDir string // no caching if empty
+
+	mut  sync.Mutex
+	next map[string]time.Time // host -> earliest time for the next request
+}
+
+func newPoliteTransport(perMinute, retries int, cacheDir string) *politeTransport {
+	t := &politeTransport{
+		base:     http.DefaultTransport,
+		retries:  retries,
+		cacheDir: cacheDir,
+		next:     make(map[string]time.Time),
+	}
+	if perMinute > 0 {
+		t.interval = time.Minute / time.Duration(perMinute)
+	}
+	return t
+}
+
+func (t *politeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	idempotent := req.Method == "GET" || req.Method == "HEAD"
+	var cached *cachedResponse
+	if idempotent {
+		cached = t.load(req)
+		if cached != nil {
+			req = req.Clone(req.Context())
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		t.wait(req.URL.Host)
+		resp, err := t.base.RoundTrip(req)
+		if err == nil && resp.StatusCode == http.StatusNotModified && cached != nil {
+			resp.Body.Close()
+			return cached.response(req), nil
+		}
+		delay, retry := retryDelay(resp, err, attempt)
+		if !retry || !idempotent || attempt >= t.retries {
+			if err == nil && resp.StatusCode == http.StatusOK && idempotent {
+				return t.store(req, resp)
+			}
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		time.Sleep(delay)
+	}
+}
+
+// wait blocks until the next request to the host is allowed.
+func (t *politeTransport) wait(host string) {
+	if t.interval == 0 {
+		return
+	}
+	t.mut.Lock()
+	now := time.Now()
+	at := t.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	t.next[host] = at.Add(t.interval)
+	t.mut.Unlock()
+	time.Sleep(at.Sub(now))
+}
+
+// retryDelay returns how long to wait before retrying the request, and
+// whether to retry at all: after network errors, server errors and rate
+// limiting, but not other client errors.
+func retryDelay(resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	backoff := time.Second << uint(attempt)
+	jittered := backoff/2 + time.Duration(rand.Int63n(int64(backoff)))
+	if err != nil {
+		return jittered, true
+	}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(secs) * time.Second, time.Duration(secs)*time.Second <= maxRateLimitWait
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return jittered, true
+	case http.StatusForbidden:
+		if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+			return 0, false
+		}
+		reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+		if err != nil {
+			return jittered, true
+		}
+		wait := time.Until(time.Unix(reset, 0)) + jittered
+		return wait, wait <= maxRateLimitWait
+	}
+	return 0, false
+}
+
+// A cachedResponse is a response with an ETag, kept for conditional
+// requests.
+type cachedResponse struct {
+	ETag   string      `json:"etag"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+func (c *cachedResponse) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        c.Header,
+		Body:          ioutil.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+}
+
+// cacheFile returns the cache file for the request. The credentials are
+// part of the key, as they may change the response.
+func (t *politeTransport) cacheFile(req *http.Request) string {
+	h := sha256.Sum256([]byte(req.Method + " " + req.URL.String() + " " + req.Header.Get("Authorization")))
+	return filepath.Join(t.cacheDir, hex.EncodeToString(h[:16])+".json")
+}
+
+func (t *politeTransport) load(req *http.Request) *cachedResponse {
+	if t.cacheDir == "" {
+		return nil
+	}
+	bs, err := ioutil.ReadFile(t.cacheFile(req))
+	if err != nil {
+		return nil
+	}
+	var c cachedResponse
+	if err := json.Unmarshal(bs, &c); err != nil || c.ETag == "" {
+		return nil
+	}
+	return &c
+}
+
+// store caches the response if it has an ETag, returning a response to
+// use in its place.
+func (t *politeTransport) store(req *http.Request, resp *http.Response) (*http.Response, error) {
+	etag := resp.Header.Get("ETag")
+	if t.cacheDir == "" || etag == "" {
+		return resp, nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	c := &cachedResponse{ETag: etag, Header: resp.Header, Body: body}
+	if bs, err := json.Marshal(c); err == nil {
+		if err := os.MkdirAll(t.cacheDir, 0755); err == nil {
+			file := t.cacheFile(req)
+			err := writeFileAtomic(file, false, func(w io.Writer) error {
+				_, err := w.Write(bs)
+				return err
+			})
+			if err != nil {
+				warn(warning{Kind: warnCache, File: file, Message: err.Error()})
+			}
+		}
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// defaultAPICache returns the directory for cached API responses in the
+// user's cache directory, or the empty string if there is none.
+func defaultAPICache() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "git-contributors", "http")
+}
@@ -0,0 +1,173 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	response := func(code int, header ...string) *http.Response {
+		resp := &http.Response{StatusCode: code, Header: make(http.Header)}
+		for i := 0; i < len(header); i += 2 {
+			resp.Header.Set(header[i], header[i+1])
+		}
+		return resp
+	}
+	reset := func(d time.Duration) string { return strconv.FormatInt(time.Now().Add(d).Unix(), 10) }
+
+	cases := []struct {
+		what  string
+		resp  *http.Response
+		err   error
+		retry bool
+	}{
+		{"network error", nil, errors.New("connection reset"), true},
+		{"unavailable", response(http.StatusServiceUnavailable), nil, true},
+		{"too many requests", response(http.StatusTooManyRequests), nil, true},
+		{"not found", response(http.StatusNotFound), nil, false},
+		{"forbidden", response(http.StatusForbidden, "X-RateLimit-Remaining", "10"), nil, false},
+		{"rate limited", response(http.StatusForbidden, "X-RateLimit-Remaining", "0", "X-RateLimit-Reset", reset(time.Minute)), nil, true},
+		{"rate limited for long", response(http.StatusForbidden, "X-RateLimit-Remaining", "0", "X-RateLimit-Reset", reset(time.Hour)), nil, false},
+		{"retry after", response(http.StatusForbidden, "Retry-After", "2"), nil, true},
+		{"retry much later", response(http.StatusTooManyRequests, "Retry-After", "3600"), nil, false},
+	}
+	for _, c := range cases {
+		delay, retry := retryDelay(c.resp, c.err, 0)
+		if retry != c.retry {
+			t.Errorf("%s: retry %v, expected %v", c.what, retry, c.retry)
+		}
+		if retry && (delay < 0 || delay > maxRateLimitWait) {
+			t.Errorf("%s: delay %v", c.what, delay)
+		}
+	}
+	if delay, _ := retryDelay(response(http.StatusForbidden, "Retry-After", "2"), nil, 0); delay != 2*time.Second {
+		t.Errorf("delay %v for Retry-After: 2", delay)
+	}
+}
+
+func TestPoliteRetries(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests%2 == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: newPoliteTransport(0, 3, "")}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || requests != 2 {
+		t.Errorf("status %d after %d requests", resp.StatusCode, requests)
+	}
+
+	// Only idempotent requests are retried
+	requests = 0
+	resp, err = client.Post(srv.URL, "text/plain", strings.NewReader("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || requests != 1 {
+		t.Errorf("POST status %d after %d requests", resp.StatusCode, requests)
+	}
+
+	requests = 0
+	client = &http.Client{Transport: newPoliteTransport(0, 0, "")}
+	resp, err = client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || requests != 1 {
+		t.Errorf("status %d after %d requests without retries", resp.StatusCode, requests)
+	}
+}
+
+func TestPoliteETag(t *testing.T) {
+	var full, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, "body")
+	}))
+	defer srv.Close()
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+
+	get := func(transport http.RoundTripper) string {
+		t.Helper()
+		resp, err := (&http.Client{Transport: transport}).Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		bs, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("status %d", resp.StatusCode)
+		}
+		return string(bs)
+	}
+
+	// The cache outlives the transport
+	for i := 0; i < 3; i++ {
+		if body := get(newPoliteTransport(0, 0, dir)); body != "body" {
+			t.Errorf("body %q", body)
+		}
+	}
+	if full != 1 || notModified != 2 {
+		t.Errorf("%d full and %d conditional requests, expected 1 and 2", full, notModified)
+	}
+
+	get(newPoliteTransport(0, 0, ""))
+	if full != 2 {
+		t.Errorf("%d full requests without a cache, expected 2", full)
+	}
+}
+
+func TestPoliteRate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: newPoliteTransport(1200, 0, "")}
+	t0 := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	// 50 ms between requests
+	if d := time.Since(t0); d < 100*time.Millisecond {
+		t.Errorf("three requests in %v at 1200 a minute", d)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// A profile is what a contributor says about themselves in a file of
+// their own in the contributors.d directory, so that nobody needs to
+// edit a shared file. The emails identify the author; the rest is
+// optional and overrides what we'd otherwise show.
+type profile struct {
+	Emails   []string `yaml:"emails"`
+	Name     string   `yaml:"name,omitempty"`
+	Pronouns string   `yaml:"pronouns,omitempty"`
+	URL      string   `yaml:"url,omitempty"`
+	Avatar   string   `yaml:"avatar,omitempty"` // image URL
+}
+
+// readProfiles reads the *.yaml and *.yml files in the directory, in name
+// order, keyed by file name.
+func readProfiles(dir string) (map[string]profile, []string) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		fatal(err)
+	}
+	profiles := make(map[string]profile)
+	var files []string
+	for _, e := range entries {
+		if e.IsDir() || !isYAMLFile(e.Name()) {
+			continue
+		}
+		file := filepath.Join(dir, e.Name())
+		var p profile
+		if err := yaml.UnmarshalStrict(readAll(file), &p); err != nil {
+			fatalf("%s: %v", file, err)
+		}
+		if len(p.Emails) == 0 {
+			fatalf("%s: no emails to identify the contributor by", file)
+		}
+		profiles[file] = p
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return profiles, files
+}
+
+// applyProfiles applies the profiles to the authors they match by email.
+// Profiles matching no author, or several, are warned about and ignored.
+func applyProfiles(authors []author, idx *authorIndex, dir string) {
+	profiles, files := readProfiles(dir)
+	for _, file := range files {
+		p := profiles[file]
+		matched := make(map[int]bool)
+		for _, e := range p.Emails {
+			if i, ok := idx.email(e); ok {
+				matched[i] = true
+			}
+		}
+		if len(matched) != 1 {
+			warn(warning{Kind: warnProfile, File: file, Message: fmt.Sprintf("matches %d authors, not one", len(matched))})
+			continue
+		}
+		for i := range matched {
+			a := &authors[i]
+			if p.Name != "" {
+				a.name = p.Name
+			}
+			a.pronouns = p.Pronouns
+			if p.URL != "" {
+				a.urls = append([]string{p.URL}, removeString(a.urls, p.URL)...)
+			}
+			a.chosenAvatar = p.Avatar
+		}
+	}
+}
+
+// removeString returns the strings other than s.
+func removeString(ss []string, s string) []string {
+	var res []string
+	for _, e := range ss {
+		if e != s {
+			res = append(res, e)
+		}
+	}
+	return res
+}
@@ -0,0 +1,71 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	profiles := filepath.Join(dir, "contributors.d")
+	if err := os.Mkdir(profiles, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, profiles, "alice.yaml", "emails: [alice@example.com]\nname: Alice Anderson\npronouns: she/her\nurl: https://alice.example.com\n")
+	writeTestFile(t, profiles, "nobody.yml", "emails: [nobody@example.com]\n")
+	writeTestFile(t, profiles, "README", "Not a profile\n")
+
+	stdout, stderr, code := runMain(t, dir, "-profiles", profiles, "-json")
+	if code != 0 {
+		t.Fatalf("exit code %d\n%s", code, stderr)
+	}
+	if !strings.Contains(stderr, "nobody.yml: matches 0 authors, not one") {
+		t.Errorf("no warning for the unmatched profile\n%s", stderr)
+	}
+	var authors []struct {
+		Name     string `json:"name"`
+		URL      string `json:"url"`
+		Pronouns string `json:"pronouns"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &authors); err != nil {
+		t.Fatalf("%v:\n%s", err, stdout)
+	}
+	if len(authors) != 3 {
+		t.Fatalf("%d authors, expected 3", len(authors))
+	}
+	if a := authors[0]; a.Name != "Alice Anderson" || a.URL != "https://alice.example.com" || a.Pronouns != "she/her" {
+		t.Errorf("profile not applied: %+v", a)
+	}
+	if a := authors[1]; a.Name != "Bob B" || a.URL != "" || a.Pronouns != "" {
+		t.Errorf("profile applied to another author: %+v", a)
+	}
+}
+
+func TestProfileErrors(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	for contents, msg := range map[string]string{
+		"name: Alice A\n": "no emails to identify the contributor by",
+		"emails: [alice@example.com]\nmail: alice@example.net\n": "field mail not found",
+	} {
+		profiles := filepath.Join(dir, "contributors.d")
+		os.RemoveAll(profiles)
+		if err := os.Mkdir(profiles, 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeTestFile(t, profiles, "alice.yaml", contents)
+		if _, stderr, code := runMain(t, dir, "-profiles", profiles, "-authors"); code == 0 || !strings.Contains(stderr, msg) {
+			t.Errorf("%q: exit code %d, expected %q in\n%s", contents, code, msg, stderr)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// releaseWeeks is the number of week buckets before a release, the last
+// one holding everything older.
+const releaseWeeks = 5
+
+// A release is a release tag and the time it was made.
+type release struct {
+	tag  string
+	date time.Time
+}
+
+// releaseTags returns the tags matching the glob pattern, oldest first.
+func releaseTags(pattern string) []release {
+	cmd := exec.Command("git", "for-each-ref", "--sort=creatordate", "--format=%(refname:short)%1f%(creatordate:unix)", "refs/tags/"+pattern)
+	cmd.Stderr = os.Stderr
+	bs, err := cmd.Output()
+	if err != nil {
+		fatal("git:", err)
+	}
+	var releases []release
+	for _, line := range strings.Split(string(bs), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 2 {
+			continue
+		}
+		secs, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		releases = append(releases, release{fields[0], time.Unix(secs, 0)})
+	}
+	return releases
+}
+
+// releasedIn returns a map from commit hash to the first of the releases
+// that contains it.
+func releasedIn(releases []release) map[string]release {
+	res := make(map[string]release)
+	var prev []string
+	for _, r := range releases {
+		args := append([]string{"rev-list", r.tag, "--not"}, prev...)
+		cmd := exec.Command("git", args...)
+		cmd.Stderr = os.Stderr
+		bs, err := cmd.Output()
+		if err != nil {
+			fatal("git:", err)
+		}
+		for _, hash := range strings.Fields(string(bs)) {
+			res[hash] = r
+		}
+		prev = append(prev, r.tag)
+	}
+	return res
+}
+
+// getReleaseVelocity counts the commits of each author by the number of
+// weeks between the commit and the release it first went into. Unreleased
+// commits are not counted.
+func getReleaseVelocity(authors []author, idx *authorIndex, commits []commit, released map[string]release) {
+	for _, c := range commits {
+		r, ok := released[c.hash]
+		if !ok {
+			continue
+		}
+		i, ok := idx.email(c.email)
+		if !ok {
+			continue
+		}
+		week := int(r.date.Sub(c.date).Hours() / 24 / 7)
+		if week < 0 {
+			week = 0
+		}
+		if week >= releaseWeeks {
+			week = releaseWeeks - 1
+		}
+		if authors[i].releaseWeeks == nil {
+			authors[i].releaseWeeks = make([]int, releaseWeeks)
+		}
+		authors[i].releaseWeeks[week]++
+	}
+}
+
+// writeReleaseVelocity writes the released commits of each author by the
+// number of weeks before the release, and the totals.
+func writeReleaseVelocity(w io.Writer, authors []author) error {
+	bw := bufio.NewWriter(w)
+	for week := 0; week < releaseWeeks; week++ {
+		label := fmt.Sprintf("wk-%d", week)
+		if week == releaseWeeks-1 {
+			label += "+"
+		}
+		fmt.Fprintf(bw, "%6s ", label)
+	}
+	fmt.Fprintf(bw, "name\n")
+
+	total := make([]int, releaseWeeks)
+	for _, a := range authors {
+		if a.releaseWeeks == nil {
+			continue
+		}
+		for week, n := range a.releaseWeeks {
+			fmt.Fprintf(bw, "%6d ", n)
+			total[week] += n
+		}
+		fmt.Fprintf(bw, "%s\n", a.displayName())
+	}
+	for _, n := range total {
+		fmt.Fprintf(bw, "%6d ", n)
+	}
+	fmt.Fprintf(bw, "(total)\n")
+	return bw.Flush()
+}
@@ -0,0 +1,66 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetReleaseVelocity(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	authors := []author{
+		{name: "Alice A", emails: []string{"alice@example.com"}},
+		{name: "Bob B", emails: []string{"bob@example.com"}},
+		{name: "Carol C", emails: []string{"carol@example.com"}},
+	}
+	v1 := release{tag: "v1", date: day("2020-03-01")}
+	released := map[string]release{"a1": v1, "a2": v1, "a3": v1, "b1": v1}
+	commits := []commit{
+		{hash: "a1", email: "alice@example.com", date: day("2020-02-28")},
+		{hash: "a2", email: "alice@example.com", date: day("2020-02-20")},
+		{hash: "a3", email: "alice@example.com", date: day("2019-01-01")},
+		{hash: "b1", email: "bob@example.com", date: day("2020-03-02")},
+		{hash: "c1", email: "carol@example.com", date: day("2020-03-02")},
+	}
+	getReleaseVelocity(authors, newAuthorIndex(authors), commits, released)
+	if expected := []int{1, 1, 0, 0, 1}; !reflect.DeepEqual(authors[0].releaseWeeks, expected) {
+		t.Errorf("Alice %v, expected %v", authors[0].releaseWeeks, expected)
+	}
+	// Committed after the release was tagged, as with a rebased branch
+	if expected := []int{1, 0, 0, 0, 0}; !reflect.DeepEqual(authors[1].releaseWeeks, expected) {
+		t.Errorf("Bob %v, expected %v", authors[1].releaseWeeks, expected)
+	}
+	if authors[2].releaseWeeks != nil {
+		t.Errorf("Carol %v, expected nothing released", authors[2].releaseWeeks)
+	}
+}
+
+func TestReleaseVelocity(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	runGit(t, dir, "tag", "v0.1", "HEAD~3")
+	runGit(t, dir, "tag", "v0.2", "HEAD~1")
+	runGit(t, dir, "tag", "other", "HEAD")
+
+	// The tags are made now, long after the commits
+	out := mustRunMain(t, dir, "-release-velocity")
+	expected := "  wk-0   wk-1   wk-2   wk-3  wk-4+ name\n" +
+		"     0      0      0      0      2 Alice A\n" +
+		"     0      0      0      0      1 Bob B\n" +
+		"     0      0      0      0      3 (total)\n"
+	if out != expected {
+		t.Errorf("output\n%s\nexpected\n%s", out, expected)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// A repository is where git finds the repository we run against, which
+// need not be the current directory: GIT_DIR and GIT_WORK_TREE may point
+// elsewhere, and we may be in a subdirectory or a linked worktree.
+type repository struct {
+	gitDir    string // the git directory of this worktree
+	commonDir string // the git directory shared by all worktrees
+	workTree  string // top level of the work tree, empty if bare
+}
+
+var (
+	repoOnce sync.Once
+	repo     repository
+	repoErr  error
+)
+
+// currentRepository returns the repository, as found by git.
+func currentRepository() (repository, error) {
+	repoOnce.Do(func() {
+		repo, repoErr = findRepository()
+	})
+	return repo, repoErr
+}
+
+func findRepository() (repository, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "rev-parse", "--absolute-git-dir", "--git-common-dir", "--is-bare-repository")
+	cmd.Stderr = &stderr
+	bs, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(strings.TrimPrefix(stderr.String(), "fatal: "))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return repository{}, fmt.Errorf("%s; run in a git repository, or set GIT_DIR (and GIT_WORK_TREE) to one", msg)
+	}
+	lines := strings.Split(strings.TrimSpace(string(bs)), "\n")
+	if len(lines) != 3 {
+		return repository{}, errors.New("unexpected output from git rev-parse")
+	}
+
+	r := repository{gitDir: lines[0], commonDir: lines[1]}
+	if !filepath.IsAbs(r.commonDir) {
+		// Relative to the current directory
+		if r.commonDir, err = filepath.Abs(r.commonDir); err != nil {
+			return repository{}, err
+		}
+	}
+	if lines[2] == "true" {
+		return r, nil
+	}
+
+	bs, err = exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return repository{}, fmt.Errorf("git rev-parse --show-toplevel: %w", err)
+	}
+	r.workTree = strings.TrimSpace(string(bs))
+	return r, nil
+}
+
+// bare returns true if there is no work tree.
+func (r repository) bare() bool {
+	return r.workTree == ""
+}
+
+// path returns the path of the file given relative to the top of the work
+// tree, or the empty string if bare.
+func (r repository) path(rel string) string {
+	if r.bare() {
+		return ""
+	}
+	return filepath.Join(r.workTree, filepath.FromSlash(rel))
+}
+
+// name returns the name of the repository: the directory containing the
+// main work tree, or the bare repository's directory without any .git
+// suffix. Linked worktrees have the name of the main one.
+func (r repository) name() string {
+	dir := r.commonDir
+	if filepath.Base(dir) == ".git" {
+		dir = filepath.Dir(dir)
+	}
+	return strings.TrimSuffix(filepath.Base(dir), ".git")
+}
+
+// readBlob returns the contents of the file at the path in the revision,
+// given as "rev:path".
+func readBlob(spec string) ([]byte, error) {
+	if !strings.Contains(spec, ":") {
+		return nil, fmt.Errorf("%q is not of the form rev:path", spec)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", "cat-file", "blob", spec)
+	cmd.Stderr = &stderr
+	bs, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", spec, strings.TrimSpace(strings.TrimPrefix(stderr.String(), "fatal: ")))
+	}
+	return bs, nil
+}
+
+// revision returns the revision part of a "rev:path" spec.
+func revision(spec string) string {
+	if i := strings.Index(spec, ":"); i >= 0 {
+		return spec[:i]
+	}
+	return spec
+}
+
+// getAuthorsRef reads the AUTHORS file at the path in the revision, given
+// as "rev:path".
+func getAuthorsRef(spec string) []author {
+	bs, err := readBlob(spec)
+	if err != nil {
+		fatal(err)
+	}
+	if isYAMLFile(spec) || looksLikeYAML(bs) {
+		authors, err := parseYAMLAuthors(bs)
+		if err != nil {
+			fatalf("%s: %v", spec, err)
+		}
+		return authors
+	}
+	return parseAuthors(bs)
+}
+
+// mustRepository returns the repository, exiting with an explanation if
+// there isn't one.
+func mustRepository() repository {
+	r, err := currentRepository()
+	if err != nil {
+		fatal(err)
+	}
+	return r
+}
@@ -0,0 +1,237 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tempDir returns a new temporary directory, with symlinks resolved so
+// that it compares equal to the paths git reports, and a function to
+// remove it.
+func tempDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "git-contributors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		t.Fatal(err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+// runGit runs git in the directory, outside of any GIT_DIR or
+// GIT_WORK_TREE set by the test.
+func runGit(t *testing.T, dir string, args ...string) {
+	args = append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	for _, e := range os.Environ() {
+		if !hasEnvPrefix(e, "GIT_DIR=", "GIT_WORK_TREE=") {
+			cmd.Env = append(cmd.Env, e)
+		}
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func hasEnvPrefix(e string, prefixes ...string) bool {
+	for _, p := range prefixes {
+		if len(e) >= len(p) && e[:len(p)] == p {
+			return true
+		}
+	}
+	return false
+}
+
+// newTestRepo creates a repository in the directory, with a commit of a
+// file in a subdirectory.
+func newTestRepo(t *testing.T, dir string) {
+	runGit(t, dir, "init", "-q")
+	if err := os.MkdirAll(filepath.Join(dir, "sub", "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "sub", "dir", "file"), []byte("file\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "initial")
+}
+
+// inEnv runs fn in the directory with the environment variables set, and
+// restores the current directory and environment afterwards.
+func inEnv(t *testing.T, dir string, env map[string]string, fn func()) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"GIT_DIR", "GIT_WORK_TREE"} {
+		if old, ok := os.LookupEnv(k); ok {
+			defer os.Setenv(k, old)
+		} else {
+			defer os.Unsetenv(k)
+		}
+		os.Unsetenv(k)
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+	}
+	fn()
+}
+
+func checkRepository(t *testing.T, what string, want repository, wantName string) {
+	t.Helper()
+	r, err := findRepository()
+	if err != nil {
+		t.Fatalf("%s: %v", what, err)
+	}
+	if r != want {
+		t.Errorf("%s: found %+v; want %+v", what, r, want)
+	}
+	if r.name() != wantName {
+		t.Errorf("%s: name %q; want %q", what, r.name(), wantName)
+	}
+}
+
+func TestFindRepositorySubdirectory(t *testing.T) {
+	tmp, cleanup := tempDir(t)
+	defer cleanup()
+	top := filepath.Join(tmp, "project")
+	os.Mkdir(top, 0755)
+	newTestRepo(t, top)
+
+	want := repository{
+		gitDir:    filepath.Join(top, ".git"),
+		commonDir: filepath.Join(top, ".git"),
+		workTree:  top,
+	}
+	inEnv(t, filepath.Join(top, "sub", "dir"), nil, func() {
+		checkRepository(t, "subdirectory", want, "project")
+	})
+}
+
+func TestFindRepositoryGitDir(t *testing.T) {
+	tmp, cleanup := tempDir(t)
+	defer cleanup()
+	top := filepath.Join(tmp, "project")
+	os.Mkdir(top, 0755)
+	newTestRepo(t, top)
+	bare := filepath.Join(tmp, "project.git")
+	runGit(t, tmp, "clone", "-q", "--bare", top, bare)
+	elsewhere := filepath.Join(tmp, "elsewhere")
+	os.Mkdir(elsewhere, 0755)
+
+	// A bare repository given by GIT_DIR alone
+	want := repository{gitDir: bare, commonDir: bare}
+	inEnv(t, elsewhere, map[string]string{"GIT_DIR": bare}, func() {
+		checkRepository(t, "GIT_DIR", want, "project")
+	})
+
+	// The work tree given by GIT_WORK_TREE, while in another directory
+	want = repository{
+		gitDir:    filepath.Join(top, ".git"),
+		commonDir: filepath.Join(top, ".git"),
+		workTree:  top,
+	}
+	env := map[string]string{"GIT_DIR": filepath.Join(top, ".git"), "GIT_WORK_TREE": top}
+	inEnv(t, elsewhere, env, func() {
+		checkRepository(t, "GIT_WORK_TREE", want, "project")
+	})
+}
+
+func TestFindRepositoryLinkedWorktree(t *testing.T) {
+	tmp, cleanup := tempDir(t)
+	defer cleanup()
+	top := filepath.Join(tmp, "project")
+	os.Mkdir(top, 0755)
+	newTestRepo(t, top)
+	linked := filepath.Join(tmp, "feature")
+	runGit(t, top, "worktree", "add", "-q", "-b", "feature", linked)
+
+	want := repository{
+		gitDir:    filepath.Join(top, ".git", "worktrees", "feature"),
+		commonDir: filepath.Join(top, ".git"),
+		workTree:  linked,
+	}
+	inEnv(t, filepath.Join(linked, "sub", "dir"), nil, func() {
+		checkRepository(t, "linked worktree", want, "project")
+	})
+}
+
+// committedAuthors is a history with the top level and lib/a AUTHORS
+// files committed and up to date.
+var committedAuthors = []testCommit{
+	{author: "Alice A <alice@example.com>", date: "2020-01-01T12:00:00Z", message: "One", files: map[string]string{"lib/a/file": "x", "top": "y"}},
+	{author: "Bob B <bob@example.com>", date: "2020-02-01T12:00:00Z", message: "Two", files: map[string]string{"lib/a/file": "xz"}},
+	{author: "Alice A <alice@example.com>", date: "2020-03-01T12:00:00Z", message: "Add AUTHORS", files: map[string]string{
+		"AUTHORS":       "Alice A <alice@example.com>\nBob B <bob@example.com>\n",
+		"lib/a/AUTHORS": "Alice A <alice@example.com>\nBob B <bob@example.com>\n",
+	}},
+}
+
+func TestBareRepository(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, committedAuthors...)
+	defer cleanup()
+	bare := filepath.Join(dir, "project.git")
+	runGit(t, dir, "clone", "-q", "--bare", dir, bare)
+	scopes := writeTestFile(t, dir, "scopes", "lib/a/\n")
+
+	if out := mustRunMain(t, bare, "-stats"); out != "    2  1 Alice A\n    1  0 Bob B\n" {
+		t.Errorf("unexpected output\n%s", out)
+	}
+	if stdout, stderr, code := runMain(t, bare, "-read-authors-ref", "HEAD:AUTHORS", "-scopes", scopes, "-check"); code != 0 {
+		t.Errorf("exit code %d checking the committed files\n%s%s", code, stdout, stderr)
+	}
+	if _, stderr, code := runMain(t, bare, "-scopes", scopes, "-write-scopes"); code == 0 || !strings.Contains(stderr, "can't write scoped AUTHORS files without a work tree") {
+		t.Errorf("exit code %d writing scopes\n%s", code, stderr)
+	}
+}
+
+func TestReadAuthorsRef(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, committedAuthors...)
+	defer cleanup()
+	scopes := writeTestFile(t, dir, "scopes", "lib/a/\n")
+	// Local edits, not committed
+	authors := writeTestFile(t, dir, "AUTHORS", "Alice A <alice@example.com>\n")
+	writeTestFile(t, dir, "lib/a/AUTHORS", "Alice A <alice@example.com>\n")
+
+	if _, _, code := runMain(t, dir, "-read-authors", authors, "-check"); code != 1 {
+		t.Errorf("exit code %d checking the edited file", code)
+	}
+	if stdout, stderr, code := runMain(t, dir, "-read-authors-ref", "HEAD:AUTHORS", "-scopes", scopes, "-check"); code != 0 {
+		t.Errorf("exit code %d checking the committed files\n%s%s", code, stdout, stderr)
+	}
+
+	// The scopes are compared as committed in the same revision
+	commitFiles(t, dir, testCommit{author: "Alice A <alice@example.com>", date: "2020-04-01T12:00:00Z", message: "Drop Bob"})
+	stdout, _, code := runMain(t, dir, "-read-authors-ref", "HEAD:AUTHORS", "-scopes", scopes, "-check")
+	if code != 1 || !strings.Contains(stdout, "lib/a/AUTHORS:\nMissing author: Bob B <bob@example.com>\n") {
+		t.Errorf("exit code %d checking the edits as committed\n%s", code, stdout)
+	}
+	if stdout, stderr, code := runMain(t, dir, "-read-authors-ref", "HEAD~1:AUTHORS", "-scopes", scopes, "-check"); code != 0 {
+		t.Errorf("exit code %d checking the previous revision\n%s%s", code, stdout, stderr)
+	}
+
+	for spec, msg := range map[string]string{
+		"HEAD":         `"HEAD" is not of the form rev:path`,
+		"HEAD:missing": "HEAD:missing: ",
+	} {
+		if _, stderr, code := runMain(t, dir, "-read-authors-ref", spec, "-check"); code == 0 || !strings.Contains(stderr, msg) {
+			t.Errorf("exit code %d for %s\n%s", code, spec, stderr)
+		}
+	}
+}
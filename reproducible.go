@@ -0,0 +1,43 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// now returns the time that the analysis and outputs are relative to. That
+// is the present or, with -reproducible, a time fixed by the sources: the
+// $SOURCE_DATE_EPOCH of reproducible builds if set, else the commit time
+// of HEAD.
+func (opts *options) now() time.Time {
+	if !opts.reproducible {
+		return time.Now()
+	}
+	if opts.fixedNow.IsZero() {
+		opts.fixedNow = sourceDate()
+	}
+	return opts.fixedNow
+}
+
+func sourceDate() time.Time {
+	if s := os.Getenv("SOURCE_DATE_EPOCH"); s != "" {
+		if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return time.Unix(secs, 0).UTC()
+		}
+	}
+	if bs, err := exec.Command("git", "log", "-1", "--format=%ct", "HEAD").Output(); err == nil {
+		if secs, err := strconv.ParseInt(strings.TrimSpace(string(bs)), 10, 64); err == nil {
+			return time.Unix(secs, 0).UTC()
+		}
+	}
+	return time.Unix(0, 0).UTC()
+}
@@ -0,0 +1,68 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNow(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1577880000")
+	if now := (&options{}).now(); time.Since(now) > time.Minute {
+		t.Errorf("now %v without -reproducible", now)
+	}
+	opts := &options{reproducible: true}
+	if now := opts.now(); !now.Equal(time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("now %v, expected $SOURCE_DATE_EPOCH", now)
+	}
+	// Fixed once known
+	t.Setenv("SOURCE_DATE_EPOCH", "0")
+	if now := opts.now(); now.Year() != 2020 {
+		t.Errorf("now %v changed", now)
+	}
+}
+
+func TestReproducible(t *testing.T) {
+	t.Setenv("GIT_COMMITTER_DATE", "2020-04-01T12:00:00Z")
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+
+	// Times are relative to the HEAD commit
+	out := mustRunMain(t, dir, "-reproducible", "-metadata", "-authors")
+	if !containsLine(out, "# git-contributors-generated: 2020-04-01T12:00:00Z") {
+		t.Errorf("unexpected output\n%s", out)
+	}
+	t.Setenv("SOURCE_DATE_EPOCH", "1600000000")
+	out = mustRunMain(t, dir, "-reproducible", "-metadata", "-authors")
+	if !containsLine(out, "# git-contributors-generated: 2020-09-13T12:26:40Z") {
+		t.Errorf("unexpected output with $SOURCE_DATE_EPOCH\n%s", out)
+	}
+
+	var exports [][]byte
+	for i := 0; i < 2; i++ {
+		if i > 0 {
+			time.Sleep(time.Second)
+		}
+		file := filepath.Join(dir, ".git", "state.json")
+		mustRunMain(t, dir, "-reproducible", "export", file)
+		bs, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exports = append(exports, bs)
+	}
+	if !bytes.Equal(exports[0], exports[1]) {
+		t.Errorf("exports differ\n%s\n%s", exports[0], exports[1])
+	}
+	if !bytes.Contains(exports[0], []byte(`"exported": "2020-09-13T12:26:40Z"`)) {
+		t.Errorf("unexpected export\n%s", exports[0])
+	}
+}
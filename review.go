@@ -0,0 +1,109 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// writeReview writes the changes the analysis proposes to the AUTHORS
+// file, as lines to be approved by a human before being applied:
+//
+//	add Name <email>...
+//	merge <email> into <email>
+//
+// A trailing "# ..." is a comment.
+func writeReview(w io.Writer, a *analysis, authorsFile string) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# Proposed changes to %s. Delete or edit lines as needed, then\n", authorsFile)
+	fmt.Fprintf(bw, "# apply them with: git-contributors -read-authors %s apply <this file>\n", authorsFile)
+	for _, au := range a.authors {
+		if !au.listed {
+			fmt.Fprintf(bw, "add %s", au.fullName())
+			for _, e := range au.emails {
+				fmt.Fprintf(bw, " <%s>", e)
+			}
+			fmt.Fprintf(bw, "  # commits: %d\n", au.commits)
+			continue
+		}
+		for _, e := range au.emails {
+			switch au.provenance[e] {
+			case provenanceName:
+				fmt.Fprintf(bw, "merge <%s> into <%s>  # %s, by name only\n", e, au.emails[0], au.name)
+			case provenanceKey:
+				fmt.Fprintf(bw, "merge <%s> into <%s>  # %s, by signing key\n", e, au.emails[0], au.name)
+			case provenanceSSO:
+				fmt.Fprintf(bw, "merge <%s> into <%s>  # %s, by SSO identity\n", e, au.emails[0], au.name)
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// applyReview applies the approved changes in the review file to the
+// AUTHORS file, recording them in the changelog if one is given.
+func applyReview(reviewFile, authorsFile, changelog string, backup bool, now time.Time) error {
+	old := getAuthors(authorsFile)
+	authors := getAuthors(authorsFile)
+	idx := newAuthorIndex(authors)
+
+	// The lines are numbered as in the file, comments included
+	for n, line := range strings.Split(string(readAll(reviewFile)), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) > 1 && fields[0] == "add":
+			added := parseAuthors([]byte(strings.TrimSpace(line[len("add"):])))[0]
+			if len(added.emails) == 0 {
+				return fmt.Errorf("%s:%d: no email to add", reviewFile, n+1)
+			}
+			if i, ok := idx.email(added.emails[0]); ok {
+				warn(warning{Kind: warnReview, File: reviewFile, Message: fmt.Sprintf("<%s> is already listed for %s", added.emails[0], authors[i].name)})
+				continue
+			}
+			authors = append(authors, added)
+			idx.add(authors, len(authors)-1)
+
+		case len(fields) == 4 && fields[0] == "merge" && fields[2] == "into":
+			email, target := strings.Trim(fields[1], "<>"), strings.Trim(fields[3], "<>")
+			i, ok := idx.email(target)
+			if !ok {
+				return fmt.Errorf("%s:%d: <%s> is not listed", reviewFile, n+1, target)
+			}
+			if j, ok := idx.email(email); ok {
+				warn(warning{Kind: warnReview, File: reviewFile, Message: fmt.Sprintf("<%s> is already listed for %s", email, authors[j].name)})
+				continue
+			}
+			authors[i].emails = append(authors[i].emails, email)
+			idx.addEmail(email, i)
+
+		default:
+			return fmt.Errorf("%s:%d: unrecognized line %q", reviewFile, n+1, line)
+		}
+	}
+
+	sortByName(authors)
+	placePinned(authors)
+	comments := readAuthorComments(readAll(authorsFile), isYAMLFile(authorsFile), old)
+	err := writeFileAtomic(authorsFile, backup, func(w io.Writer) error {
+		return writeAuthorsWithComments(w, authors, isYAMLFile(authorsFile), comments)
+	})
+	if err != nil || changelog == "" {
+		return err
+	}
+	return appendChangelog(changelog, authorsFile, old, authors, now)
+}
@@ -0,0 +1,92 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReviewApply(t *testing.T) {
+	commits := append(threeAuthors[:len(threeAuthors):len(threeAuthors)],
+		testCommit{author: "Alice A <alice@laptop.local>", date: "2020-05-01T12:00:00Z", message: "Fix alice", files: map[string]string{"alice.txt": "alice\nfixed\n"}})
+	dir, cleanup := newHistoryRepo(t, commits...)
+	defer cleanup()
+	authors := writeTestFile(t, dir, "AUTHORS", "# The authors\n\nAlice A <alice@example.com>\n")
+	review := filepath.Join(dir, "review.txt")
+
+	mustRunMain(t, dir, "-read-authors", authors, "review", review)
+	bs, err := ioutil.ReadFile(review)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"add Bob B <bob@example.com>  # commits: 1",
+		"add Carol C <carol@example.com>  # commits: 1",
+		"merge <alice@laptop.local> into <alice@example.com>  # Alice A, by name only",
+	} {
+		if !containsLine(string(bs), line) {
+			t.Errorf("review lacks %q:\n%s", line, bs)
+		}
+	}
+
+	// Carol is not approved
+	var approved []string
+	for _, line := range strings.Split(string(bs), "\n") {
+		if !strings.Contains(line, "Carol") {
+			approved = append(approved, line)
+		}
+	}
+	writeTestFile(t, dir, "review.txt", strings.Join(approved, "\n"))
+	mustRunMain(t, dir, "-read-authors", authors, "apply", review)
+	bs, err = ioutil.ReadFile(authors)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# The authors\n\nAlice A <alice@example.com> <alice@laptop.local>\nBob B <bob@example.com>\n"
+	if string(bs) != expected {
+		t.Errorf("applied\n%s\nexpected\n%s", bs, expected)
+	}
+
+	// Applying again changes nothing, with warnings
+	_, stderr, code := runMain(t, dir, "-read-authors", authors, "apply", review)
+	if code != 0 {
+		t.Errorf("exit code %d applying again:\n%s", code, stderr)
+	}
+	if !strings.Contains(stderr, "<bob@example.com> is already listed for Bob B") {
+		t.Errorf("no warning applying again:\n%s", stderr)
+	}
+	if bs, err := ioutil.ReadFile(authors); err != nil {
+		t.Fatal(err)
+	} else if string(bs) != expected {
+		t.Errorf("applied again\n%s\nexpected\n%s", bs, expected)
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	authors := writeTestFile(t, dir, "AUTHORS", "Alice A <alice@example.com>\n")
+	for review, msg := range map[string]string{
+		"add Bob B\n": "review.txt:1: no email to add",
+		"# comment\nmerge <bob@example.com> into <bob@example.net>\n": "review.txt:2: <bob@example.net> is not listed",
+		"remove <alice@example.com>\n":                                `review.txt:1: unrecognized line "remove <alice@example.com>"`,
+	} {
+		file := writeTestFile(t, dir, "review.txt", review)
+		_, stderr, code := runMain(t, dir, "-read-authors", authors, "apply", file)
+		if code == 0 || !strings.Contains(stderr, msg) {
+			t.Errorf("%q: exit code %d, expected %q in\n%s", review, code, msg, stderr)
+		}
+		if bs, err := ioutil.ReadFile(authors); err != nil {
+			t.Fatal(err)
+		} else if string(bs) != "Alice A <alice@example.com>\n" {
+			t.Errorf("%q: AUTHORS changed to\n%s", review, bs)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A rewriteRule changes email addresses before they are matched to
+// authors. The local parts are either an address's own, or "*" for any,
+// keeping it as is.
+type rewriteRule struct {
+	fromLocal, fromDomain string
+	toLocal, toDomain     string
+}
+
+// readRewrites reads the rewrite rules, one per line, like
+//
+//	*@oldcorp.com -> *@newcorp.com
+//	jdoe@oldcorp.com -> jane.doe@newcorp.com
+func readRewrites(file string) []rewriteRule {
+	var rules []rewriteRule
+	for _, line := range readLines(file) {
+		r, err := parseRewrite(line)
+		if err != nil {
+			fatalf("%s: %s: %v", file, line, err)
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+func parseRewrite(line string) (rewriteRule, error) {
+	parts := strings.Split(line, "->")
+	if len(parts) != 2 {
+		return rewriteRule{}, fmt.Errorf("not of the form from -> to")
+	}
+	fromLocal, fromDomain, ok1 := splitEmail(strings.TrimSpace(parts[0]))
+	toLocal, toDomain, ok2 := splitEmail(strings.TrimSpace(parts[1]))
+	if !ok1 || !ok2 {
+		return rewriteRule{}, fmt.Errorf("not an email address or *@domain")
+	}
+	if toLocal == "*" && fromLocal != "*" {
+		return rewriteRule{}, fmt.Errorf("* on the right requires * on the left")
+	}
+	return rewriteRule{
+		fromLocal:  fromLocal,
+		fromDomain: strings.ToLower(fromDomain),
+		toLocal:    toLocal,
+		toDomain:   toDomain,
+	}, nil
+}
+
+// splitEmail splits the address into local part and domain.
+func splitEmail(email string) (string, string, bool) {
+	email = strings.TrimSuffix(strings.TrimPrefix(email, "<"), ">")
+	idx := strings.LastIndexByte(email, '@')
+	if idx <= 0 || idx == len(email)-1 {
+		return "", "", false
+	}
+	return email[:idx], email[idx+1:], true
+}
+
+// rewrite returns the email as changed by the first matching rule, if
+// any. Domains compare case insensitively, local parts exactly.
+func rewrite(rules []rewriteRule, email string) string {
+	local, domain, ok := splitEmail(email)
+	if !ok {
+		return email
+	}
+	for _, r := range rules {
+		if !strings.EqualFold(domain, r.fromDomain) || (r.fromLocal != "*" && r.fromLocal != local) {
+			continue
+		}
+		if r.toLocal != "*" {
+			local = r.toLocal
+		}
+		return local + "@" + r.toDomain
+	}
+	return email
+}
+
+// rewriteCommits applies the rules to the commit author emails, in place.
+func rewriteCommits(commits []commit, rules []rewriteRule) {
+	if len(rules) == 0 {
+		return
+	}
+	rewritten := make(map[string]string)
+	for i, c := range commits {
+		to, ok := rewritten[c.email]
+		if !ok {
+			to = rewrite(rules, c.email)
+			rewritten[c.email] = to
+		}
+		commits[i].email = to
+	}
+}
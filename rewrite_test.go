@@ -0,0 +1,75 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRewrite(t *testing.T) {
+	var rules []rewriteRule
+	for _, line := range []string{
+		"jdoe@OldCorp.com -> jane.doe@newcorp.com",
+		"*@oldcorp.com -> *@newcorp.com",
+		"<build@ci.example.com> -> <release@example.com>",
+	} {
+		r, err := parseRewrite(line)
+		if err != nil {
+			t.Fatalf("%s: %v", line, err)
+		}
+		rules = append(rules, r)
+	}
+	cases := map[string]string{
+		"jdoe@oldcorp.com":     "jane.doe@newcorp.com",
+		"jdoe@OLDCORP.COM":     "jane.doe@newcorp.com",
+		"JDoe@oldcorp.com":     "JDoe@newcorp.com",
+		"bob@oldcorp.com":      "bob@newcorp.com",
+		"bob@sub.oldcorp.com":  "bob@sub.oldcorp.com",
+		"build@ci.example.com": "release@example.com",
+		"bob@example.com":      "bob@example.com",
+		"not an email":         "not an email",
+	}
+	for from, expected := range cases {
+		if got := rewrite(rules, from); got != expected {
+			t.Errorf("rewrite(%q) = %q, expected %q", from, got, expected)
+		}
+	}
+}
+
+func TestParseRewriteErrors(t *testing.T) {
+	for line, msg := range map[string]string{
+		"a@example.com":                       "not of the form from -> to",
+		"a@example.com -> b@example.com -> c": "not of the form from -> to",
+		"a@example.com -> example.com":        "not an email address or *@domain",
+		"a@example.com -> *@example.net":      "* on the right requires * on the left",
+	} {
+		if _, err := parseRewrite(line); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("%q: error %v, expected %q", line, err, msg)
+		}
+	}
+}
+
+func TestRewriteEmails(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Jane Doe <jdoe@oldcorp.com>", date: "2019-01-01T12:00:00Z", message: "Old"},
+		testCommit{author: "Jane D <jane.doe@newcorp.com>", date: "2020-01-01T12:00:00Z", message: "New"},
+		testCommit{author: "Bob B <bob@oldcorp.com>", date: "2020-02-01T12:00:00Z", message: "Bob"},
+	)
+	defer cleanup()
+	rules := writeTestFile(t, dir, "rewrites", "# Moved to newcorp\njdoe@oldcorp.com -> jane.doe@newcorp.com\n*@oldcorp.com -> *@newcorp.com\n")
+
+	out := mustRunMain(t, dir, "-rewrite-emails", rules, "-authors", "-no-name-matching")
+	if out != "Bob B <bob@newcorp.com>\nJane D <jane.doe@newcorp.com>\n" {
+		t.Errorf("unexpected output\n%s", out)
+	}
+
+	bad := writeTestFile(t, dir, "bad", "jdoe@oldcorp.com => jane.doe@newcorp.com\n")
+	if _, stderr, code := runMain(t, dir, "-rewrite-emails", bad, "-authors"); code == 0 || !strings.Contains(stderr, "not of the form from -> to") {
+		t.Errorf("exit code %d for a bad rule\n%s", code, stderr)
+	}
+}
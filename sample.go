@@ -0,0 +1,54 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+)
+
+// sampleCommits returns every nth commit or, if random, each commit with
+// a probability of one in n, repeatably for the same seed.
+func sampleCommits(commits []commit, n int, random bool, seed int64) []commit {
+	if n <= 1 {
+		return commits
+	}
+	rnd := rand.New(rand.NewSource(seed))
+	var res []commit
+	for i, c := range commits {
+		if random && rnd.Intn(n) == 0 || !random && i%n == 0 {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// scaleContributions turns the commit counts from a one in n sample into
+// estimates for the full history, with the geekrank to match. The other
+// statistics are left as they were in the sample.
+func scaleContributions(authors []author, n int) {
+	for i := range authors {
+		authors[i].commits *= n
+		authors[i].geekrank = geekrankOf(authors[i].commits)
+	}
+}
+
+// estimateNote is the label for outputs of a sampled analysis.
+func estimateNote(n int) string {
+	return fmt.Sprintf("estimated from a sample of 1 in %d commits", n)
+}
+
+// writeEstimateNote ends the plain text outputs of a sampled analysis,
+// which have no comments to carry the metadata, with the estimate note.
+func writeEstimateNote(w io.Writer, a *analysis) error {
+	if a.sample <= 1 {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "(%s)\n", estimateNote(a.sample))
+	return err
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSampleCommits(t *testing.T) {
+	var commits []commit
+	for i := 0; i < 100; i++ {
+		commits = append(commits, commit{hash: fmt.Sprint(i)})
+	}
+	hashes := func(cs []commit) []string {
+		var res []string
+		for _, c := range cs {
+			res = append(res, c.hash)
+		}
+		return res
+	}
+
+	if res := sampleCommits(commits, 1, false, 1); len(res) != len(commits) {
+		t.Errorf("%d commits sampled 1 in 1", len(res))
+	}
+	if res := hashes(sampleCommits(commits[:7], 3, false, 1)); !reflect.DeepEqual(res, []string{"0", "3", "6"}) {
+		t.Errorf("sampled %q, expected every third", res)
+	}
+
+	random := hashes(sampleCommits(commits, 4, true, 1))
+	if len(random) < 10 || len(random) > 40 {
+		t.Errorf("%d commits sampled 1 in 4 at random", len(random))
+	}
+	if again := hashes(sampleCommits(commits, 4, true, 1)); !reflect.DeepEqual(again, random) {
+		t.Errorf("sampled %q, then %q with the same seed", random, again)
+	}
+	if other := hashes(sampleCommits(commits, 4, true, 2)); reflect.DeepEqual(other, random) {
+		t.Error("the same sample with another seed")
+	}
+}
+
+func TestSample(t *testing.T) {
+	var commits []testCommit
+	for i := 0; i < 4; i++ {
+		commits = append(commits, testCommit{author: "Alice A <alice@example.com>", date: fmt.Sprintf("2020-01-0%dT12:00:00Z", i+1), message: "Alice"})
+	}
+	for i := 0; i < 2; i++ {
+		commits = append(commits, testCommit{author: "Bob B <bob@example.com>", date: fmt.Sprintf("2020-02-0%dT12:00:00Z", i+1), message: "Bob"})
+	}
+	dir, cleanup := newHistoryRepo(t, commits...)
+	defer cleanup()
+
+	stdout, stderr, code := runMain(t, dir, "-sample", "2", "-stats")
+	if code != 0 {
+		t.Fatalf("exit code %d\n%s", code, stderr)
+	}
+	if expected := "    4  2 Alice A\n    2  1 Bob B\n(estimated from a sample of 1 in 2 commits)\n"; stdout != expected {
+		t.Errorf("output\n%s\nexpected\n%s", stdout, expected)
+	}
+	if !strings.Contains(stderr, "Warning: commit counts are estimated from a sample of 1 in 2 commits") {
+		t.Errorf("no warning in\n%s", stderr)
+	}
+
+	if out := mustRunMain(t, dir, "-sample", "2", "-authors"); !containsLine(out, "# git-contributors-estimate: estimated from a sample of 1 in 2 commits") {
+		t.Errorf("no estimate note in\n%s", out)
+	}
+	if out := mustRunMain(t, dir, "-sample", "2", "-json"); !strings.Contains(out, `"sample": 2`) {
+		t.Errorf("no sample in JSON\n%s", out)
+	}
+	if out := mustRunMain(t, dir, "-stats"); strings.Contains(out, "estimated") {
+		t.Errorf("estimate note without -sample\n%s", out)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// A scope is a subtree of the repository that maintains its own AUTHORS
+// file, listing the authors of commits touching that subtree.
+type scope struct {
+	dir     string
+	authors []author
+}
+
+// name is the scope's AUTHORS file relative to the top of the work tree.
+func (s scope) name() string {
+	return path.Join(s.dir, "AUTHORS")
+}
+
+func (s scope) file() string {
+	return mustRepository().path(s.name())
+}
+
+// listed returns the entries of the scope's AUTHORS file, if any, as
+// committed in the revision or, if that is empty, in the work tree.
+// Without a work tree, the file is read from HEAD.
+func (s scope) listed(rev string) []author {
+	if rev == "" && mustRepository().bare() {
+		rev = "HEAD"
+	}
+	if rev != "" {
+		spec := rev + ":" + s.name()
+		if _, err := readBlob(spec); err != nil {
+			return nil
+		}
+		return getAuthorsRef(spec)
+	}
+	if _, err := os.Stat(s.file()); err != nil {
+		return nil
+	}
+	return getAuthors(s.file())
+}
+
+// readScopes reads the list of scope directories, one per line.
+func readScopes(file string) []string {
+	var dirs []string
+	for _, line := range readLines(file) {
+		dirs = append(dirs, path.Clean(filepath.ToSlash(line)))
+	}
+	return dirs
+}
+
+// getScopes returns the scopes with the authors of the commits touching
+// each. The authors are the fully merged set, i.e. the same people and
+// emails as in the top level list, with commits counted anew for each
+// scope. The keep function decides which authors make it into the lists.
+func getScopes(dirs []string, revs []string, authors []author, idx *authorIndex, commits []commit, keep func(author) bool) []scope {
+	scopes := make([]scope, len(dirs))
+	for i, dir := range dirs {
+		touching := pathCommits(revs, dir)
+		var scoped []commit
+		for _, c := range commits {
+			if touching.has(c.hash) {
+				scoped = append(scoped, c)
+			}
+		}
+
+		counted := make([]author, len(authors))
+		for j, a := range authors {
+			a.commits, a.first, a.last = 0, time.Time{}, time.Time{}
+			counted[j] = a
+		}
+		getContributions(counted, idx, scoped)
+
+		var kept []author
+		for _, a := range counted {
+			if a.commits > 0 && keep(a) {
+				kept = append(kept, a)
+			}
+		}
+		sortByName(kept)
+		placePinned(kept)
+		scopes[i] = scope{dir: dir, authors: kept}
+	}
+	return scopes
+}
+
+// writeScopes writes the AUTHORS file for each scope.
+func writeScopes(scopes []scope, maxEmails int, backup bool) {
+	if len(scopes) > 0 && mustRepository().bare() {
+		fatal("can't write scoped AUTHORS files without a work tree")
+	}
+	for _, s := range scopes {
+		authors := s.authors
+		err := writeFileAtomic(s.file(), backup, func(w io.Writer) error {
+			return writeAuthors(w, authors, maxEmails)
+		})
+		if err != nil {
+			fatal(err)
+		}
+	}
+}
+
+// checkScopes compares each scope with its existing AUTHORS file, if any,
+// as committed in the revision unless that is empty.
+func checkScopes(scopes []scope, rev string) map[string]checkResult {
+	res := make(map[string]checkResult)
+	for _, s := range scopes {
+		res[s.name()] = checkListed(s.authors, s.listed(rev))
+	}
+	return res
+}
@@ -0,0 +1,53 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScopes(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Alice A <alice@example.com>", message: "One", files: map[string]string{"lib/a/file": "x", "top": "y"}},
+		testCommit{author: "Bob B <bob@example.com>", message: "Two", files: map[string]string{"lib/a/file": "xz"}},
+		testCommit{author: "Carol C <carol@example.com>", message: "Three", files: map[string]string{"top": "yz"}},
+	)
+	defer cleanup()
+	scopes := writeTestFile(t, dir, "scopes", "# Subtrees\nlib/a/\n")
+	authors := writeTestFile(t, dir, "AUTHORS", mustRunMain(t, dir, "-authors"))
+
+	mustRunMain(t, dir, "-scopes", scopes, "-write-scopes")
+	scoped := filepath.Join(dir, "lib", "a", "AUTHORS")
+	bs, err := ioutil.ReadFile(scoped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bs) != "Alice A <alice@example.com>\nBob B <bob@example.com>\n" {
+		t.Errorf("unexpected scoped AUTHORS\n%s", bs)
+	}
+	if stdout, _, code := runMain(t, dir, "-read-authors", authors, "-scopes", scopes, "-check"); code != 0 {
+		t.Errorf("exit code %d checking the written files\n%s", code, stdout)
+	}
+
+	f, err := os.OpenFile(scoped, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("Zed Z <zed@example.com>\n")
+	f.Close()
+	stdout, _, code := runMain(t, dir, "-read-authors", authors, "-scopes", scopes, "-check")
+	if code != 1 {
+		t.Errorf("exit code %d with a stale scoped author", code)
+	}
+	if !strings.Contains(stdout, "lib/a/AUTHORS:\nStale author: Zed Z\n") {
+		t.Errorf("stale scoped author not reported\n%s", stdout)
+	}
+}
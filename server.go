@@ -0,0 +1,111 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A server serves the outputs over HTTP, from an analysis that is redone
+// periodically.
+type server struct {
+	opts *options
+
+	mut      sync.Mutex
+	analysis *analysis
+	authors  []author
+}
+
+// serve analyzes the history and serves the outputs on the listen address
+// until it fails.
+func serve(opts *options) error {
+	s := &server{opts: opts}
+	s.refresh()
+	if opts.serveRefresh > 0 {
+		go func() {
+			for range time.Tick(opts.serveRefresh) {
+				s.refresh()
+			}
+		}()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/badge.json", s.handleBadge)
+	mux.HandleFunc("/", s.handleOutput)
+	log.Printf("Listening on %s", opts.listen)
+	return http.ListenAndServe(opts.listen, mux)
+}
+
+func (s *server) refresh() {
+	a, authors := prepare(analyze(s.opts), s.opts)
+	s.mut.Lock()
+	s.analysis, s.authors = a, authors
+	s.mut.Unlock()
+}
+
+func (s *server) current() (*analysis, []author) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.analysis, s.authors
+}
+
+// handleBadge serves the number of contributors in the shields.io endpoint
+// badge format, for https://img.shields.io/endpoint?url=...
+func (s *server) handleBadge(w http.ResponseWriter, r *http.Request) {
+	_, authors := s.current()
+	badge := struct {
+		SchemaVersion int    `json:"schemaVersion"`
+		Label         string `json:"label"`
+		Message       string `json:"message"`
+		Color         string `json:"color"`
+	}{1, "contributors", fmt.Sprint(len(authors)), "blue"}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(s.opts.serveRefresh.Seconds())))
+	json.NewEncoder(w).Encode(badge)
+}
+
+// handleOutput serves the output named by the path, like /names, or the
+// list of outputs at the root.
+func (s *server) handleOutput(w http.ResponseWriter, r *http.Request) {
+	name := strings.Trim(r.URL.Path, "/")
+	if name == "" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintf(w, "/badge.json\n")
+		for _, name := range outputNames() {
+			fmt.Fprintf(w, "/%s\n", name)
+		}
+		return
+	}
+	fn, ok := outputFuncs[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	a, authors := s.current()
+	var buf strings.Builder
+	if err := fn(&buf, a, authors, s.opts); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	switch name {
+	case "json", "trend-json":
+		w.Header().Set("Content-Type", "application/json")
+	case "html":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	default:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	fmt.Fprint(w, buf.String())
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testServer() *server {
+	return &server{
+		opts:     &options{serveRefresh: time.Hour},
+		analysis: &analysis{},
+		authors: []author{
+			{name: "Alice A", emails: []string{"alice@example.com"}, commits: 2},
+			{name: "Bob B", emails: []string{"bob@example.com"}, commits: 1},
+		},
+	}
+}
+
+func TestServeBadge(t *testing.T) {
+	s := testServer()
+	rec := httptest.NewRecorder()
+	s.handleBadge(rec, httptest.NewRequest("GET", "/badge.json", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type %q", ct)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "max-age=3600" {
+		t.Errorf("Cache-Control %q", cc)
+	}
+	var badge map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &badge); err != nil {
+		t.Fatal(err)
+	}
+	if badge["schemaVersion"] != 1.0 || badge["label"] != "contributors" || badge["message"] != "2" {
+		t.Errorf("unexpected badge %v", badge)
+	}
+}
+
+func TestServeOutput(t *testing.T) {
+	s := testServer()
+	cases := []struct {
+		path        string
+		code        int
+		contentType string
+		body        string
+	}{
+		{"/authors", http.StatusOK, "text/plain; charset=utf-8", "Alice A <alice@example.com>\nBob B <bob@example.com>\n"},
+		{"/json", http.StatusOK, "application/json", `"name": "Bob B"`},
+		{"/", http.StatusOK, "text/plain; charset=utf-8", "/badge.json\n"},
+		{"/nope", http.StatusNotFound, "", ""},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		s.handleOutput(rec, httptest.NewRequest("GET", c.path, nil))
+		if rec.Code != c.code {
+			t.Errorf("%s: status %d, expected %d", c.path, rec.Code, c.code)
+			continue
+		}
+		if c.code != http.StatusOK {
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != c.contentType {
+			t.Errorf("%s: Content-Type %q, expected %q", c.path, ct, c.contentType)
+		}
+		if !strings.Contains(rec.Body.String(), c.body) {
+			t.Errorf("%s: no %q in\n%s", c.path, c.body, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.handleOutput(rec, httptest.NewRequest("GET", "/", nil))
+	if !containsLine(rec.Body.String(), "/names") {
+		t.Errorf("outputs not listed\n%s", rec.Body.String())
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// signingKeys returns a map from commit hash to the fingerprint of the GPG
+// or SSH key that signed the commit, for the signed commits in the given
+// revisions. Git verifies each signature, so this is slow on long
+// histories.
+func signingKeys(revs []string) map[string]string {
+	args := append([]string{"log", "--format=%H %GK"}, revs...)
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = os.Stderr
+	bs, err := cmd.Output()
+	if err != nil {
+		fatal("git:", err)
+	}
+
+	keys := make(map[string]string)
+	for _, line := range strings.Split(string(bs), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		keys[fields[0]] = fields[1]
+	}
+	return keys
+}
+
+// A keyRing tracks which emails have signed commits with which keys.
+type keyRing struct {
+	keys   map[string][]string // email -> keys
+	emails map[string][]string // key -> emails
+}
+
+func newKeyRing(commits []commit, keys map[string]string) *keyRing {
+	r := &keyRing{
+		keys:   make(map[string][]string),
+		emails: make(map[string][]string),
+	}
+	for _, c := range commits {
+		key, ok := keys[c.hash]
+		if !ok {
+			continue
+		}
+		r.keys[c.email] = appendMissing(r.keys[c.email], key)
+		r.emails[key] = appendMissing(r.emails[key], c.email)
+	}
+	return r
+}
+
+// match returns the author having another email that signed commits with
+// the same key as the given email.
+func (r *keyRing) match(email string, idx *authorIndex) (int, bool) {
+	for _, key := range r.keys[email] {
+		for _, other := range r.emails[key] {
+			if other == email {
+				continue
+			}
+			if i, ok := idx.email(other); ok {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
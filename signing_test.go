@@ -0,0 +1,70 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKeyRing(t *testing.T) {
+	commits := []commit{
+		{hash: "a", email: "alice@example.com"},
+		{hash: "b", email: "alice@laptop.local"},
+		{hash: "c", email: "bob@example.com"},
+		{hash: "d", email: "bob@laptop.local"},
+	}
+	keys := map[string]string{"a": "SHA256:alice", "b": "SHA256:alice", "c": "SHA256:bob"}
+	authors := []author{{name: "Alice A", emails: []string{"alice@example.com"}}, {name: "Bob B", emails: []string{"bob@example.com"}}}
+	ring := newKeyRing(commits, keys)
+	idx := newAuthorIndex(authors)
+
+	if i, ok := ring.match("alice@laptop.local", idx); !ok || i != 0 {
+		t.Errorf("match = %d, %v, expected Alice", i, ok)
+	}
+	// Bob's laptop commit isn't signed
+	if _, ok := ring.match("bob@laptop.local", idx); ok {
+		t.Error("unsigned email matched")
+	}
+	if _, ok := ring.match("eve@example.com", idx); ok {
+		t.Error("unknown email matched")
+	}
+}
+
+func TestSigningKeys(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("no ssh-keygen")
+	}
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	key := filepath.Join(dir, ".git", "key")
+	if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", key).CombinedOutput(); err != nil {
+		t.Skipf("ssh-keygen: %v\n%s", err, out)
+	}
+	pub, err := ioutil.ReadFile(key + ".pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	allowed := writeTestFile(t, filepath.Join(dir, ".git"), "allowed", "* "+string(pub))
+	runGit(t, dir, "config", "gpg.ssh.allowedSignersFile", allowed)
+
+	// Alice signs under both emails, but under another name on the laptop
+	sign := []string{"-c", "gpg.format=ssh", "-c", "user.signingkey=" + key, "commit", "-q", "-S", "--allow-empty"}
+	runGit(t, dir, append(sign, "-m", "Signed", "--author", "Alice A <alice@example.com>")...)
+	runGit(t, dir, append(sign, "-m", "Signed on the laptop", "--author", "alice <alice@laptop.local>")...)
+
+	if out := mustRunMain(t, dir, "-authors"); !containsLine(out, "alice <alice@laptop.local>") {
+		t.Errorf("unexpected output\n%s", out)
+	}
+	out := mustRunMain(t, dir, "-signing-keys", "-authors")
+	if !containsLine(out, "Alice A <alice@example.com> <alice@laptop.local>") || strings.Contains(out, "alice <") {
+		t.Errorf("unexpected output with -signing-keys\n%s", out)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// sqliteSchema is the schema of the -sqlite database. Commits by authors
+// that were filtered out have a NULL author_id.
+const sqliteSchema = `CREATE TABLE authors (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	nickname TEXT,
+	commits INTEGER NOT NULL,
+	geekrank INTEGER NOT NULL,
+	days_active INTEGER NOT NULL,
+	first_commit TEXT,
+	last_commit TEXT,
+	listed INTEGER NOT NULL,
+	team INTEGER NOT NULL,
+	note TEXT,
+	department TEXT
+);
+CREATE TABLE emails (
+	email TEXT PRIMARY KEY,
+	author_id TEXT NOT NULL REFERENCES authors(id),
+	provenance TEXT
+);
+CREATE TABLE commits (
+	hash TEXT PRIMARY KEY,
+	author_id TEXT REFERENCES authors(id),
+	author_email TEXT NOT NULL,
+	author_name TEXT NOT NULL,
+	author_date TEXT NOT NULL,
+	subject TEXT NOT NULL
+);
+CREATE INDEX commits_author_id ON commits(author_id);
+`
+
+// writeSQLite writes the authors, their emails and the analyzed commits
+// with the author each is attributed to into a new SQLite database at the
+// path, replacing any existing file. The database is created by the
+// sqlite3 command.
+func writeSQLite(path string, a *analysis, authors []author) error {
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+	cmd := exec.Command("sqlite3", "-bail", tmp)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("sqlite3: %v", err)
+	}
+	werr := writeSQL(in, a, authors)
+	in.Close()
+	if err := cmd.Wait(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("sqlite3: %v", err)
+	}
+	if werr != nil {
+		os.Remove(tmp)
+		return werr
+	}
+	return os.Rename(tmp, path)
+}
+
+// writeSQL writes the SQL statements creating and filling the database.
+func writeSQL(w io.Writer, a *analysis, authors []author) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "BEGIN;\n%s", sqliteSchema)
+	for _, au := range authors {
+		fmt.Fprintf(bw, "INSERT INTO authors VALUES (%s, %s, %s, %d, %d, %d, %s, %s, %d, %d, %s, %s);\n",
+			sqlString(au.id()), sqlString(au.name), sqlNullString(au.nickname),
+			au.commits, au.geekrank, au.daysActive, sqlDate(au.first), sqlDate(au.last),
+			sqlBool(au.listed), sqlBool(au.team), sqlNullString(au.note), sqlNullString(au.department))
+		for _, e := range au.emails {
+			fmt.Fprintf(bw, "INSERT OR IGNORE INTO emails VALUES (%s, %s, %s);\n",
+				sqlString(e), sqlString(au.id()), sqlNullString(au.provenance[e]))
+		}
+	}
+	idx := newAuthorIndex(authors)
+	for _, c := range a.commits {
+		id := "NULL"
+		if i, ok := idx.email(c.email); ok {
+			id = sqlString(authors[i].id())
+		}
+		fmt.Fprintf(bw, "INSERT OR IGNORE INTO commits VALUES (%s, %s, %s, %s, %s, %s);\n",
+			sqlString(c.hash), id, sqlString(c.email), sqlString(c.name),
+			sqlString(c.date.Format(time.RFC3339)), sqlString(commitSubject(c.message)))
+	}
+	fmt.Fprintf(bw, "COMMIT;\n")
+	return bw.Flush()
+}
+
+func sqlString(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+func sqlNullString(s string) string {
+	if s == "" {
+		return "NULL"
+	}
+	return sqlString(s)
+}
+
+func sqlDate(t time.Time) string {
+	if t.IsZero() {
+		return "NULL"
+	}
+	return sqlString(t.Format("2006-01-02"))
+}
+
+func sqlBool(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
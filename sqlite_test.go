@@ -0,0 +1,73 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteSQL(t *testing.T) {
+	authors := []author{{name: "Jane O'Doe", emails: []string{"jane@example.com"}, commits: 1, geekrank: 1}}
+	a := &analysis{commits: []commit{
+		{hash: "aaaa", email: "jane@example.com", name: "Jane O'Doe", date: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), message: "It's a fix\n\nMore."},
+		{hash: "bbbb", email: "bot@example.com", name: "Bot", date: time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), message: "Update"},
+	}}
+
+	var buf bytes.Buffer
+	if err := writeSQL(&buf, a, authors); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	id := authors[0].id()
+	for _, line := range []string{
+		"INSERT INTO authors VALUES ('" + id + "', 'Jane O''Doe', NULL, 1, 1, 0, NULL, NULL, 0, 0, NULL, NULL);",
+		"INSERT OR IGNORE INTO emails VALUES ('jane@example.com', '" + id + "', NULL);",
+		"INSERT OR IGNORE INTO commits VALUES ('aaaa', '" + id + "', 'jane@example.com', 'Jane O''Doe', '2020-01-02T03:04:05Z', 'It''s a fix');",
+		// Filtered authors' commits aren't attributed
+		"INSERT OR IGNORE INTO commits VALUES ('bbbb', NULL, 'bot@example.com', 'Bot', '2020-01-03T00:00:00Z', 'Update');",
+	} {
+		if !containsLine(out, line) {
+			t.Errorf("missing %s in\n%s", line, out)
+		}
+	}
+	if !strings.HasPrefix(out, "BEGIN;\n") || !strings.HasSuffix(out, "COMMIT;\n") {
+		t.Errorf("not a single transaction:\n%s", out)
+	}
+}
+
+func TestSQLiteImport(t *testing.T) {
+	if _, err := exec.LookPath("sqlite3"); err != nil {
+		t.Skip("no sqlite3")
+	}
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	state := filepath.Join(dir, ".git", "state.json")
+	mustRunMain(t, dir, "export", state)
+
+	query := func(db string) string {
+		out, err := exec.Command("sqlite3", db, "SELECT a.name, COUNT(*) FROM commits c JOIN authors a ON a.id = c.author_id GROUP BY a.name ORDER BY a.name").Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(out)
+	}
+	live, imported := filepath.Join(dir, "live.db"), filepath.Join(dir, "imported.db")
+	mustRunMain(t, dir, "-sqlite", live)
+	mustRunMain(t, dir, "-sqlite", imported, "import", state)
+	expected := "Alice A|2\nBob B|1\nCarol C|1\n"
+	if out := query(live); out != expected {
+		t.Errorf("live database has\n%s\nexpected\n%s", out, expected)
+	}
+	if out := query(imported); out != expected {
+		t.Errorf("imported database has\n%s\nexpected\n%s", out, expected)
+	}
+}
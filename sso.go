@@ -0,0 +1,119 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+)
+
+// An ssoIdentity is a person as known to the corporate single sign-on,
+// with the emails they commit as.
+type ssoIdentity struct {
+	id     string
+	name   string
+	emails []string
+}
+
+// readSSOMap reads a CSV export mapping SSO identities to commit emails.
+// The header row names the columns; "email" is required, "identity" and
+// "name" are optional. An identity may span several rows and a cell may
+// hold several emails separated by semicolons or spaces.
+func readSSOMap(file string) []ssoIdentity {
+	rd := csv.NewReader(bytes.NewReader(readAll(file)))
+	rd.FieldsPerRecord = -1
+	rows, err := rd.ReadAll()
+	if err != nil {
+		fatalf("%s: %v", file, err)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	cols := map[string]int{"identity": -1, "name": -1, "email": -1}
+	for i, h := range rows[0] {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if _, ok := cols[h]; ok {
+			cols[h] = i
+		}
+	}
+	if cols["email"] < 0 {
+		fatalf("%s: no email column in the header", file)
+	}
+	cell := func(row []string, col string) string {
+		if i := cols[col]; i >= 0 && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	var ids []ssoIdentity
+	byID := make(map[string]int)
+	for _, row := range rows[1:] {
+		emails := strings.FieldsFunc(cell(row, "email"), func(r rune) bool {
+			return r == ';' || r == ' '
+		})
+		if len(emails) == 0 {
+			continue
+		}
+		id := cell(row, "identity")
+		if i, ok := byID[id]; ok && id != "" {
+			ids[i].emails = appendMissing(ids[i].emails, emails...)
+			continue
+		}
+		byID[id] = len(ids)
+		ids = append(ids, ssoIdentity{id: id, name: cell(row, "name"), emails: emails})
+	}
+	return ids
+}
+
+// applySSOMap merges the emails in the history that belong to the same SSO
+// identity into one author: the listed author having one of the emails, or
+// else a new author under the name from the SSO export. Emails are
+// compared case insensitively, as exports rarely preserve case.
+func applySSOMap(authors []author, idx *authorIndex, ids []ssoIdentity, all []identity) []author {
+	byEmail := make(map[string]int)
+	for i, id := range ids {
+		for _, e := range id.emails {
+			byEmail[strings.ToLower(e)] = i
+		}
+	}
+	owner := make(map[int]int) // SSO identity -> author
+	for j, a := range authors {
+		for _, e := range a.emails {
+			if i, ok := byEmail[strings.ToLower(e)]; ok {
+				owner[i] = j
+			}
+		}
+	}
+
+	for _, h := range all {
+		i, ok := byEmail[strings.ToLower(h.email)]
+		if !ok {
+			continue
+		}
+		if _, ok := idx.email(h.email); ok {
+			continue
+		}
+		j, ok := owner[i]
+		if !ok {
+			name := ids[i].name
+			if name == "" {
+				name = h.name
+			}
+			authors = append(authors, author{name: name})
+			j = len(authors) - 1
+			idx.add(authors, j)
+			owner[i] = j
+		}
+		authors[j].emails = append(authors[j].emails, h.email)
+		authors[j].setProvenance(h.email, provenanceSSO)
+		idx.addEmail(h.email, j)
+	}
+	return authors
+}
@@ -0,0 +1,57 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadSSOMap(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	file := writeTestFile(t, dir, "sso.csv", "Department, Email ,Identity,Name\n"+
+		"R&D,jane@corp.example.com,E1,Jane Doe\n"+
+		"R&D,jdoe@laptop.local; jd@home.example.com,E1,Jane Doe\n"+
+		"Sales,,E2,Nobody\n"+
+		"Sales,bob@corp.example.com,,Bob B\n"+
+		"Sales,carol@corp.example.com,,Carol C\n")
+
+	expected := []ssoIdentity{
+		{id: "E1", name: "Jane Doe", emails: []string{"jane@corp.example.com", "jdoe@laptop.local", "jd@home.example.com"}},
+		{id: "", name: "Bob B", emails: []string{"bob@corp.example.com"}},
+		{id: "", name: "Carol C", emails: []string{"carol@corp.example.com"}},
+	}
+	if ids := readSSOMap(file); !reflect.DeepEqual(ids, expected) {
+		t.Errorf("read\n%+v\nexpected\n%+v", ids, expected)
+	}
+}
+
+func TestSSOMap(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "jdoe <JDoe@laptop.local>", date: "2020-01-01T12:00:00Z", message: "One"},
+		testCommit{author: "J <jane@corp.example.com>", date: "2020-02-01T12:00:00Z", message: "Two"},
+		testCommit{author: "bob <bob@laptop.local>", date: "2020-03-01T12:00:00Z", message: "Three"},
+		testCommit{author: "Bob <bob@corp.example.com>", date: "2020-04-01T12:00:00Z", message: "Four"},
+	)
+	defer cleanup()
+	sso := writeTestFile(t, dir, "sso.csv", "identity,name,email\nE1,Jane Doe,jane@corp.example.com\nE1,Jane Doe,jdoe@laptop.local\nE2,Bob B,bob@corp.example.com bob@laptop.local\n")
+	authors := writeTestFile(t, dir, "AUTHORS", "Robert B <bob@corp.example.com>\n")
+
+	// Jane is a new author under the name from the export, Bob's emails
+	// go to the listed author
+	out := mustRunMain(t, dir, "-read-authors", authors, "-sso-map", sso, "-authors", "-no-name-matching")
+	if out != "Jane Doe <JDoe@laptop.local> <jane@corp.example.com>\nRobert B <bob@corp.example.com> <bob@laptop.local>\n" {
+		t.Errorf("unexpected output\n%s", out)
+	}
+
+	noEmail := writeTestFile(t, dir, "bad.csv", "identity,name\nE1,Jane Doe\n")
+	if _, stderr, code := runMain(t, dir, "-sso-map", noEmail); code == 0 || !strings.Contains(stderr, "no email column in the header") {
+		t.Errorf("exit code %d without an email column\n%s", code, stderr)
+	}
+}
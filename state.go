@@ -0,0 +1,337 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+// stateVersion is incremented for incompatible changes to the state file
+// format.
+const stateVersion = 1
+
+// stateFile is the serialized form of an analysis, written by the export
+// command and read by import.
+type stateFile struct {
+	Version      int                      `json:"version"`
+	Exported     time.Time                `json:"exported"`
+	Authors      []stateAuthor            `json:"authors"`
+	Stale        []stateAuthor            `json:"stale,omitempty"`
+	ListedEmails []string                 `json:"listedEmails,omitempty"`
+	Scopes       []stateScope             `json:"scopes,omitempty"`
+	Modules      []stateModule            `json:"modules,omitempty"`
+	Maintenance  []stateBranch            `json:"maintenance,omitempty"`
+	Credits      map[string][]stateCredit `json:"credits,omitempty"`
+	Milestones   []stateMilestone         `json:"milestones,omitempty"`
+	Trend        *trend                   `json:"trend,omitempty"`
+	Imports      []stateImport            `json:"imports,omitempty"`
+	Sample       int                      `json:"sample,omitempty"`
+	Dropped      []stateDropped           `json:"dropped,omitempty"`
+	Commits      []stateLogCommit         `json:"commits,omitempty"`
+}
+
+// stateAuthor holds all of an author, unlike authorView which is what we
+// present to users.
+type stateAuthor struct {
+	Name         string            `json:"name"`
+	Nickname     string            `json:"nickname,omitempty"`
+	Nicknames    []string          `json:"nicknames,omitempty"`
+	Emails       []string          `json:"emails"`
+	Provenance   map[string]string `json:"provenance,omitempty"`
+	Tags         []string          `json:"tags,omitempty"`
+	URLs         []string          `json:"urls,omitempty"`
+	Listed       bool              `json:"listed,omitempty"`
+	Commits      int               `json:"commits"`
+	Geekrank     int               `json:"geekrank"`
+	DaysActive   int               `json:"daysActive,omitempty"`
+	DayStreak    int               `json:"dayStreak,omitempty"`
+	WeekStreak   int               `json:"weekStreak,omitempty"`
+	First        time.Time         `json:"first"`
+	Last         time.Time         `json:"last"`
+	Categories   map[string]int    `json:"categories,omitempty"`
+	Direct       int               `json:"direct,omitempty"`
+	Merged       int               `json:"merged,omitempty"`
+	AddedFiles   int               `json:"addedFiles,omitempty"`
+	Licenses     map[string]int    `json:"licenses,omitempty"`
+	BlameLines   int               `json:"blameLines,omitempty"`
+	Lines        int               `json:"lines,omitempty"`
+	Largest      []stateCommit     `json:"largest,omitempty"`
+	Activity     float64           `json:"activity,omitempty"`
+	Team         bool              `json:"team,omitempty"`
+	ReleaseWeeks []int             `json:"releaseWeeks,omitempty"`
+	Pin          int               `json:"pin,omitempty"`
+	Note         string            `json:"note,omitempty"`
+	Pronouns     string            `json:"pronouns,omitempty"`
+	Department   string            `json:"department,omitempty"`
+	Tagged       int               `json:"tagged,omitempty"`
+	ChosenAvatar string            `json:"chosenAvatar,omitempty"`
+}
+
+type stateScope struct {
+	Dir     string        `json:"dir"`
+	Authors []stateAuthor `json:"authors"`
+}
+
+type stateModule struct {
+	Name    string        `json:"name"`
+	Authors []stateAuthor `json:"authors"`
+}
+
+type stateBranch struct {
+	Name    string        `json:"name"`
+	Commits int           `json:"commits"`
+	Authors []stateAuthor `json:"authors"`
+}
+
+type stateCredit struct {
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Count  int    `json:"count"`
+	Author bool   `json:"author,omitempty"`
+}
+
+type stateCommit struct {
+	Hash    string `json:"hash"`
+	Subject string `json:"subject"`
+	Lines   int    `json:"lines"`
+	Import  bool   `json:"import,omitempty"`
+}
+
+type stateImport struct {
+	Hash    string `json:"hash"`
+	Email   string `json:"email"`
+	Subject string `json:"subject"`
+	Lines   int    `json:"lines"`
+	Reason  string `json:"reason"`
+}
+
+// stateLogCommit is a commit of the history analyzed.
+type stateLogCommit struct {
+	Hash    string    `json:"hash"`
+	Email   string    `json:"email"`
+	Name    string    `json:"name"`
+	Date    time.Time `json:"date"`
+	Parents []string  `json:"parents,omitempty"`
+	Message string    `json:"message"`
+}
+
+type stateDropped struct {
+	Author stateAuthor `json:"author"`
+	Reason string      `json:"reason"`
+}
+
+type stateMilestone struct {
+	Date time.Time `json:"date"`
+	Name string    `json:"name"`
+	What string    `json:"what"`
+}
+
+func toStateAuthors(authors []author) []stateAuthor {
+	res := make([]stateAuthor, len(authors))
+	for i, a := range authors {
+		res[i] = stateAuthor{
+			Name:         a.name,
+			Nickname:     a.nickname,
+			Nicknames:    a.nicknames,
+			Emails:       a.emails,
+			Provenance:   a.provenance,
+			Tags:         a.tags,
+			URLs:         a.urls,
+			Listed:       a.listed,
+			Commits:      a.commits,
+			Geekrank:     a.geekrank,
+			DaysActive:   a.daysActive,
+			DayStreak:    a.dayStreak,
+			WeekStreak:   a.weekStreak,
+			First:        a.first,
+			Last:         a.last,
+			Categories:   a.categories,
+			Direct:       a.direct,
+			Merged:       a.merged,
+			AddedFiles:   a.addedFiles,
+			Licenses:     a.licenses,
+			BlameLines:   a.blameLines,
+			Lines:        a.lines,
+			Largest:      toStateCommits(a.largest),
+			Activity:     a.activity,
+			Team:         a.team,
+			ReleaseWeeks: a.releaseWeeks,
+			Pin:          a.pin,
+			Note:         a.note,
+			Pronouns:     a.pronouns,
+			Department:   a.department,
+			Tagged:       a.tagged,
+			ChosenAvatar: a.chosenAvatar,
+		}
+	}
+	return res
+}
+
+func fromStateAuthors(authors []stateAuthor) []author {
+	res := make([]author, len(authors))
+	for i, a := range authors {
+		res[i] = author{
+			name:         a.Name,
+			nickname:     a.Nickname,
+			nicknames:    a.Nicknames,
+			emails:       a.Emails,
+			provenance:   a.Provenance,
+			tags:         a.Tags,
+			urls:         a.URLs,
+			listed:       a.Listed,
+			commits:      a.Commits,
+			geekrank:     a.Geekrank,
+			daysActive:   a.DaysActive,
+			dayStreak:    a.DayStreak,
+			weekStreak:   a.WeekStreak,
+			first:        a.First,
+			last:         a.Last,
+			categories:   a.Categories,
+			direct:       a.Direct,
+			merged:       a.Merged,
+			addedFiles:   a.AddedFiles,
+			licenses:     a.Licenses,
+			blameLines:   a.BlameLines,
+			lines:        a.Lines,
+			largest:      fromStateCommits(a.Largest),
+			activity:     a.Activity,
+			team:         a.Team,
+			releaseWeeks: a.ReleaseWeeks,
+			pin:          a.Pin,
+			note:         a.Note,
+			pronouns:     a.Pronouns,
+			department:   a.Department,
+			tagged:       a.Tagged,
+			chosenAvatar: a.ChosenAvatar,
+		}
+	}
+	return res
+}
+
+func toStateCommits(commits []largeCommit) []stateCommit {
+	var res []stateCommit
+	for _, c := range commits {
+		res = append(res, stateCommit{Hash: c.hash, Subject: c.subject, Lines: c.lines, Import: c.imports})
+	}
+	return res
+}
+
+func fromStateCommits(commits []stateCommit) []largeCommit {
+	var res []largeCommit
+	for _, c := range commits {
+		res = append(res, largeCommit{hash: c.Hash, subject: c.Subject, lines: c.Lines, imports: c.Import})
+	}
+	return res
+}
+
+// saveState writes the analysis to the file, as exported at the time.
+func saveState(file string, a *analysis, now time.Time) error {
+	st := stateFile{
+		Version:      stateVersion,
+		Exported:     now.UTC(),
+		Authors:      toStateAuthors(a.authors),
+		Stale:        toStateAuthors(a.stale),
+		ListedEmails: a.listedEmails,
+		Trend:        a.trend,
+		Sample:       a.sample,
+	}
+	for _, s := range a.scopes {
+		st.Scopes = append(st.Scopes, stateScope{Dir: s.dir, Authors: toStateAuthors(s.authors)})
+	}
+	for _, m := range a.modules {
+		st.Modules = append(st.Modules, stateModule{Name: m.name, Authors: toStateAuthors(m.authors)})
+	}
+	for _, b := range a.maintenance {
+		st.Maintenance = append(st.Maintenance, stateBranch{Name: b.name, Commits: b.commits, Authors: toStateAuthors(b.authors)})
+	}
+	if len(a.credits) > 0 {
+		st.Credits = make(map[string][]stateCredit)
+		for key, credits := range a.credits {
+			for _, c := range credits {
+				st.Credits[key] = append(st.Credits[key], stateCredit{Name: c.name, Email: c.email, Count: c.count, Author: c.author})
+			}
+		}
+	}
+	for _, c := range a.imports {
+		st.Imports = append(st.Imports, stateImport{Hash: c.hash, Email: c.email, Subject: c.subject, Lines: c.lines, Reason: c.reason})
+	}
+	for _, m := range a.milestones {
+		st.Milestones = append(st.Milestones, stateMilestone{Date: m.date, Name: m.name, What: m.what})
+	}
+	for _, d := range a.dropped {
+		st.Dropped = append(st.Dropped, stateDropped{Author: toStateAuthors([]author{d.author})[0], Reason: d.reason})
+	}
+	for _, c := range a.commits {
+		st.Commits = append(st.Commits, stateLogCommit{Hash: c.hash, Email: c.email, Name: c.name, Date: c.date, Parents: c.parents, Message: c.message})
+	}
+
+	bs, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(file, false, func(w io.Writer) error {
+		_, err := w.Write(bs)
+		return err
+	})
+}
+
+// loadState reads an analysis previously written by saveState.
+func loadState(file string) (*analysis, error) {
+	bs, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var st stateFile
+	if err := json.Unmarshal(bs, &st); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if st.Version != stateVersion {
+		return nil, fmt.Errorf("%s: unsupported state version %d", file, st.Version)
+	}
+
+	a := &analysis{
+		authors:      fromStateAuthors(st.Authors),
+		stale:        fromStateAuthors(st.Stale),
+		listedEmails: st.ListedEmails,
+		trend:        st.Trend,
+		sample:       st.Sample,
+	}
+	for _, s := range st.Scopes {
+		a.scopes = append(a.scopes, scope{dir: s.Dir, authors: fromStateAuthors(s.Authors)})
+	}
+	for _, m := range st.Modules {
+		a.modules = append(a.modules, module{name: m.Name, authors: fromStateAuthors(m.Authors)})
+	}
+	for _, b := range st.Maintenance {
+		a.maintenance = append(a.maintenance, maintainedBranch{name: b.Name, commits: b.Commits, authors: fromStateAuthors(b.Authors)})
+	}
+	if len(st.Credits) > 0 {
+		a.credits = make(map[string][]credit)
+		for key, credits := range st.Credits {
+			for _, c := range credits {
+				a.credits[key] = append(a.credits[key], credit{name: c.Name, email: c.Email, count: c.Count, author: c.Author})
+			}
+		}
+	}
+	for _, c := range st.Imports {
+		a.imports = append(a.imports, importCommit{hash: c.Hash, email: c.Email, subject: c.Subject, lines: c.Lines, reason: c.Reason})
+	}
+	for _, m := range st.Milestones {
+		a.milestones = append(a.milestones, milestone{date: m.Date, name: m.Name, what: m.What})
+	}
+	for _, d := range st.Dropped {
+		a.dropped = append(a.dropped, droppedAuthor{author: fromStateAuthors([]stateAuthor{d.Author})[0], reason: d.Reason})
+	}
+	for _, c := range st.Commits {
+		a.commits = append(a.commits, commit{hash: c.Hash, email: c.Email, name: c.Name, date: c.Date, parents: c.Parents, message: c.Message})
+	}
+	return a, nil
+}
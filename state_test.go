@@ -0,0 +1,64 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// exportImport renders the outputs selected by the arguments both from the
+// history in the directory and from an export of it, fails the test unless
+// they are the same, and returns the output.
+func exportImport(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	state := filepath.Join(dir, ".git", "state.json")
+	mustRunMain(t, dir, append(args, "export", state)...)
+	live := mustRunMain(t, dir, args...)
+	imported := mustRunMain(t, dir, append(args, "import", state)...)
+	if imported != live {
+		t.Errorf("%v: imported output differs:\n%s\nexpected:\n%s", args, imported, live)
+	}
+	return live
+}
+
+func TestExportImport(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	authors := writeTestFile(t, dir, "AUTHORS", "# Header\n\nAlice A <alice@example.com>\nEve E <eve@example.com>\n")
+
+	for _, args := range [][]string{
+		{"-authors"},
+		{"-names"},
+		{"-stats", "-geekrank"},
+		{"-json"},
+		{"-read-authors", authors, "-authors"},
+	} {
+		if out := exportImport(t, dir, args...); out == "" {
+			t.Errorf("%v: no output", args)
+		}
+	}
+
+	// The stale authors are kept for -check
+	stdout, _, code := runMain(t, dir, "-read-authors", authors, "export", filepath.Join(dir, ".git", "state.json"))
+	if code != 0 {
+		t.Fatalf("export: exit code %d\n%s", code, stdout)
+	}
+	stdout, _, code = runMain(t, dir, "-read-authors", authors, "-check", "import", filepath.Join(dir, ".git", "state.json"))
+	if code != 1 || !containsLine(stdout, "Stale author: Eve E") {
+		t.Errorf("exit code %d, expected Eve stale:\n%s", code, stdout)
+	}
+}
+
+func TestImportVersion(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	state := writeTestFile(t, dir, "state.json", `{"version": 999, "authors": []}`)
+	if _, err := loadState(state); err == nil {
+		t.Error("unexpected success loading a state of an unknown version")
+	}
+}
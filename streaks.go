@@ -0,0 +1,55 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// longestStreaks returns the longest runs of consecutive days, and of
+// consecutive Monday to Sunday weeks, with commits, given the days with
+// commits as "2006-01-02" in UTC.
+func longestStreaks(days stringSet) (int, int) {
+	var dayNums []int
+	for d := range days {
+		t, err := time.Parse("2006-01-02", d)
+		if err != nil {
+			continue
+		}
+		dayNums = append(dayNums, int(t.Unix()/86400))
+	}
+	sort.Ints(dayNums)
+
+	// The epoch was a Thursday, so day n is in the week starting on
+	// Monday, day 7k-3.
+	var weekNums []int
+	for _, d := range dayNums {
+		w := (d + 3) / 7
+		if len(weekNums) == 0 || weekNums[len(weekNums)-1] != w {
+			weekNums = append(weekNums, w)
+		}
+	}
+	return longestRun(dayNums), longestRun(weekNums)
+}
+
+// longestRun returns the length of the longest run of consecutive numbers
+// in the sorted, distinct numbers.
+func longestRun(nums []int) int {
+	longest, run := 0, 0
+	for i, n := range nums {
+		if i > 0 && n == nums[i-1]+1 {
+			run++
+		} else {
+			run = 1
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+	return longest
+}
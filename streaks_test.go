@@ -0,0 +1,56 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLongestStreaks(t *testing.T) {
+	cases := []struct {
+		days          []string
+		daily, weekly int
+	}{
+		{nil, 0, 0},
+		{[]string{"2020-01-01"}, 1, 1},
+		// Wednesday to Friday, then Sunday and Monday across a week boundary
+		{[]string{"2020-01-01", "2020-01-02", "2020-01-03", "2020-01-05", "2020-01-06"}, 3, 2},
+		// Across a month and a year, with a gap week
+		{[]string{"2019-12-31", "2020-01-01", "2020-01-20", "2020-01-27", "2020-02-03"}, 2, 3},
+		{[]string{"not a date", "2020-03-01"}, 1, 1},
+	}
+	for _, c := range cases {
+		daily, weekly := longestStreaks(stringSetFromStrings(c.days))
+		if daily != c.daily || weekly != c.weekly {
+			t.Errorf("%v: streaks %d days, %d weeks, expected %d, %d", c.days, daily, weekly, c.daily, c.weekly)
+		}
+	}
+}
+
+func TestStreaksJSON(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Alice A <alice@example.com>", date: "2020-01-06T09:00:00Z", message: "Monday"},
+		testCommit{author: "Alice A <alice@example.com>", date: "2020-01-06T17:00:00Z", message: "Monday again"},
+		testCommit{author: "Alice A <alice@example.com>", date: "2020-01-07T09:00:00Z", message: "Tuesday"},
+		testCommit{author: "Alice A <alice@example.com>", date: "2020-01-14T09:00:00Z", message: "Next week"},
+	)
+	defer cleanup()
+
+	var authors []struct {
+		DaysActive int `json:"daysActive"`
+		DayStreak  int `json:"dayStreak"`
+		WeekStreak int `json:"weekStreak"`
+	}
+	out := mustRunMain(t, dir, "-json")
+	if err := json.Unmarshal([]byte(out), &authors); err != nil {
+		t.Fatalf("%v:\n%s", err, out)
+	}
+	if len(authors) != 1 || authors[0].DaysActive != 3 || authors[0].DayStreak != 2 || authors[0].WeekStreak != 2 {
+		t.Errorf("unexpected streaks %+v", authors)
+	}
+}
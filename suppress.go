@@ -0,0 +1,70 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import "strings"
+
+// readSuppressed returns the set of lower cased email addresses in the
+// file.
+func readSuppressed(file string) stringSet {
+	s := make(stringSet)
+	for _, line := range readLines(file) {
+		s.add(strings.ToLower(line))
+	}
+	return s
+}
+
+// suppressEmails returns a copy of the analysis with the suppressed email
+// addresses removed, so that they are never printed. The matching of
+// commits to authors has already happened, so they still count.
+func (a *analysis) suppressEmails(suppressed stringSet) *analysis {
+	res := *a
+	res.authors = suppressAuthorEmails(a.authors, suppressed)
+	res.stale = suppressAuthorEmails(a.stale, suppressed)
+	res.listedEmails = nil
+	for _, e := range a.listedEmails {
+		if !suppressed.has(strings.ToLower(e)) {
+			res.listedEmails = append(res.listedEmails, e)
+		}
+	}
+	res.scopes = make([]scope, len(a.scopes))
+	for i, s := range a.scopes {
+		res.scopes[i] = scope{dir: s.dir, authors: suppressAuthorEmails(s.authors, suppressed)}
+	}
+	if a.credits != nil {
+		res.credits = make(map[string][]credit)
+		for key, credits := range a.credits {
+			cs := make([]credit, len(credits))
+			for i, c := range credits {
+				if suppressed.has(strings.ToLower(c.email)) {
+					c.email = ""
+				}
+				cs[i] = c
+			}
+			res.credits[key] = cs
+		}
+	}
+	return &res
+}
+
+func suppressAuthorEmails(authors []author, suppressed stringSet) []author {
+	res := make([]author, len(authors))
+	for i, a := range authors {
+		var emails []string
+		for _, e := range a.emails {
+			if !suppressed.has(strings.ToLower(e)) {
+				emails = append(emails, e)
+			}
+		}
+		// The id stays the same, or the authors without emails left would
+		// all have the same, empty one
+		a.keptID = a.id()
+		a.emails = emails
+		res[i] = a
+	}
+	return res
+}
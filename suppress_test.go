@@ -0,0 +1,52 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSuppressEmails(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Alice A <alice@private.example.com>", date: "2020-01-01T12:00:00Z", message: "Private"},
+		testCommit{author: "Alice A <alice@example.com>", date: "2020-02-01T12:00:00Z", message: "Public"},
+		testCommit{author: "Bob B <bob@private.example.com>", date: "2020-03-01T12:00:00Z", message: "Bob"},
+	)
+	defer cleanup()
+	authors := writeTestFile(t, dir, "AUTHORS", "Alice A <alice@example.com> <alice@private.example.com>\nBob B <bob@private.example.com>\n")
+	suppressed := writeTestFile(t, dir, "suppressed", "# Never printed\nALICE@private.example.com\nbob@private.example.com\n")
+
+	out := mustRunMain(t, dir, "-read-authors", authors, "-suppress-emails", suppressed, "-authors")
+	if strings.Contains(out, "private") {
+		t.Errorf("suppressed emails printed\n%s", out)
+	}
+	if !containsLine(out, "Alice A <alice@example.com>") || !containsLine(out, "Bob B") {
+		t.Errorf("unexpected output\n%s", out)
+	}
+
+	// The suppressed emails still match the commits
+	out = mustRunMain(t, dir, "-read-authors", authors, "-suppress-emails", suppressed, "-json")
+	if strings.Contains(out, "private") {
+		t.Errorf("suppressed emails in JSON\n%s", out)
+	}
+	var res []struct {
+		Name    string `json:"name"`
+		Commits int    `json:"commits"`
+	}
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatal(err)
+	}
+	commits := make(map[string]int)
+	for _, r := range res {
+		commits[r.Name] = r.Commits
+	}
+	if commits["Alice A"] != 2 || commits["Bob B"] != 1 {
+		t.Errorf("commits %v, expected two by Alice and one by Bob", commits)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// releaseCategory is the category crediting the creation of release tags.
+const releaseCategory = "release"
+
+// A tagging is the creation of an annotated tag.
+type tagging struct {
+	tag   string
+	email string
+	name  string
+}
+
+// annotatedTags returns the annotated tags matching the pattern, with
+// their taggers. Lightweight tags have no tagger and are skipped.
+func annotatedTags(pattern string) []tagging {
+	cmd := exec.Command("git", "for-each-ref", "--format=%(objecttype)%1f%(refname:short)%1f%(taggeremail)%1f%(taggername)", "refs/tags/"+pattern)
+	cmd.Stderr = os.Stderr
+	bs, err := cmd.Output()
+	if err != nil {
+		fatal("git:", err)
+	}
+	var res []tagging
+	for _, line := range strings.Split(string(bs), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 || fields[0] != "tag" {
+			continue
+		}
+		email := strings.TrimSuffix(strings.TrimPrefix(fields[2], "<"), ">")
+		if email == "" {
+			continue
+		}
+		res = append(res, tagging{tag: fields[1], email: email, name: fields[3]})
+	}
+	return res
+}
+
+// getTagged credits the taggers with the tags they created, as a
+// contribution of its own and, if categorized, in the release category.
+// Taggers who aren't otherwise authors are added, matched by name first
+// unless byName is false.
+func getTagged(authors []author, idx *authorIndex, tags []tagging, byName, categorize bool) []author {
+	for _, t := range tags {
+		i, ok := idx.email(t.email)
+		if !ok && byName {
+			i, ok = idx.name(t.name)
+		}
+		if !ok {
+			authors = append(authors, author{name: t.name, emails: []string{t.email}})
+			i = len(authors) - 1
+			authors[i].setProvenance(t.email, provenanceNew)
+			idx.add(authors, i)
+		}
+		authors[i].tagged++
+		if categorize {
+			if authors[i].categories == nil {
+				authors[i].categories = make(map[string]int)
+			}
+			authors[i].categories[releaseCategory]++
+		}
+	}
+	return authors
+}
@@ -0,0 +1,58 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestTagCredit(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	tag := func(name, email string, args ...string) {
+		runGit(t, dir, append([]string{"-c", "user.name=" + name, "-c", "user.email=" + email, "tag"}, args...)...)
+	}
+	tag("Dave D", "dave@example.com", "-a", "-m", "Release v1", "v1", "HEAD~2")
+	tag("Alice Anderson", "alice@example.net", "-a", "-m", "Release v2", "v2", "HEAD~1")
+	tag("Dave D", "dave@example.com", "-a", "-m", "Not a release", "other", "HEAD")
+	tag("Dave D", "dave@example.com", "v3", "HEAD")
+
+	out := mustRunMain(t, dir, "-tag-credit", "-json")
+	var res []struct {
+		Name    string `json:"name"`
+		Commits int    `json:"commits"`
+		Tagged  int    `json:"tagged"`
+	}
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatal(err)
+	}
+	tagged := make(map[string][2]int)
+	for _, r := range res {
+		tagged[r.Name] = [2]int{r.Commits, r.Tagged}
+	}
+	// A tagger matching no author by email or name is a new one
+	expected := map[string][2]int{
+		"Alice A":        {2, 0},
+		"Alice Anderson": {0, 1},
+		"Bob B":          {1, 0},
+		"Carol C":        {1, 0},
+		"Dave D":         {0, 1},
+	}
+	if len(tagged) != len(expected) {
+		t.Errorf("authors %v, expected %v", tagged, expected)
+	}
+	for name, e := range expected {
+		if tagged[name] != e {
+			t.Errorf("%s: commits and tags %v, expected %v", name, tagged[name], e)
+		}
+	}
+
+	if out := mustRunMain(t, dir, "-json"); containsLine(out, `    "name": "Dave D",`) {
+		t.Errorf("tagger credited without -tag-credit\n%s", out)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// linkNextRe matches the next page URL in a GitHub API Link header.
+var linkNextRe = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// githubOrgMembers returns the user names of the members of the GitHub
+// organization, using the token in $GITHUB_TOKEN if set. Without a token
+// only public members are visible.
+func githubOrgMembers(org string) ([]string, error) {
+	next := fmt.Sprintf("https://api.github.com/orgs/%s/members?per_page=100", url.PathEscape(org))
+	var members []string
+	for next != "" {
+		req, err := http.NewRequest("GET", next, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		var page []struct {
+			Login string `json:"login"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s: %s", next, resp.Status)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range page {
+			members = append(members, m.Login)
+		}
+
+		next = ""
+		if m := linkNextRe.FindStringSubmatch(resp.Header.Get("Link")); m != nil {
+			next = m[1]
+		}
+	}
+	return members, nil
+}
+
+// isTeamMember returns true if any of the author's emails, nickname or
+// GitHub user names are covered by the team.
+func isTeamMember(a author, team policy) bool {
+	for _, e := range a.emails {
+		if team.allows(e) {
+			return true
+		}
+	}
+	if a.nickname != "" && team.users.has(strings.ToLower(a.nickname)) {
+		return true
+	}
+	for _, u := range a.urls {
+		if user := githubProfileUser(u); user != "" && team.users.has(strings.ToLower(user)) {
+			return true
+		}
+	}
+	return false
+}
+
+// getTeam marks the authors who are members of the team.
+func getTeam(authors []author, team policy) {
+	for i := range authors {
+		authors[i].team = isTeamMember(authors[i], team)
+	}
+}
+
+// writeTeamStats writes the number of authors and commits by team members
+// and by the community.
+func writeTeamStats(w io.Writer, authors []author) error {
+	var counts [2]struct{ authors, commits int }
+	total := 0
+	for _, a := range authors {
+		i := 1
+		if a.team {
+			i = 0
+		}
+		counts[i].authors++
+		counts[i].commits += a.commits
+		total += a.commits
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%-10s %7s %7s\n", "", "authors", "commits")
+	for i, label := range []string{"team", "community"} {
+		pct := 0.0
+		if total > 0 {
+			pct = 100 * float64(counts[i].commits) / float64(total)
+		}
+		fmt.Fprintf(bw, "%-10s %7d %7d (%.0f%%)\n", label, counts[i].authors, counts[i].commits, pct)
+	}
+	return bw.Flush()
+}
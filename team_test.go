@@ -0,0 +1,106 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestIsTeamMember(t *testing.T) {
+	team := newPolicy([]string{"alice@example.com", "@core.example.com", "Bob", "carol"})
+	cases := []struct {
+		author   author
+		expected bool
+	}{
+		{author{emails: []string{"other@example.net", "ALICE@example.com"}}, true},
+		{author{emails: []string{"dave@core.example.com"}}, true},
+		{author{emails: []string{"123+bob@users.noreply.github.com"}}, true},
+		{author{nickname: "Carol", emails: []string{"carol@example.net"}}, true},
+		{author{urls: []string{"https://github.com/bob"}}, true},
+		{author{emails: []string{"eve@example.com"}, urls: []string{"https://eve.example.com/bob"}}, false},
+		{author{nickname: "eve", emails: []string{"eve@sub.core.example.com"}}, false},
+	}
+	for _, c := range cases {
+		if got := isTeamMember(c.author, team); got != c.expected {
+			t.Errorf("isTeamMember(%+v) = %v, expected %v", c.author, got, c.expected)
+		}
+	}
+}
+
+func TestWriteTeamStats(t *testing.T) {
+	authors := []author{
+		{name: "Alice A", commits: 6, team: true},
+		{name: "Bob B", commits: 3},
+		{name: "Carol C", commits: 1},
+	}
+	var buf bytes.Buffer
+	if err := writeTeamStats(&buf, authors); err != nil {
+		t.Fatal(err)
+	}
+	expected := "           authors commits\n" +
+		"team             1       6 (60%)\n" +
+		"community        2       4 (40%)\n"
+	if buf.String() != expected {
+		t.Errorf("team stats\n%s\nexpected\n%s", buf.String(), expected)
+	}
+}
+
+func TestTeamStats(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	team := writeTestFile(t, dir, "team", "# The core team\nalice@example.com\n")
+
+	out := mustRunMain(t, dir, "-team", team, "-team-stats")
+	if !containsLine(out, "team             1       2 (50%)") || !containsLine(out, "community        2       2 (50%)") {
+		t.Errorf("unexpected output\n%s", out)
+	}
+}
+
+func TestGithubOrgMembers(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/orgs/example/members" {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization %q", got)
+		}
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `[{"login": "carol"}]`)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%s/orgs/example/members?per_page=100&page=2>; rel="next", <%s/orgs/example/members?per_page=100&page=2>; rel="last"`, srv.URL, srv.URL))
+		fmt.Fprint(w, `[{"login": "alice"}, {"login": "bob"}]`)
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(c *http.Client) { httpClient = c }(httpClient)
+	httpClient = &http.Client{Transport: redirectTransport{u}}
+	t.Setenv("GITHUB_TOKEN", "secret")
+
+	members, err := githubOrgMembers("example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"alice", "bob", "carol"}; !reflect.DeepEqual(members, expected) {
+		t.Errorf("members %q, expected %q", members, expected)
+	}
+
+	if _, err := githubOrgMembers("missing"); err == nil {
+		t.Error("no error for a missing organization")
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the functions available in output templates. Functions
+// taking a list of authors take it as the last argument, so that they can
+// be chained in pipelines:
+//
+//	sortBy "key" authors     sorted copy; key is name, commits, geekrank,
+//	                         daysActive, dayStreak, weekStreak, firstYear
+//	                         or lastYear, prefixed by "-" for descending
+//	                         order
+//	filter "key" min authors authors whose numeric key is at least min
+//	top n authors            the first n authors
+//	initials name            "Jane Q. Doe" becomes "JQD"
+//	shortName name           "Jane Quinn Doe" becomes "J. Q. Doe"
+//	gravatarURL size email   Gravatar image URL
+//	markdownEscape s         s with Markdown special characters escaped
+//	obfuscateEmail email     "jane@example.com" becomes
+//	                         "jane at example dot com"
+//
+// For example, the ten most active contributors as a Markdown list:
+//
+//	{{range top 10 (sortBy "-commits" .Authors)}}
+//	- {{markdownEscape .DisplayName}} ({{.Commits}} commits)
+//	{{end}}
+var templateFuncs = template.FuncMap{
+	"sortBy":         sortViewsBy,
+	"filter":         filterViews,
+	"top":            topViews,
+	"initials":       initials,
+	"shortName":      shortName,
+	"gravatarURL":    func(size int, email string) string { return gravatarURL(email, size) },
+	"markdownEscape": markdownEscaper.Replace,
+	"obfuscateEmail": obfuscateEmail,
+}
+
+// viewKey returns the value of the named field, as a string or an int.
+func viewKey(v authorView, key string) (string, int, error) {
+	switch key {
+	case "name":
+		return strings.ToLower(v.Name), 0, nil
+	case "commits":
+		return "", v.Commits, nil
+	case "geekrank":
+		return "", v.Geekrank, nil
+	case "daysActive":
+		return "", v.DaysActive, nil
+	case "dayStreak":
+		return "", v.DayStreak, nil
+	case "weekStreak":
+		return "", v.WeekStreak, nil
+	case "firstYear":
+		return "", v.FirstYear, nil
+	case "lastYear":
+		return "", v.LastYear, nil
+	default:
+		return "", 0, fmt.Errorf("unknown key %q", key)
+	}
+}
+
+func sortViewsBy(key string, views []authorView) ([]authorView, error) {
+	desc := strings.HasPrefix(key, "-")
+	key = strings.TrimPrefix(key, "-")
+	if _, _, err := viewKey(authorView{}, key); err != nil {
+		return nil, err
+	}
+
+	sorted := make([]authorView, len(views))
+	copy(sorted, views)
+	sort.SliceStable(sorted, func(a, b int) bool {
+		as, ai, _ := viewKey(sorted[a], key)
+		bs, bi, _ := viewKey(sorted[b], key)
+		if desc {
+			as, ai, bs, bi = bs, bi, as, ai
+		}
+		if as != bs {
+			return as < bs
+		}
+		if ai != bi {
+			return ai < bi
+		}
+		return lessByView(sorted[a], sorted[b])
+	})
+	return sorted, nil
+}
+
+// lessByView is the tie-breaker for sortBy, ordering by name and then
+// first email like lessByName.
+func lessByView(a, b authorView) bool {
+	aname, bname := strings.ToLower(a.Name), strings.ToLower(b.Name)
+	if aname != bname {
+		return aname < bname
+	}
+	var ae, be string
+	if len(a.Emails) > 0 {
+		ae = strings.ToLower(a.Emails[0])
+	}
+	if len(b.Emails) > 0 {
+		be = strings.ToLower(b.Emails[0])
+	}
+	return ae < be
+}
+
+func filterViews(key string, min int, views []authorView) ([]authorView, error) {
+	var res []authorView
+	for _, v := range views {
+		_, n, err := viewKey(v, key)
+		if err != nil {
+			return nil, err
+		}
+		if n >= min {
+			res = append(res, v)
+		}
+	}
+	return res, nil
+}
+
+func topViews(n int, views []authorView) []authorView {
+	if n < 0 {
+		return nil
+	}
+	if n < len(views) {
+		return views[:n]
+	}
+	return views
+}
+
+// obfuscateEmail spells out the punctuation of the address, to make
+// harvesting slightly harder.
+func obfuscateEmail(email string) string {
+	return strings.NewReplacer("@", " at ", ".", " dot ").Replace(email)
+}
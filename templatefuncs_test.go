@@ -0,0 +1,158 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testViews = []authorView{
+	{Name: "Carol", Emails: []string{"carol@example.com"}, Commits: 5, Geekrank: 2, DaysActive: 3, DayStreak: 2, WeekStreak: 1, FirstYear: 2016, LastYear: 2020},
+	{Name: "alice", Emails: []string{"alice@example.com"}, Commits: 10, Geekrank: 3, DaysActive: 8, DayStreak: 4, WeekStreak: 3, FirstYear: 2015, LastYear: 2021},
+	{Name: "Bob", Emails: []string{"bob@b.example.com"}, Commits: 5, Geekrank: 2, DaysActive: 5, DayStreak: 1, WeekStreak: 2, FirstYear: 2018, LastYear: 2018},
+	{Name: "Bob", Emails: []string{"bob@a.example.com"}, Commits: 1, Geekrank: 0, DaysActive: 1, DayStreak: 1, WeekStreak: 1, FirstYear: 2019, LastYear: 2019},
+}
+
+// viewIDs returns the first email of each view, identifying it.
+func viewIDs(views []authorView) []string {
+	ids := make([]string, len(views))
+	for i, v := range views {
+		ids[i] = v.Emails[0]
+	}
+	return ids
+}
+
+func TestViewKey(t *testing.T) {
+	v := testViews[1]
+	cases := []struct {
+		key string
+		str string
+		num int
+	}{
+		{"name", "alice", 0},
+		{"commits", "", 10},
+		{"geekrank", "", 3},
+		{"daysActive", "", 8},
+		{"dayStreak", "", 4},
+		{"weekStreak", "", 3},
+		{"firstYear", "", 2015},
+		{"lastYear", "", 2021},
+	}
+	for _, tc := range cases {
+		str, num, err := viewKey(v, tc.key)
+		if err != nil {
+			t.Errorf("viewKey(%q): unexpected error %v", tc.key, err)
+			continue
+		}
+		if str != tc.str || num != tc.num {
+			t.Errorf("viewKey(%q) = %q, %d; want %q, %d", tc.key, str, num, tc.str, tc.num)
+		}
+	}
+
+	if _, _, err := viewKey(v, "email"); err == nil {
+		t.Error("viewKey(\"email\"): expected an error")
+	}
+}
+
+func TestSortBy(t *testing.T) {
+	cases := []struct {
+		key  string
+		want []string
+	}{
+		// Names compare case insensitively; the same names by email
+		{"name", []string{"alice@example.com", "bob@a.example.com", "bob@b.example.com", "carol@example.com"}},
+		// Ties are broken by name and email, also when descending
+		{"commits", []string{"bob@a.example.com", "bob@b.example.com", "carol@example.com", "alice@example.com"}},
+		{"-commits", []string{"alice@example.com", "bob@b.example.com", "carol@example.com", "bob@a.example.com"}},
+		{"-daysActive", []string{"alice@example.com", "bob@b.example.com", "carol@example.com", "bob@a.example.com"}},
+		{"firstYear", []string{"alice@example.com", "carol@example.com", "bob@b.example.com", "bob@a.example.com"}},
+		{"-name", []string{"carol@example.com", "bob@a.example.com", "bob@b.example.com", "alice@example.com"}},
+	}
+	for _, tc := range cases {
+		sorted, err := sortViewsBy(tc.key, testViews)
+		if err != nil {
+			t.Errorf("sortBy %q: unexpected error %v", tc.key, err)
+			continue
+		}
+		if got := viewIDs(sorted); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("sortBy %q = %v; want %v", tc.key, got, tc.want)
+		}
+	}
+
+	if got := viewIDs(testViews); got[0] != "carol@example.com" {
+		t.Error("sortBy modified its argument")
+	}
+	if _, err := sortViewsBy("-nope", testViews); err == nil {
+		t.Error("sortBy \"-nope\": expected an error")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	cases := []struct {
+		key  string
+		min  int
+		want []string
+	}{
+		{"commits", 5, []string{"carol@example.com", "alice@example.com", "bob@b.example.com"}},
+		{"commits", 11, []string{}},
+		{"lastYear", 2019, []string{"carol@example.com", "alice@example.com", "bob@a.example.com"}},
+		{"geekrank", 0, []string{"carol@example.com", "alice@example.com", "bob@b.example.com", "bob@a.example.com"}},
+	}
+	for _, tc := range cases {
+		filtered, err := filterViews(tc.key, tc.min, testViews)
+		if err != nil {
+			t.Errorf("filter %q %d: unexpected error %v", tc.key, tc.min, err)
+			continue
+		}
+		if got := viewIDs(filtered); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("filter %q %d = %v; want %v", tc.key, tc.min, got, tc.want)
+		}
+	}
+
+	if _, err := filterViews("nope", 1, testViews); err == nil {
+		t.Error("filter \"nope\": expected an error")
+	}
+}
+
+func TestTop(t *testing.T) {
+	cases := []struct {
+		n    int
+		want int
+	}{
+		{2, 2},
+		{4, 4},
+		{10, 4},
+		{0, 0},
+		{-1, 0},
+	}
+	for _, tc := range cases {
+		if got := len(topViews(tc.n, testViews)); got != tc.want {
+			t.Errorf("top %d gave %d authors; want %d", tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestInitials(t *testing.T) {
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"Jane Q. Doe", "JQD"},
+		{"Jean-Luc Picard", "JLP"},
+		{"ludwig van beethoven", "LVB"},
+		{"Émile Zola", "ÉZ"},
+		{"alice", "A"},
+		{"  ", ""},
+		{"42 (bot)", ""},
+	}
+	for _, tc := range cases {
+		if got := initials(tc.name); got != tc.want {
+			t.Errorf("initials(%q) = %q; want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ANSI escape sequences for terminal output.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+)
+
+// isTerminal returns true if the file is a terminal, or at least a
+// character device, which is what we care about.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalColors returns true if stdout is a terminal that wants color,
+// honouring the NO_COLOR convention.
+func terminalColors(noColor bool) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(os.Stdout)
+}
+
+// colorsFor returns true if output to w should be colored: it's stdout,
+// and that is a terminal that wants color. Output files never are.
+func colorsFor(w io.Writer, opts *options) bool {
+	f, ok := w.(*os.File)
+	return ok && f == os.Stdout && opts.color
+}
+
+// isBot returns true if the name is that of a bot account, as in
+// "dependabot[bot]".
+func isBot(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), "[bot]")
+}
+
+// heatColor returns the color for the rank, out of the highest one: red
+// for the top third, yellow for the middle and green for the rest.
+func heatColor(rank, max int) string {
+	switch {
+	case max > 0 && rank*3 >= max*2:
+		return ansiBold + ansiRed
+	case max > 0 && rank*3 >= max:
+		return ansiYellow
+	default:
+		return ansiGreen
+	}
+}
+
+// startPager sends stdout through $PAGER, by default less, when it's a
+// terminal. Like git, less is told to exit if the output fits on one
+// screen and to pass colors through. The returned function restores
+// stdout and waits for the pager to exit.
+func startPager() func() {
+	if !isTerminal(os.Stdout) {
+		return func() {}
+	}
+	pager, ok := os.LookupEnv("PAGER")
+	if !ok {
+		pager = "less"
+	}
+	if pager == "" || pager == "cat" {
+		return func() {}
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		log.Println("Pager:", err)
+		return func() {}
+	}
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = r
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+	if err := cmd.Start(); err != nil {
+		log.Println("Pager:", err)
+		r.Close()
+		w.Close()
+		return func() {}
+	}
+	r.Close()
+
+	stdout := os.Stdout
+	os.Stdout = w
+	return func() {
+		os.Stdout = stdout
+		w.Close()
+		cmd.Wait()
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// withTerminalStdout runs fn with stdout set to the null device, which is
+// a character device and so taken for a terminal.
+func withTerminalStdout(t *testing.T, fn func()) {
+	t.Helper()
+	null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer null.Close()
+	defer func(f *os.File) { os.Stdout = f }(os.Stdout)
+	os.Stdout = null
+	fn()
+}
+
+func TestTerminalColors(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm")
+
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	fd, err := os.Create(writeTestFile(t, dir, "out", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	defer func(f *os.File) { os.Stdout = f }(os.Stdout)
+	os.Stdout = fd
+	if terminalColors(false) {
+		t.Error("colors for a file")
+	}
+
+	withTerminalStdout(t, func() {
+		if !terminalColors(false) {
+			t.Error("no colors for a terminal")
+		}
+		if terminalColors(true) {
+			t.Error("colors with -no-color")
+		}
+		t.Setenv("TERM", "dumb")
+		if terminalColors(false) {
+			t.Error("colors for a dumb terminal")
+		}
+		t.Setenv("TERM", "xterm")
+		t.Setenv("NO_COLOR", "1")
+		if terminalColors(false) {
+			t.Error("colors with NO_COLOR")
+		}
+
+		opts := &options{color: true}
+		if !colorsFor(os.Stdout, opts) || colorsFor(new(bytes.Buffer), opts) || colorsFor(fd, opts) {
+			t.Error("colors for the wrong writer")
+		}
+	})
+}
+
+func TestHeatColor(t *testing.T) {
+	cases := []struct {
+		rank, max int
+		expected  string
+	}{
+		{9, 9, ansiBold + ansiRed},
+		{6, 9, ansiBold + ansiRed},
+		{5, 9, ansiYellow},
+		{3, 9, ansiYellow},
+		{2, 9, ansiGreen},
+		{0, 0, ansiGreen},
+	}
+	for _, c := range cases {
+		if color := heatColor(c.rank, c.max); color != c.expected {
+			t.Errorf("heatColor(%d, %d) = %q, expected %q", c.rank, c.max, color, c.expected)
+		}
+	}
+}
+
+func TestColorStats(t *testing.T) {
+	authors := []author{
+		{name: "Alice A", commits: 10, geekrank: 9},
+		{name: "dependabot[bot]", commits: 3, geekrank: 2},
+	}
+	buf := new(bytes.Buffer)
+	if err := writeStats(buf, authors, false, true); err != nil {
+		t.Fatal(err)
+	}
+	expected := "   10 " + ansiBold + ansiRed + " 9" + ansiReset + " Alice A\n" +
+		ansiDim + "    3  2 dependabot[bot]" + ansiReset + "\n"
+	if buf.String() != expected {
+		t.Errorf("output %q, expected %q", buf.String(), expected)
+	}
+}
+
+func TestPager(t *testing.T) {
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	out := filepath.Join(dir, "out")
+	t.Setenv("PAGER", "(echo \"$LESS\"; cat) > "+shellQuote(out))
+	t.Setenv("LESS", "")
+	os.Unsetenv("LESS")
+
+	withTerminalStdout(t, func() {
+		stop := startPager()
+		fmt.Fprintln(os.Stdout, "paged")
+		stop()
+	})
+	if bs, err := ioutil.ReadFile(out); err != nil || string(bs) != "FRX\npaged\n" {
+		t.Errorf("paged %q, %v", bs, err)
+	}
+
+	// No pager
+	for _, pager := range []string{"", "cat"} {
+		t.Setenv("PAGER", pager)
+		withTerminalStdout(t, func() {
+			stdout := os.Stdout
+			stop := startPager()
+			if os.Stdout != stdout {
+				t.Errorf("stdout replaced with PAGER=%q", pager)
+			}
+			stop()
+		})
+	}
+
+	// Not a terminal
+	t.Setenv("PAGER", "false")
+	fd, err := os.Create(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	defer func(f *os.File) { os.Stdout = f }(os.Stdout)
+	os.Stdout = fd
+	stop := startPager()
+	if os.Stdout != fd {
+		t.Error("stdout replaced when not a terminal")
+	}
+	stop()
+}
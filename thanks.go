@@ -0,0 +1,85 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// defaultThanksTemplate renders the THANKS file when there is no
+// -thanks-template.
+const defaultThanksTemplate = `This project is grateful to the people below, who contributed in other
+ways than as authors of its code. The authors are listed in AUTHORS.
+{{range .Groups}}
+{{.Title}}:
+{{range .People}}
+    {{.Name}}{{end}}
+{{end}}`
+
+// thanksData is the data passed to THANKS templates: the people credited
+// in each kind of trailer, and all of them together.
+type thanksData struct {
+	Groups []thanksGroup
+	People []thanksPerson
+}
+
+type thanksGroup struct {
+	Key    string // the trailer key, like "Reported-by"
+	Title  string // the same for people, like "Reported by"
+	People []thanksPerson
+}
+
+type thanksPerson struct {
+	Name  string
+	Email string
+	Count int
+}
+
+// getThanks returns the people credited in trailers who are not authors,
+// grouped in the order of the keys. People credited under several keys
+// are in each group but listed once in People.
+func getThanks(credits map[string][]credit, keys []string) thanksData {
+	var data thanksData
+	seen := make(stringSet)
+	for _, key := range keys {
+		g := thanksGroup{Key: key, Title: creditTitle(key)}
+		for _, c := range credits[key] {
+			if c.author {
+				continue
+			}
+			p := thanksPerson{Name: c.name, Email: c.email, Count: c.count}
+			g.People = append(g.People, p)
+			if id := strings.ToLower(c.email); !seen.has(id) {
+				seen.add(id)
+				data.People = append(data.People, p)
+			}
+		}
+		if len(g.People) > 0 {
+			data.Groups = append(data.Groups, g)
+		}
+	}
+	return data
+}
+
+// writeThanks executes the template in the file, or the default one if
+// the file is empty, with the non-author credits.
+func writeThanks(w io.Writer, file string, credits map[string][]credit, keys []string) error {
+	var tpl *template.Template
+	var err error
+	if file == "" {
+		tpl, err = template.New("THANKS").Funcs(templateFuncs).Parse(defaultThanksTemplate)
+	} else {
+		tpl, err = template.New(filepath.Base(file)).Funcs(templateFuncs).ParseFiles(file)
+	}
+	if err != nil {
+		return err
+	}
+	return tpl.Execute(w, getThanks(credits, keys))
+}
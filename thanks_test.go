@@ -0,0 +1,62 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetThanks(t *testing.T) {
+	credits := map[string][]credit{
+		"Reported-by": {
+			{name: "Jane Doe", email: "jane@example.com", count: 2},
+			{name: "Bob B", email: "bob@example.com", count: 1, author: true},
+		},
+		"Tested-by": {
+			{name: "Jane D", email: "JANE@example.com", count: 1},
+			{name: "Zed", email: "zed@example.com", count: 3},
+		},
+		"Reviewed-by": {
+			{name: "Bob B", email: "bob@example.com", count: 1, author: true},
+		},
+	}
+	data := getThanks(credits, []string{"Tested-by", "Reviewed-by", "Reported-by"})
+
+	expected := thanksData{
+		Groups: []thanksGroup{
+			{Key: "Tested-by", Title: "Tested by", People: []thanksPerson{{"Jane D", "JANE@example.com", 1}, {"Zed", "zed@example.com", 3}}},
+			{Key: "Reported-by", Title: "Reported by", People: []thanksPerson{{"Jane Doe", "jane@example.com", 2}}},
+		},
+		People: []thanksPerson{{"Jane D", "JANE@example.com", 1}, {"Zed", "zed@example.com", 3}},
+	}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("thanks %+v, expected %+v", data, expected)
+	}
+}
+
+func TestThanks(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Alice A <alice@example.com>", message: "One\n\nReported-by: Jane Doe <jane@example.com>\nReported-by: Bob B <bob@example.com>"},
+		testCommit{author: "Bob B <bob@example.com>", message: "Two\n\nTested-by: Zed <zed@example.com>"},
+	)
+	defer cleanup()
+
+	out := mustRunMain(t, dir, "-credit-trailers", "Reported-by,Tested-by", "-thanks")
+	expected := "This project is grateful to the people below, who contributed in other\n" +
+		"ways than as authors of its code. The authors are listed in AUTHORS.\n" +
+		"\nReported by:\n\n    Jane Doe\n" +
+		"\nTested by:\n\n    Zed\n"
+	if out != expected {
+		t.Errorf("output\n%s\nexpected\n%s", out, expected)
+	}
+
+	tpl := writeTestFile(t, dir, "thanks.tpl", "{{range .People}}{{.Name}} <{{.Email}}>{{\"\\n\"}}{{end}}")
+	if out := mustRunMain(t, dir, "-credit-trailers", "Reported-by,Tested-by", "-thanks", "-thanks-template", tpl); out != "Jane Doe <jane@example.com>\nZed <zed@example.com>\n" {
+		t.Errorf("unexpected output with -thanks-template\n%s", out)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultCreditTrailers are the commit message trailers giving credit to
+// people other than the commit author.
+var defaultCreditTrailers = []string{"Reported-by", "Suggested-by", "Translated-by"}
+
+var trailerRe = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*):\s*(.*?)\s*<([^>\s]+)>\s*$`)
+
+// A trailer is a "Key: Name <email>" line in a commit message.
+type trailer struct {
+	key   string
+	name  string
+	email string
+}
+
+// parseTrailers returns the person trailers in the commit message.
+func parseTrailers(message string) []trailer {
+	var trailers []trailer
+	for _, line := range strings.Split(message, "\n") {
+		if m := trailerRe.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			trailers = append(trailers, trailer{key: m[1], name: m[2], email: m[3]})
+		}
+	}
+	return trailers
+}
+
+// A credit is a person credited in a trailer, with the number of commits
+// crediting them.
+type credit struct {
+	name   string
+	email  string
+	count  int
+	author bool // also an author of commits
+}
+
+// trailerPerson returns the display name and a key identifying the person
+// in the trailer, and whether they are one of the authors.
+func trailerPerson(t trailer, authors []author, idx *authorIndex) (string, string, bool) {
+	if i, ok := idx.email(t.email); ok {
+		return authors[i].displayName(), authors[i].id(), true
+	}
+	if i, ok := idx.name(t.name); ok {
+		return authors[i].displayName(), authors[i].id(), true
+	}
+	return t.name, strings.ToLower(t.email), false
+}
+
+// getCredits returns, for each of the trailer keys, the people credited
+// in the commits, most credited first. People who are also authors are
+// given their author display name.
+func getCredits(commits []commit, authors []author, idx *authorIndex, keys []string) map[string][]credit {
+	wanted := make(map[string]string)
+	for _, k := range keys {
+		wanted[strings.ToLower(k)] = k
+	}
+
+	// key -> person -> credit
+	found := make(map[string]map[string]*credit)
+	for _, c := range commits {
+		for _, t := range parseTrailers(c.message) {
+			key, ok := wanted[strings.ToLower(t.key)]
+			if !ok {
+				continue
+			}
+			name, person, isAuthor := trailerPerson(t, authors, idx)
+			if found[key] == nil {
+				found[key] = make(map[string]*credit)
+			}
+			if found[key][person] == nil {
+				found[key][person] = &credit{name: name, email: t.email, author: isAuthor}
+			}
+			found[key][person].count++
+		}
+	}
+
+	res := make(map[string][]credit)
+	for key, people := range found {
+		for _, c := range people {
+			res[key] = append(res[key], *c)
+		}
+		sort.SliceStable(res[key], func(a, b int) bool {
+			ca, cb := res[key][a], res[key][b]
+			if ca.count != cb.count {
+				return ca.count > cb.count
+			}
+			if an, bn := strings.ToLower(ca.name), strings.ToLower(cb.name); an != bn {
+				return an < bn
+			}
+			return strings.ToLower(ca.email) < strings.ToLower(cb.email)
+		})
+	}
+	return res
+}
+
+// creditTitle turns a trailer key like "Reported-by" into "Reported by".
+func creditTitle(key string) string {
+	return strings.ReplaceAll(key, "-", " ")
+}
+
+// writeCredits writes one line per trailer key, listing the people
+// credited.
+func writeCredits(w io.Writer, credits map[string][]credit, keys []string) error {
+	bw := bufio.NewWriter(w)
+	for _, key := range keys {
+		people := credits[key]
+		if len(people) == 0 {
+			continue
+		}
+		names := make([]string, len(people))
+		for i, c := range people {
+			names[i] = fmt.Sprintf("%s (%d)", c.name, c.count)
+		}
+		fmt.Fprintf(bw, "%s: %s\n", creditTitle(key), strings.Join(names, ", "))
+	}
+	return bw.Flush()
+}
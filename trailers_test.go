@@ -0,0 +1,48 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTrailers(t *testing.T) {
+	msg := "Fix the thing\n\nIt was broken: really <not a trailer>.\n\n" +
+		"Reported-by: Jane Doe <jane@example.com>\n" +
+		"  Translated-by:  Jörg  <jorg@example.com> \n" +
+		"Signed-off-by: <anon@example.com>\n" +
+		"Fixes: #123\n"
+	expected := []trailer{
+		{key: "Reported-by", name: "Jane Doe", email: "jane@example.com"},
+		{key: "Translated-by", name: "Jörg", email: "jorg@example.com"},
+		{key: "Signed-off-by", name: "", email: "anon@example.com"},
+	}
+	if got := parseTrailers(msg); !reflect.DeepEqual(got, expected) {
+		t.Errorf("parsed %+v, expected %+v", got, expected)
+	}
+}
+
+func TestCredits(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Alice A <alice@example.com>", message: "One\n\nReported-by: Jane Doe <jane@example.com>\nSuggested-by: bob  b <bob@laptop.local>"},
+		testCommit{author: "Bob B <bob@example.com>", message: "Two\n\nreported-by: Jane D <JANE@example.com>\nReported-by: Zed <zed@example.com>"},
+		testCommit{author: "Bob B <bob@example.com>", message: "Three\n\nTested-by: Carol <carol@example.com>"},
+	)
+	defer cleanup()
+
+	// Jane is credited under the most recent name, Bob is matched by name
+	// and credited under the author name
+	out := mustRunMain(t, dir, "-credits")
+	if out != "Reported by: Jane D (2), Zed (1)\nSuggested by: Bob B (1)\n" {
+		t.Errorf("unexpected output\n%s", out)
+	}
+	out = mustRunMain(t, dir, "-credits", "-credit-trailers", "Tested-by")
+	if out != "Tested by: Carol (1)\n" {
+		t.Errorf("unexpected output with -credit-trailers\n%s", out)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// A trend compares the current window of time with the one before it.
+type trend struct {
+	Window    string      `json:"window"`
+	Current   trendWindow `json:"current"`
+	Previous  trendWindow `json:"previous"`
+	Newcomers []string    `json:"newcomers"` // first commit in the current window
+	Dropouts  []string    `json:"dropouts"`  // active in the previous window only
+	Retained  int         `json:"retained"`  // active in both windows
+}
+
+type trendWindow struct {
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+	Contributors int       `json:"contributors"`
+	Commits      int       `json:"commits"`
+}
+
+// churn is the share of the previous window's contributors that dropped
+// out, in percent.
+func (t trend) churn() float64 {
+	if t.Previous.Contributors == 0 {
+		return 0
+	}
+	return 100 * float64(len(t.Dropouts)) / float64(t.Previous.Contributors)
+}
+
+// getTrend compares the window before now with the window before that.
+// The authors are expected to be sorted, which is the order the newcomers
+// and dropouts are listed in.
+func getTrend(authors []author, idx *authorIndex, commits []commit, window string, now time.Time) (*trend, error) {
+	mid, err := spanBefore(now, window)
+	if err != nil {
+		return nil, err
+	}
+	start, _ := spanBefore(mid, window)
+
+	t := &trend{
+		Window:    window,
+		Current:   trendWindow{From: mid, To: now},
+		Previous:  trendWindow{From: start, To: mid},
+		Newcomers: []string{},
+		Dropouts:  []string{},
+	}
+	cur := make([]int, len(authors))
+	prev := make([]int, len(authors))
+	for _, c := range commits {
+		i, ok := idx.email(c.email)
+		if !ok || c.date.Before(start) || c.date.After(now) {
+			continue
+		}
+		if c.date.Before(mid) {
+			prev[i]++
+			t.Previous.Commits++
+		} else {
+			cur[i]++
+			t.Current.Commits++
+		}
+	}
+
+	for i, a := range authors {
+		if cur[i] > 0 {
+			t.Current.Contributors++
+		}
+		if prev[i] > 0 {
+			t.Previous.Contributors++
+		}
+		switch {
+		case cur[i] > 0 && prev[i] > 0:
+			t.Retained++
+		case cur[i] > 0 && !a.first.Before(mid):
+			t.Newcomers = append(t.Newcomers, a.displayName())
+		case prev[i] > 0:
+			t.Dropouts = append(t.Dropouts, a.displayName())
+		}
+	}
+	return t, nil
+}
+
+// writeTrend writes the comparison in human readable form.
+func writeTrend(w io.Writer, t *trend) error {
+	if t == nil {
+		return nil
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%-14s %12s %12s %7s\n", "", t.Previous.From.Format("2006-01-02")+"-", t.Current.From.Format("2006-01-02")+"-", "change")
+	fmt.Fprintf(bw, "%-14s %12d %12d %+7d\n", "contributors", t.Previous.Contributors, t.Current.Contributors, t.Current.Contributors-t.Previous.Contributors)
+	fmt.Fprintf(bw, "%-14s %12d %12d %+7d\n", "commits", t.Previous.Commits, t.Current.Commits, t.Current.Commits-t.Previous.Commits)
+	fmt.Fprintf(bw, "Retained: %d, churn %.0f%%\n", t.Retained, t.churn())
+	fmt.Fprintf(bw, "Newcomers (%d): %s\n", len(t.Newcomers), strings.Join(t.Newcomers, ", "))
+	fmt.Fprintf(bw, "Dropouts (%d): %s\n", len(t.Dropouts), strings.Join(t.Dropouts, ", "))
+	return bw.Flush()
+}
+
+// writeTrendJSON writes the comparison as indented JSON.
+func writeTrendJSON(w io.Writer, t *trend) error {
+	if t == nil {
+		return nil
+	}
+	v := struct {
+		*trend
+		Churn float64 `json:"churn"`
+	}{t, t.churn()}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTrend(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	authors := []author{
+		{name: "Alice A", emails: []string{"alice@example.com"}, first: day("2019-01-01")},
+		{name: "Bob B", emails: []string{"bob@example.com"}, first: day("2019-06-01")},
+		{name: "Carol C", emails: []string{"carol@example.com"}, first: day("2020-03-01")},
+		{name: "Dave D", emails: []string{"dave@example.com"}, first: day("2019-02-01")},
+		{name: "Eve E", emails: []string{"eve@example.com"}, first: day("2018-01-01")},
+	}
+	commits := []commit{
+		{email: "alice@example.com", date: day("2020-05-01")},
+		{email: "alice@example.com", date: day("2019-11-01")},
+		{email: "bob@example.com", date: day("2019-10-01")},
+		{email: "carol@example.com", date: day("2020-03-01")},
+		{email: "carol@example.com", date: day("2020-04-01")},
+		// Back after a break, so neither new nor retained
+		{email: "dave@example.com", date: day("2020-02-01")},
+		// Before both windows, and an unknown email
+		{email: "eve@example.com", date: day("2019-01-01")},
+		{email: "unknown@example.com", date: day("2020-05-01")},
+	}
+
+	tr, err := getTrend(authors, newAuthorIndex(authors), commits, "6m", day("2020-06-01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &trend{
+		Window:    "6m",
+		Current:   trendWindow{From: day("2019-12-01"), To: day("2020-06-01"), Contributors: 3, Commits: 4},
+		Previous:  trendWindow{From: day("2019-06-01"), To: day("2019-12-01"), Contributors: 2, Commits: 2},
+		Newcomers: []string{"Carol C"},
+		Dropouts:  []string{"Bob B"},
+		Retained:  1,
+	}
+	if !reflect.DeepEqual(tr, expected) {
+		t.Errorf("trend\n%+v\nexpected\n%+v", tr, expected)
+	}
+
+	var buf bytes.Buffer
+	if err := writeTrend(&buf, tr); err != nil {
+		t.Fatal(err)
+	}
+	text := "                2019-06-01-  2019-12-01-  change\n" +
+		"contributors              2            3      +1\n" +
+		"commits                   2            4      +2\n" +
+		"Retained: 1, churn 50%\n" +
+		"Newcomers (1): Carol C\n" +
+		"Dropouts (1): Bob B\n"
+	if buf.String() != text {
+		t.Errorf("trend written as\n%s\nexpected\n%s", buf.String(), text)
+	}
+
+	buf.Reset()
+	if err := writeTrendJSON(&buf, tr); err != nil {
+		t.Fatal(err)
+	}
+	var v struct {
+		Window    string   `json:"window"`
+		Newcomers []string `json:"newcomers"`
+		Dropouts  []string `json:"dropouts"`
+		Churn     float64  `json:"churn"`
+		Current   struct {
+			Contributors int `json:"contributors"`
+		} `json:"current"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Window != "6m" || v.Churn != 50 || v.Current.Contributors != 3 || len(v.Newcomers) != 1 || len(v.Dropouts) != 1 {
+		t.Errorf("unexpected JSON\n%s", buf.Bytes())
+	}
+}
+
+func TestTrendWindow(t *testing.T) {
+	if _, err := getTrend(nil, newAuthorIndex(nil), nil, "6x", time.Now()); err == nil {
+		t.Error("no error for an invalid window")
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+)
+
+// Kinds of warnings.
+const (
+	warnNameMatch      = "name-match"      // an email merged into an author by name only
+	warnMalformedEntry = "malformed-entry" // an AUTHORS line that isn't a proper entry
+	warnBadExclude     = "bad-exclude"     // an exclude or import entry that isn't a commit
+	warnReview         = "review"          // a review line that can't be applied as is
+	warnCache          = "cache"           // a problem with a cache file
+	warnProfile        = "profile"         // a contributors.d file that can't be applied
+	warnVersion        = "version"         // a file generated by an incompatible version
+	warnEstimate       = "estimate"        // the results are from a sample of the commits
+)
+
+// A warning is a problem the user should look at, though it didn't stop
+// us. Warnings are logged as they happen and collected for
+// -warnings-json, so that automation can show them to people.
+type warning struct {
+	Kind    string `json:"kind"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+func (w warning) String() string {
+	switch {
+	case w.File != "" && w.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", w.File, w.Line, w.Message)
+	case w.File != "":
+		return fmt.Sprintf("%s: %s", w.File, w.Message)
+	default:
+		return w.Message
+	}
+}
+
+var (
+	warningsMut  sync.Mutex
+	warnings     []warning
+	warningsFile string // where to write the warnings on exit, if set
+)
+
+// warn logs and collects a warning.
+func warn(w warning) {
+	log.Printf("Warning: %s", w)
+	warningsMut.Lock()
+	warnings = append(warnings, w)
+	warningsMut.Unlock()
+}
+
+// warnf logs and collects a warning not about any particular file.
+func warnf(kind, format string, args ...interface{}) {
+	warn(warning{Kind: kind, Message: fmt.Sprintf(format, args...)})
+}
+
+// writeWarningsJSON writes the warnings so far as a JSON array.
+func writeWarningsJSON(w io.Writer) error {
+	warningsMut.Lock()
+	defer warningsMut.Unlock()
+	res := warnings
+	if res == nil {
+		res = []warning{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(res)
+}
+
+// flushWarnings writes the warnings file, if one was asked for.
+func flushWarnings() {
+	if warningsFile == "" {
+		return
+	}
+	if err := writeFileAtomic(warningsFile, false, writeWarningsJSON); err != nil {
+		log.Println("Writing warnings:", err)
+	}
+}
+
+// exit writes the warnings file, if any, and exits with the code.
+func exit(code int) {
+	flushWarnings()
+	os.Exit(code)
+}
+
+// fatal logs like log.Fatal, then writes the warnings file, if any, and
+// exits, so that -warnings-json is there also for failed runs.
+func fatal(v ...interface{}) {
+	log.Print(v...)
+	exit(1)
+}
+
+// fatalf logs like log.Fatalf, then writes the warnings file and exits.
+func fatalf(format string, v ...interface{}) {
+	log.Printf(format, v...)
+	exit(1)
+}
@@ -0,0 +1,81 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestWarningString(t *testing.T) {
+	cases := []struct {
+		w        warning
+		expected string
+	}{
+		{warning{File: "AUTHORS", Line: 3, Message: "no email"}, "AUTHORS:3: no email"},
+		{warning{File: "AUTHORS", Message: "no email"}, "AUTHORS: no email"},
+		{warning{Message: "no email"}, "no email"},
+	}
+	for _, c := range cases {
+		if s := c.w.String(); s != c.expected {
+			t.Errorf("String() = %q, expected %q", s, c.expected)
+		}
+	}
+}
+
+func TestWarningsJSON(t *testing.T) {
+	commits := append(threeAuthors[:len(threeAuthors):len(threeAuthors)],
+		testCommit{author: "Alice A <alice@laptop.local>", date: "2020-05-01T12:00:00Z", message: "From the laptop"})
+	dir, cleanup := newHistoryRepo(t, commits...)
+	defer cleanup()
+	authors := writeTestFile(t, dir, "AUTHORS", "Alice A <alice@example.com>\nBob B without an email\n")
+	excludes := writeTestFile(t, dir, "excludes", "deadbeef\n")
+	file := filepath.Join(dir, "warnings.json")
+
+	read := func() []warning {
+		t.Helper()
+		bs, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var res []warning
+		if err := json.Unmarshal(bs, &res); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	mustRunMain(t, dir, "-read-authors", authors, "-exclude-commits", excludes, "-warnings-json", file, "-stats")
+	kinds := make(map[string]warning)
+	for _, w := range read() {
+		kinds[w.Kind] = w
+	}
+	if w := kinds[warnMalformedEntry]; w.File != authors || w.Line != 2 {
+		t.Errorf("malformed entry warning %+v", w)
+	}
+	if w := kinds[warnBadExclude]; w.File != excludes || w.Message != "ignoring deadbeef: not a commit" {
+		t.Errorf("bad exclude warning %+v", w)
+	}
+	if w := kinds[warnNameMatch]; w.Message != "Alice A <alice@laptop.local> merged into Alice A <alice@example.com> by name only" {
+		t.Errorf("name match warning %+v", w)
+	}
+
+	// Written also when failing
+	if _, _, code := runMain(t, dir, "-read-authors", authors, "-exclude-commits", excludes, "-strict", "-warnings-json", file, "-stats"); code == 0 {
+		t.Error("no failure with -strict")
+	}
+	if ws := read(); len(ws) == 0 {
+		t.Error("no warnings written for a failed run")
+	}
+
+	mustRunMain(t, dir, "-no-name-matching", "-warnings-json", file, "-stats")
+	if bs, err := ioutil.ReadFile(file); err != nil || string(bs) != "[]\n" {
+		t.Errorf("warnings %q, %v, expected none", bs, err)
+	}
+}
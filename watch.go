@@ -0,0 +1,115 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// watchInputs returns the files and directories given in the options that
+// the analysis and outputs depend on.
+func (opts *options) watchInputs() []string {
+	files := append([]string(nil), opts.authorsFiles...)
+	for _, p := range opts.inputs {
+		if *p != "" {
+			files = append(files, *p)
+		}
+	}
+	return files
+}
+
+// watchState describes what the analysis depends on: the refs, including
+// HEAD, and the size and modification time of the files, and of those in
+// the directories. Something has changed when it does.
+func watchState(files []string) string {
+	bs, err := exec.Command("git", "show-ref", "--head").Output()
+	var ee *exec.ExitError
+	if errors.As(err, &ee) && ee.ExitCode() == 1 && len(bs) == 0 {
+		// There are no refs at all in a repository without commits
+		err = nil
+	}
+	if err != nil {
+		fatal("git:", err)
+	}
+	var sb strings.Builder
+	sb.Write(bs)
+	for _, file := range files {
+		fi, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&sb, "%s %d %d\n", file, fi.Size(), fi.ModTime().UnixNano())
+		if fi.IsDir() {
+			fis, _ := ioutil.ReadDir(file)
+			for _, fi := range fis {
+				fmt.Fprintf(&sb, "%s/%s %d %d\n", file, fi.Name(), fi.Size(), fi.ModTime().UnixNano())
+			}
+		}
+	}
+	return sb.String()
+}
+
+// watchRunEnv is set for the runs of -watch, to do the analysis and
+// render the outputs instead of watching.
+const watchRunEnv = "GIT_CONTRIBUTORS_WATCH_RUN"
+
+// watchRun does the analysis and renders the outputs in a process of its
+// own, so that a failure, such as of a file that is being edited, doesn't
+// end the watch. It returns whether the run succeeded; the error has been
+// reported by then.
+func watchRun() bool {
+	exe, err := os.Executable()
+	if err != nil {
+		fatal(err)
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), watchRunEnv+"=1")
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run() == nil
+}
+
+// hasCommits returns whether HEAD points at a commit, which it doesn't in
+// a repository without commits.
+func hasCommits() bool {
+	return exec.Command("git", "rev-parse", "--verify", "--quiet", "HEAD").Run() == nil
+}
+
+// watch redoes the analysis and renders the outputs whenever the refs or
+// the input files change, checking at the interval, until interrupted.
+func watch(opts *options, interval time.Duration) {
+	if opts.check || opts.claFile != "" {
+		fatal("-watch can't be combined with -check or -cla")
+	}
+	files := opts.watchInputs()
+	var prev string
+	for first := true; ; first = false {
+		if cur := watchState(files); first || cur != prev {
+			prev = cur
+			if isTerminal(os.Stdout) {
+				fmt.Print("\x1b[H\x1b[2J") // clear screen
+			}
+			switch {
+			case !hasCommits():
+				log.Println("No commits yet; waiting for the first one")
+			case !watchRun():
+				log.Println("Failed; watching for changes to try again; press Ctrl-C to stop")
+			default:
+				log.Println("Watching for changes; press Ctrl-C to stop")
+			}
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t, threeAuthors...)
+	defer cleanup()
+	profiles := filepath.Join(dir, "contributors.d")
+	if err := os.Mkdir(profiles, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, profiles, "alice.yaml", "emails: [alice@example.com]\nname: Alice Profile\n")
+	modules := writeTestFile(t, dir, "modules", "*.txt all\n")
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	cmd := exec.Command(os.Args[0], "-names", "-profiles", profiles, "-modules", modules, "-watch", "-watch-interval", "10ms")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), runMainEnv+"=1")
+	cmd.Stdout = w
+	cmd.Stderr = w
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		sc := bufio.NewScanner(r)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+	}()
+	waitFor := func(what string) {
+		t.Helper()
+		timeout := time.After(10 * time.Second)
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					t.Fatalf("exited while waiting for %q", what)
+				}
+				if strings.Contains(line, what) {
+					return
+				}
+			case <-timeout:
+				t.Fatalf("timed out waiting for %q", what)
+			}
+		}
+	}
+
+	waitFor("Alice Profile")
+	waitFor("Watching for changes")
+
+	// A broken input fails the run, but not the watch
+	writeTestFile(t, profiles, "alice.yaml", "emails: [alice@example.com\n")
+	waitFor("Failed; watching for changes")
+	writeTestFile(t, profiles, "alice.yaml", "emails: [alice@example.com]\nname: Alice Again\n")
+	waitFor("Alice Again")
+	waitFor("Watching for changes")
+
+	writeTestFile(t, dir, "modules", "not a module line\n")
+	waitFor("Failed; watching for changes")
+}
@@ -0,0 +1,131 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// defaultWordCloudWords is the number of words per author in a word cloud
+// written with -out but without -word-cloud.
+const defaultWordCloudWords = 20
+
+// minWordLength is the length of the shortest word counted in the word
+// cloud; shorter words are rarely meaningful.
+const minWordLength = 3
+
+// stopwords are the words left out of the word cloud: common English
+// words, and the words every project's commit subjects are full of.
+var stopwords = make(stringSet)
+
+func init() {
+	for _, w := range strings.Fields(`
+		about above after again all also and any are because been before
+		being below between both but can cannot could did does doing down
+		during each else few for from further had has have having her here
+		hers him his how into its itself just more most not now off once
+		only other our ours out over own same she should some such than
+		that the their theirs them then there these they this those through
+		too under until use used uses using very via was way were what when
+		where which while who whom why will with within without would you
+		your yours
+		add added adds adding change changed changes commit commits fix
+		fixed fixes fixing make makes merge merged branch pull request
+		remove removed removes revert reverts update updated updates wip
+		feat chore docs refactor style test tests build perf
+	`) {
+		stopwords.add(w)
+	}
+}
+
+// subjectWords returns the lower cased words of the commit subject, leaving
+// out any subject prefix or fixup marker, stopwords, and words shorter than
+// minWordLength or containing digits.
+func subjectWords(message string) []string {
+	subject, _ := fixupTarget(commitSubject(message))
+	if subjectPrefix(subject) != "" {
+		subject = subject[strings.IndexByte(subject, ':')+1:]
+	}
+	var words []string
+	for _, w := range strings.FieldsFunc(subject, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		w = strings.ToLower(w)
+		if len([]rune(w)) < minWordLength || stopwords.has(w) || strings.IndexFunc(w, unicode.IsDigit) >= 0 {
+			continue
+		}
+		words = append(words, w)
+	}
+	return words
+}
+
+type wordCount struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+type authorWords struct {
+	ID    string      `json:"id"`
+	Name  string      `json:"name"`
+	Words []wordCount `json:"words"`
+}
+
+// getWordCloud returns the n most frequent words in the commit subjects of
+// each author, most frequent first. Authors without any words are left out.
+func getWordCloud(commits []commit, authors []author, n int) []authorWords {
+	idx := newAuthorIndex(authors)
+	counts := make([]map[string]int, len(authors))
+	for _, c := range commits {
+		i, ok := idx.email(c.email)
+		if !ok {
+			continue
+		}
+		for _, w := range subjectWords(c.message) {
+			if counts[i] == nil {
+				counts[i] = make(map[string]int)
+			}
+			counts[i][w]++
+		}
+	}
+
+	var res []authorWords
+	for i, a := range authors {
+		if len(counts[i]) == 0 {
+			continue
+		}
+		words := make([]wordCount, 0, len(counts[i]))
+		for w, c := range counts[i] {
+			words = append(words, wordCount{w, c})
+		}
+		sort.Slice(words, func(a, b int) bool {
+			if words[a].Count != words[b].Count {
+				return words[a].Count > words[b].Count
+			}
+			return words[a].Word < words[b].Word
+		})
+		if len(words) > n {
+			words = words[:n]
+		}
+		res = append(res, authorWords{ID: a.id(), Name: a.displayName(), Words: words})
+	}
+	return res
+}
+
+// writeWordCloud writes the most frequent words of each author as JSON.
+func writeWordCloud(w io.Writer, a *analysis, authors []author, n int) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	words := getWordCloud(a.commits, authors, n)
+	if words == nil {
+		words = []authorWords{}
+	}
+	return enc.Encode(words)
+}
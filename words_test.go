@@ -0,0 +1,57 @@
+// Copyright (C) 2015 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSubjectWords(t *testing.T) {
+	cases := []struct {
+		message string
+		words   []string
+	}{
+		{"Fix the parser crash on empty input", []string{"parser", "crash", "empty", "input"}},
+		{"lib/model: Don't index v2 folders\n\nBody words aren't counted.", []string{"don", "index", "folders"}},
+		{"fixup! Speed up the Lexer", []string{"speed", "lexer"}},
+		{"Go 1.18", nil},
+	}
+	for _, c := range cases {
+		if words := subjectWords(c.message); !reflect.DeepEqual(words, c.words) {
+			t.Errorf("subjectWords(%q) = %q, expected %q", c.message, words, c.words)
+		}
+	}
+}
+
+func TestWordCloud(t *testing.T) {
+	dir, cleanup := newHistoryRepo(t,
+		testCommit{author: "Alice A <alice@example.com>", message: "Fix parser crash"},
+		testCommit{author: "Alice A <alice@example.com>", message: "Parser speedup"},
+		testCommit{author: "Alice A <alice@example.com>", message: "Refactor parser tokens"},
+		testCommit{author: "Bob B <bob@example.com>", message: "Update the docs"},
+	)
+	defer cleanup()
+
+	out := exportImport(t, dir, "-word-cloud", "2")
+	var res []authorWords
+	if err := json.Unmarshal([]byte(out), &res); err != nil {
+		t.Fatal(err)
+	}
+	// Bob's subject is all stopwords, so Bob is left out
+	if len(res) != 1 || res[0].Name != "Alice A" {
+		t.Fatalf("unexpected authors in\n%s", out)
+	}
+	if expected := []wordCount{{"parser", 3}, {"crash", 1}}; !reflect.DeepEqual(res[0].Words, expected) {
+		t.Errorf("words %v, expected %v", res[0].Words, expected)
+	}
+
+	if _, stderr, code := runMain(t, dir, "-word-cloud", "-1"); code == 0 {
+		t.Errorf("negative -word-cloud accepted\n%s", stderr)
+	}
+}